@@ -4,25 +4,66 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/sourcegraph/checkup/check/amqp"
 	"github.com/sourcegraph/checkup/check/dns"
+	"github.com/sourcegraph/checkup/check/domain"
 	"github.com/sourcegraph/checkup/check/exec"
+	"github.com/sourcegraph/checkup/check/file"
+	"github.com/sourcegraph/checkup/check/group"
 	"github.com/sourcegraph/checkup/check/http"
+	"github.com/sourcegraph/checkup/check/httpflow"
+	"github.com/sourcegraph/checkup/check/kafka"
+	"github.com/sourcegraph/checkup/check/ldap"
+	"github.com/sourcegraph/checkup/check/metrics"
+	"github.com/sourcegraph/checkup/check/mqtt"
+	"github.com/sourcegraph/checkup/check/ntp"
+	"github.com/sourcegraph/checkup/check/process"
+	"github.com/sourcegraph/checkup/check/system"
 	"github.com/sourcegraph/checkup/check/tcp"
 	"github.com/sourcegraph/checkup/check/tls"
+	"github.com/sourcegraph/checkup/check/udp"
 )
 
 func checkerDecode(typeName string, config json.RawMessage) (Checker, error) {
 	switch typeName {
+	case amqp.Type:
+		return amqp.New(config)
 	case dns.Type:
 		return dns.New(config)
+	case domain.Type:
+		return domain.New(config)
 	case exec.Type:
 		return exec.New(config)
+	case file.Type:
+		return file.New(config)
+	case group.Type:
+		return group.New(config, func(typeName string, config json.RawMessage) (group.Member, error) {
+			return checkerDecode(typeName, config)
+		})
 	case http.Type:
 		return http.New(config)
+	case httpflow.Type:
+		return httpflow.New(config)
+	case kafka.Type:
+		return kafka.New(config)
+	case ldap.Type:
+		return ldap.New(config)
+	case metrics.Type:
+		return metrics.New(config)
+	case mqtt.Type:
+		return mqtt.New(config)
+	case ntp.Type:
+		return ntp.New(config)
+	case process.Type:
+		return process.New(config)
+	case system.Type:
+		return system.New(config)
 	case tcp.Type:
 		return tcp.New(config)
 	case tls.Type:
 		return tls.New(config)
+	case udp.Type:
+		return udp.New(config)
 	default:
 		return nil, fmt.Errorf(errUnknownCheckerType, typeName)
 	}
@@ -2,7 +2,6 @@ package dns
 
 import (
 	"encoding/json"
-	"fmt"
 	"net"
 	"time"
 
@@ -35,6 +34,22 @@ type Checker struct {
 	// Attempts is how many requests the client will
 	// make to the endpoint in a single check.
 	Attempts int `json:"attempts,omitempty"`
+
+	// Retries is how many times to retry a failed attempt
+	// before counting it as failed.
+	Retries int `json:"retries,omitempty"`
+
+	// RetryBackoff is how long to wait before the first
+	// retry of an attempt. The wait doubles for each
+	// further retry.
+	RetryBackoff time.Duration `json:"retry_backoff,omitempty"`
+
+	// Policy is how the status of the endpoint is
+	// concluded from the attempts.
+	types.Policy
+
+	// CheckerOptions are the options common to all checkers.
+	types.CheckerOptions
 }
 
 // New creates a new Checker instance based on json config
@@ -49,6 +64,11 @@ func (Checker) Type() string {
 	return Type
 }
 
+// EndpointName returns the name of the endpoint
+func (c Checker) EndpointName() string {
+	return c.Name
+}
+
 // Check performs checks using c according to its configuration.
 // An error is only returned if there is a configuration error.
 func (c Checker) Check() (types.Result, error) {
@@ -59,15 +79,17 @@ func (c Checker) Check() (types.Result, error) {
 	result := types.NewResult()
 	result.Title = c.Name
 	result.Endpoint = c.URL
+
+	if err := c.Policy.Validate(); err != nil {
+		return result, err
+	}
 	result.Times = c.doChecks()
 
-	return c.conclude(result), nil
+	return c.Policy.Conclude(result, c.ThresholdRTT), nil
 }
 
 // doChecks executes and returns each attempt.
 func (c Checker) doChecks() types.Attempts {
-	var conn net.Conn
-
 	timeout := c.Timeout
 	if timeout == 0 {
 		timeout = 1 * time.Second
@@ -75,58 +97,39 @@ func (c Checker) doChecks() types.Attempts {
 
 	checks := make(types.Attempts, c.Attempts)
 	for i := 0; i < c.Attempts; i++ {
-		var err error
-		start := time.Now()
-
-		if c.Host != "" {
-			hostname := c.Host
-			m1 := new(dns.Msg)
-			m1.Id = dns.Id()
-			m1.RecursionDesired = true
-			m1.Question = make([]dns.Question, 1)
-			m1.Question[0] = dns.Question{Name: hostname, Qtype: dns.TypeA, Qclass: dns.ClassINET}
-			d := new(dns.Client)
-			_, _, err := d.Exchange(m1, c.URL)
-			if err != nil {
-				checks[i].Error = err.Error()
-				continue
-			}
-		}
-		if conn, err = net.DialTimeout("tcp", c.URL, c.Timeout); err != nil {
-			checks[i].Error = err.Error()
-		} else {
-			conn.Close()
-		}
-		checks[i].RTT = time.Since(start)
+		checks[i] = types.Retry(c.Retries, c.RetryBackoff, func() types.Attempt {
+			return c.doCheck(timeout)
+		})
 	}
 	return checks
 }
 
-// conclude takes the data in result from the attempts and
-// computes remaining values needed to fill out the result.
-// It detects degraded (high-latency) responses and makes
-// the conclusion about the result's status.
-func (c Checker) conclude(result types.Result) types.Result {
-	result.ThresholdRTT = c.ThresholdRTT
-
-	// Check errors (down)
-	for i := range result.Times {
-		if result.Times[i].Error != "" {
-			result.Down = true
-			return result
+// doCheck queries and connects to the endpoint once.
+func (c Checker) doCheck(timeout time.Duration) types.Attempt {
+	var check types.Attempt
+	var conn net.Conn
+	var err error
+	start := time.Now()
+
+	if c.Host != "" {
+		hostname := c.Host
+		m1 := new(dns.Msg)
+		m1.Id = dns.Id()
+		m1.RecursionDesired = true
+		m1.Question = make([]dns.Question, 1)
+		m1.Question[0] = dns.Question{Name: hostname, Qtype: dns.TypeA, Qclass: dns.ClassINET}
+		d := new(dns.Client)
+		_, _, err := d.Exchange(m1, c.URL)
+		if err != nil {
+			check.Error = err.Error()
+			return check
 		}
 	}
-
-	// Check round trip time (degraded)
-	if c.ThresholdRTT > 0 {
-		stats := result.ComputeStats()
-		if stats.Median > c.ThresholdRTT {
-			result.Notice = fmt.Sprintf("median round trip time exceeded threshold (%s)", c.ThresholdRTT)
-			result.Degraded = true
-			return result
-		}
+	if conn, err = net.DialTimeout("tcp", c.URL, timeout); err != nil {
+		check.Error = err.Error()
+	} else {
+		conn.Close()
 	}
-
-	result.Healthy = true
-	return result
+	check.RTT = time.Since(start)
+	return check
 }
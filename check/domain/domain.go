@@ -0,0 +1,208 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "domain"
+
+// DefaultRDAPServer is the RDAP service used when none is
+// configured. It redirects queries to the registry that is
+// authoritative for the domain.
+const DefaultRDAPServer = "https://rdap.org"
+
+// DefaultWHOISServer is the WHOIS server used to look up the
+// authoritative WHOIS server for a TLD when none is configured.
+const DefaultWHOISServer = "whois.iana.org:43"
+
+// Checker implements a Checker for domain name registrations.
+// It queries RDAP for the registration and falls back to WHOIS
+// if the RDAP query fails.
+type Checker struct {
+	// Name is the name of the endpoint.
+	Name string `json:"endpoint_name"`
+
+	// URL is the registered domain name to check,
+	// for example "example.com".
+	URL string `json:"endpoint_url"`
+
+	// RDAPServer is the base URL of the RDAP service to
+	// query; "/domain/<name>" is appended to it. Default
+	// is DefaultRDAPServer.
+	RDAPServer string `json:"rdap_server,omitempty"`
+
+	// WHOISServer is the host:port of the WHOIS server to
+	// query if RDAP fails. If not set, the server for the
+	// domain's TLD is looked up via DefaultWHOISServer.
+	WHOISServer string `json:"whois_server,omitempty"`
+
+	// DisableWHOIS disables the WHOIS fallback.
+	DisableWHOIS bool `json:"disable_whois,omitempty"`
+
+	// ExpiryThreshold is how close to expiration the
+	// registration must be before declaring a degraded
+	// status. Default is 30 days.
+	ExpiryThreshold time.Duration `json:"expiry_threshold,omitempty"`
+
+	// Timeout is the maximum time to wait for each query.
+	// Default is 10 seconds.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// Client is the http.Client with which to make RDAP
+	// requests. If not set, one with Timeout is used.
+	Client *http.Client `json:"-"`
+
+	// CheckerOptions are the options common to all checkers.
+	types.CheckerOptions
+}
+
+// New creates a new Checker instance based on json config
+func New(config json.RawMessage) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	return checker, err
+}
+
+// Type returns the checker package name
+func (Checker) Type() string {
+	return Type
+}
+
+// EndpointName returns the name of the endpoint
+func (c Checker) EndpointName() string {
+	return c.Name
+}
+
+// registration is the registration data of a domain.
+type registration struct {
+	expiration time.Time
+	registrar  string
+	status     []string
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	result := types.NewResult()
+	result.Title = c.Name
+	result.Endpoint = c.URL
+	if c.URL == "" {
+		return result, fmt.Errorf("no domain name configured")
+	}
+	if c.RDAPServer == "" {
+		c.RDAPServer = DefaultRDAPServer
+	}
+	if c.ExpiryThreshold == 0 {
+		c.ExpiryThreshold = 24 * time.Hour * 30
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 10 * time.Second
+	}
+	if c.Client == nil {
+		c.Client = &http.Client{Timeout: c.Timeout}
+	}
+
+	start := time.Now()
+	reg, err := c.lookup()
+	result.Times = types.Attempts{{RTT: time.Since(start)}}
+	if err != nil {
+		result.Times[0].Error = err.Error()
+	}
+
+	return c.conclude(result, reg), nil
+}
+
+// lookup queries RDAP for the registration of the domain,
+// falling back to WHOIS.
+func (c Checker) lookup() (registration, error) {
+	domain := strings.TrimSuffix(strings.ToLower(c.URL), ".")
+	reg, err := c.rdap(domain)
+	if err == nil || c.DisableWHOIS {
+		return reg, err
+	}
+	reg, werr := c.whois(domain)
+	if werr != nil {
+		return reg, fmt.Errorf("rdap: %v; whois: %v", err, werr)
+	}
+	return reg, nil
+}
+
+// conclude takes the registration data and computes the
+// remaining values needed to fill out the result.
+func (c Checker) conclude(result types.Result, reg registration) types.Result {
+	// Check errors (down)
+	for i := range result.Times {
+		if result.Times[i].Error != "" {
+			result.Down = true
+			return result
+		}
+	}
+
+	summary := fmt.Sprintf("expires %s", reg.expiration.UTC().Format("2006-01-02"))
+	if reg.registrar != "" {
+		summary += ", registrar " + reg.registrar
+	}
+	if len(reg.status) > 0 {
+		summary += ", status " + strings.Join(reg.status, ", ")
+	}
+
+	// Check status codes (down)
+	for _, status := range reg.status {
+		switch normalizeStatus(status) {
+		case "clienthold", "serverhold":
+			result.Notice = fmt.Sprintf("domain is on hold (%s); %s", status, summary)
+			result.Down = true
+			return result
+		}
+	}
+
+	// Check expiration (down, degraded)
+	until := time.Until(reg.expiration)
+	if until <= 0 {
+		result.Notice = fmt.Sprintf("domain expired %s ago; %s", -until.Round(time.Hour), summary)
+		result.Down = true
+		return result
+	}
+	if until < c.ExpiryThreshold {
+		result.Notice = fmt.Sprintf("domain expiring soon (%s); %s", until.Round(time.Hour), summary)
+		result.Degraded = true
+		return result
+	}
+
+	result.Notice = summary
+	result.Healthy = true
+	return result
+}
+
+// normalizeStatus maps RDAP ("client hold") and EPP
+// ("clientHold") status values to the same string.
+func normalizeStatus(status string) string {
+	return strings.ToLower(strings.Join(strings.Fields(status), ""))
+}
+
+// sortedStatus returns the status values in s sorted, without
+// duplicates or EPP reference URLs.
+func sortedStatus(s []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, status := range s {
+		if fields := strings.Fields(status); len(fields) > 1 && strings.HasPrefix(fields[1], "http") {
+			status = fields[0]
+		}
+		if status == "" || seen[status] {
+			continue
+		}
+		seen[status] = true
+		out = append(out, status)
+	}
+	sort.Strings(out)
+	return out
+}
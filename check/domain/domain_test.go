@@ -0,0 +1,195 @@
+package domain
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rdapServer serves an RDAP domain object for example.com
+// with the given expiration date and status values.
+func rdapServer(expires time.Time, status ...string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/domain/example.com" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/rdap+json")
+		fmt.Fprintf(w, `{
+			"objectClassName": "domain",
+			"ldhName": "EXAMPLE.COM",
+			"status": ["%s"],
+			"events": [
+				{"eventAction": "registration", "eventDate": "1995-08-14T04:00:00Z"},
+				{"eventAction": "expiration", "eventDate": "%s"}
+			],
+			"entities": [{
+				"objectClassName": "entity",
+				"roles": ["registrar"],
+				"vcardArray": ["vcard", [["version", {}, "text", "4.0"], ["fn", {}, "text", "RESERVED-Internet Assigned Numbers Authority"]]]
+			}]
+		}`, strings.Join(status, `","`), expires.UTC().Format(time.RFC3339))
+	}))
+}
+
+func TestChecker(t *testing.T) {
+	srv := rdapServer(time.Now().Add(365*24*time.Hour), "client delete prohibited", "client transfer prohibited")
+	defer srv.Close()
+
+	testName := "TestDomain"
+	hc := Checker{Name: testName, URL: "example.com", RDAPServer: srv.URL, DisableWHOIS: true}
+
+	result, err := hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Title, testName; got != want {
+		t.Errorf("Expected result.Title='%s', got '%s'", want, got)
+	}
+	if got, want := result.Endpoint, "example.com"; got != want {
+		t.Errorf("Expected result.Endpoint='%s', got '%s'", want, got)
+	}
+	if got, want := result.Healthy, true; got != want {
+		t.Errorf("Expected result.Healthy=%v, got %v (%v)", want, got, result.Times)
+	}
+	if got, want := len(result.Times), 1; got != want {
+		t.Errorf("Expected %d attempts, got %d", want, got)
+	}
+	if !strings.Contains(result.Notice, "registrar RESERVED-Internet Assigned Numbers Authority") {
+		t.Errorf("Expected notice to contain the registrar, got '%s'", result.Notice)
+	}
+	if !strings.Contains(result.Notice, "status client delete prohibited, client transfer prohibited") {
+		t.Errorf("Expected notice to contain the status, got '%s'", result.Notice)
+	}
+
+	hc.ExpiryThreshold = 400 * 24 * time.Hour
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Degraded, true; got != want {
+		t.Errorf("Expected result.Degraded=%v, got %v", want, got)
+	}
+
+	// Unknown domain
+	hc.URL = "example.org"
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Down, true; got != want {
+		t.Errorf("Expected result.Down=%v, got %v", want, got)
+	}
+
+	// No domain is a configuration error
+	hc.URL = ""
+	result, err = hc.Check()
+	if err == nil {
+		t.Error("Expected an error without a domain, didn't get one")
+	}
+	if got, want := result.Title, hc.Name; got != want {
+		t.Errorf("Expected result.Title=%q, got %q", want, got)
+	}
+}
+
+func TestCheckerDown(t *testing.T) {
+	for _, test := range []struct {
+		expires time.Time
+		status  string
+		notice  string
+	}{
+		{time.Now().Add(-48 * time.Hour), "active", "domain expired"},
+		{time.Now().Add(365 * 24 * time.Hour), "client hold", "domain is on hold (client hold)"},
+	} {
+		srv := rdapServer(test.expires, test.status)
+		hc := Checker{Name: "Down", URL: "example.com", RDAPServer: srv.URL, DisableWHOIS: true}
+		result, err := hc.Check()
+		srv.Close()
+		if err != nil {
+			t.Errorf("Didn't expect an error: %v", err)
+		}
+		if got, want := result.Down, true; got != want {
+			t.Errorf("[%s] Expected result.Down=%v, got %v", test.status, want, got)
+		}
+		if !strings.HasPrefix(result.Notice, test.notice) {
+			t.Errorf("[%s] Expected notice to start with '%s', got '%s'", test.status, test.notice, result.Notice)
+		}
+	}
+}
+
+func TestCheckerWHOISFallback(t *testing.T) {
+	srv, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Couldn't start WHOIS test server with error: %v", err)
+	}
+	defer srv.Close()
+
+	expires := time.Now().Add(10 * 24 * time.Hour).UTC().Format("2006-01-02T15:04:05Z")
+	go func() {
+		for {
+			conn, err := srv.Accept()
+			if err != nil {
+				return
+			}
+			query, _ := bufio.NewReader(conn).ReadString('\n')
+			if strings.TrimSpace(query) == "example.com" {
+				fmt.Fprintf(conn, "   Domain Name: EXAMPLE.COM\r\n"+
+					"   Registrar: Example Registrar, Inc.\r\n"+
+					"   Registry Expiry Date: %s\r\n"+
+					"   Domain Status: clientTransferProhibited https://icann.org/epp#clientTransferProhibited\r\n"+
+					">>> Last update of whois database: 2020-04-01T00:00:00Z <<<\r\n", expires)
+			}
+			conn.Close()
+		}
+	}()
+
+	// RDAP server that knows nothing
+	rdap := httptest.NewServer(http.NotFoundHandler())
+	defer rdap.Close()
+
+	hc := Checker{Name: "WHOIS", URL: "example.com", RDAPServer: rdap.URL, WHOISServer: srv.Addr().String()}
+	result, err := hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Degraded, true; got != want {
+		t.Errorf("Expected result.Degraded=%v, got %v (%v)", want, got, result.Times)
+	}
+	if !strings.Contains(result.Notice, "registrar Example Registrar, Inc., status clientTransferProhibited") {
+		t.Errorf("Unexpected notice '%s'", result.Notice)
+	}
+
+	// Both lookups fail
+	hc.URL = "example.net"
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Down, true; got != want {
+		t.Errorf("Expected result.Down=%v, got %v", want, got)
+	}
+	if got := result.Times[0].Error; !strings.HasPrefix(got, "rdap: ") || !strings.Contains(got, "; whois: ") {
+		t.Errorf("Expected both lookup errors in attempt, got '%s'", got)
+	}
+}
+
+func TestParseWHOISDate(t *testing.T) {
+	for _, s := range []string{"2028-09-14T04:00:00Z", "2028-09-14", "14-Sep-2028", "2028.09.14"} {
+		d, err := parseWHOISDate(s)
+		if err != nil {
+			t.Errorf("Didn't expect an error parsing '%s': %v", s, err)
+			continue
+		}
+		if got, want := d.Format("2006-01-02"), "2028-09-14"; got != want {
+			t.Errorf("Expected '%s' to parse as %s, got %s", s, want, got)
+		}
+	}
+	if _, err := parseWHOISDate("next tuesday"); err == nil {
+		t.Error("Expected an error for an unrecognized date")
+	}
+}
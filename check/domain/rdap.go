@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// rdapDomain is the subset of an RDAP domain object
+// (RFC 9083) that is relevant to the check.
+type rdapDomain struct {
+	Status []string `json:"status"`
+	Events []struct {
+		Action string `json:"eventAction"`
+		Date   string `json:"eventDate"`
+	} `json:"events"`
+	Entities []struct {
+		Roles      []string          `json:"roles"`
+		VCardArray []json.RawMessage `json:"vcardArray"`
+	} `json:"entities"`
+}
+
+// rdap queries the RDAP server for domain.
+func (c Checker) rdap(domain string) (registration, error) {
+	var reg registration
+
+	url := strings.TrimSuffix(c.RDAPServer, "/") + "/domain/" + domain
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return reg, err
+	}
+	req.Header.Set("Accept", "application/rdap+json")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return reg, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return reg, fmt.Errorf("response status %s", resp.Status)
+	}
+
+	var obj rdapDomain
+	if err := json.NewDecoder(resp.Body).Decode(&obj); err != nil {
+		return reg, fmt.Errorf("decoding response: %v", err)
+	}
+
+	for _, event := range obj.Events {
+		if event.Action != "expiration" {
+			continue
+		}
+		reg.expiration, err = time.Parse(time.RFC3339, event.Date)
+		if err != nil {
+			return reg, fmt.Errorf("parsing expiration date: %v", err)
+		}
+	}
+	if reg.expiration.IsZero() {
+		return reg, fmt.Errorf("no expiration date in response")
+	}
+
+	for _, entity := range obj.Entities {
+		for _, role := range entity.Roles {
+			if role == "registrar" {
+				reg.registrar = vcardName(entity.VCardArray)
+			}
+		}
+	}
+	reg.status = sortedStatus(obj.Status)
+
+	return reg, nil
+}
+
+// vcardName returns the formatted name ("fn") property of
+// a jCard (RFC 7095), or "" if there is none.
+func vcardName(vcard []json.RawMessage) string {
+	if len(vcard) < 2 {
+		return ""
+	}
+	var props [][]json.RawMessage
+	if err := json.Unmarshal(vcard[1], &props); err != nil {
+		return ""
+	}
+	for _, prop := range props {
+		if len(prop) < 4 {
+			continue
+		}
+		var name, value string
+		if json.Unmarshal(prop[0], &name) != nil || name != "fn" {
+			continue
+		}
+		if json.Unmarshal(prop[3], &value) == nil {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+	"time"
+)
+
+// whoisExpiryKeys are the field names used by various
+// registries for the expiration date of a registration.
+var whoisExpiryKeys = []string{
+	"registry expiry date",
+	"registrar registration expiration date",
+	"expiration date",
+	"expiry date",
+	"expires",
+	"expire",
+	"paid-till",
+}
+
+// whoisDateLayouts are the date formats found in WHOIS
+// responses, most common first.
+var whoisDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z",
+	"2006-01-02T15:04:05.0Z",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"02-Jan-2006",
+	"2006.01.02",
+	"02.01.2006",
+}
+
+// whois queries a WHOIS server for domain.
+func (c Checker) whois(domain string) (registration, error) {
+	var reg registration
+
+	server := c.WHOISServer
+	if server == "" {
+		// ask IANA which server is authoritative for the TLD
+		tld := domain[strings.LastIndex(domain, ".")+1:]
+		resp, err := c.whoisQuery(DefaultWHOISServer, tld)
+		if err != nil {
+			return reg, err
+		}
+		refer := whoisFields(resp)["refer"]
+		if len(refer) == 0 {
+			return reg, fmt.Errorf("no WHOIS server known for .%s", tld)
+		}
+		server = net.JoinHostPort(refer[0], "43")
+	}
+
+	resp, err := c.whoisQuery(server, domain)
+	if err != nil {
+		return reg, err
+	}
+	fields := whoisFields(resp)
+
+	for _, key := range whoisExpiryKeys {
+		if values := fields[key]; len(values) > 0 {
+			reg.expiration, err = parseWHOISDate(values[0])
+			if err != nil {
+				return reg, err
+			}
+			break
+		}
+	}
+	if reg.expiration.IsZero() {
+		return reg, fmt.Errorf("no expiration date in response")
+	}
+
+	if values := fields["registrar"]; len(values) > 0 {
+		reg.registrar = values[0]
+	}
+	reg.status = sortedStatus(append(fields["domain status"], fields["status"]...))
+
+	return reg, nil
+}
+
+// whoisQuery sends query to server and returns the response.
+func (c Checker) whoisQuery(server, query string) ([]byte, error) {
+	conn, err := net.DialTimeout("tcp", server, c.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(c.Timeout)); err != nil {
+		return nil, err
+	}
+	if _, err := fmt.Fprintf(conn, "%s\r\n", query); err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(conn)
+}
+
+// whoisFields parses the "key: value" lines of a WHOIS
+// response. Keys are lower-cased.
+func whoisFields(resp []byte) map[string][]string {
+	fields := make(map[string][]string)
+	scanner := bufio.NewScanner(bytes.NewReader(resp))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "%") || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ">>>") {
+			continue
+		}
+		i := strings.Index(line, ":")
+		if i < 0 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:i]))
+		value := strings.TrimSpace(line[i+1:])
+		if value == "" {
+			continue
+		}
+		fields[key] = append(fields[key], value)
+	}
+	return fields
+}
+
+// parseWHOISDate parses a date as found in a WHOIS response.
+func parseWHOISDate(s string) (time.Time, error) {
+	for _, layout := range whoisDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date format: %s", s)
+}
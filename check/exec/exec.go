@@ -64,6 +64,13 @@ type Checker struct {
 	// quickly in succession. By default, no waiting
 	// occurs between attempts.
 	AttemptSpacing time.Duration `json:"attempt_spacing,omitempty"`
+
+	// Policy is how the status of the endpoint is
+	// concluded from the attempts.
+	types.Policy
+
+	// CheckerOptions are the options common to all checkers.
+	types.CheckerOptions
 }
 
 // New creates a new Checker instance based on json config
@@ -78,6 +85,11 @@ func (Checker) Type() string {
 	return Type
 }
 
+// EndpointName returns the name of the endpoint
+func (c Checker) EndpointName() string {
+	return c.Name
+}
+
 // Check performs checks using c according to its configuration.
 // An error is only returned if there is a configuration error.
 func (c Checker) Check() (types.Result, error) {
@@ -88,6 +100,10 @@ func (c Checker) Check() (types.Result, error) {
 	result := types.NewResult()
 	result.Title = c.Name
 	result.Endpoint = c.Command
+
+	if err := c.Policy.Validate(); err != nil {
+		return result, err
+	}
 	result.Times = c.doChecks()
 
 	return c.conclude(result), nil
@@ -139,26 +155,26 @@ func (c Checker) conclude(result types.Result) types.Result {
 	warning := c.Raise == "warn" || c.Raise == "warning"
 
 	// Check errors (down)
-	for i := range result.Times {
-		if result.Times[i].Error != "" {
-			if warning {
-				result.Notice = result.Times[i].Error
-				result.Degraded = true
-				return result
+	if c.Policy.Failed(result.Times) {
+		if warning {
+			for i := range result.Times {
+				if result.Times[i].Error != "" {
+					result.Notice = result.Times[i].Error
+					break
+				}
 			}
-			result.Down = true
+			result.Degraded = true
 			return result
 		}
+		result.Down = true
+		return result
 	}
 
 	// Check round trip time (degraded)
-	if c.ThresholdRTT > 0 {
-		stats := result.ComputeStats()
-		if stats.Median > c.ThresholdRTT {
-			result.Notice = fmt.Sprintf("median round trip time exceeded threshold (%s)", c.ThresholdRTT)
-			result.Degraded = true
-			return result
-		}
+	if notice := c.Policy.Slow(result, c.ThresholdRTT); notice != "" {
+		result.Notice = notice
+		result.Degraded = true
+		return result
 	}
 
 	result.Healthy = true
@@ -0,0 +1,150 @@
+package file
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "file"
+
+// Checker implements a Checker for files on the local
+// filesystem, for example to verify that backups are
+// fresh and of a plausible size.
+type Checker struct {
+	// Name is the name of the endpoint.
+	Name string `json:"endpoint_name"`
+
+	// Path is the path of the file to check. It may be a
+	// glob pattern (see filepath.Match), in which case the
+	// most recently modified matching file is checked.
+	Path string `json:"path"`
+
+	// MaxAge is the maximum time since the file was last
+	// modified. If zero, the age is not checked.
+	MaxAge time.Duration `json:"max_age,omitempty"`
+
+	// MinSize is the minimum size of the file in bytes.
+	MinSize int64 `json:"min_size,omitempty"`
+
+	// MaxSize is the maximum size of the file in bytes.
+	// If zero, there is no maximum.
+	MaxSize int64 `json:"max_size,omitempty"`
+
+	// Raise is a string that tells us if we should mark
+	// the endpoint as down when the file does not meet
+	// the requirements ("error" - the default), or if we
+	// should just mark it as degraded ("warn" or "warning").
+	// A missing file always marks the endpoint as down.
+	Raise string `json:"raise,omitempty"`
+
+	// CheckerOptions are the options common to all checkers.
+	types.CheckerOptions
+}
+
+// New creates a new Checker instance based on json config
+func New(config json.RawMessage) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	return checker, err
+}
+
+// Type returns the checker package name
+func (Checker) Type() string {
+	return Type
+}
+
+// EndpointName returns the name of the endpoint
+func (c Checker) EndpointName() string {
+	return c.Name
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	result := types.NewResult()
+	result.Title = c.Name
+	result.Endpoint = c.Path
+
+	if c.Path == "" {
+		return result, fmt.Errorf("no path configured")
+	}
+	if _, err := filepath.Match(c.Path, ""); err != nil {
+		return result, fmt.Errorf("invalid path pattern: %v", err)
+	}
+
+	start := time.Now()
+	info, err := c.stat()
+	result.Times = types.Attempts{{RTT: time.Since(start)}}
+	if err != nil {
+		result.Times[0].Error = err.Error()
+	}
+
+	return c.conclude(result, info), nil
+}
+
+// stat returns information about the file at c.Path or the
+// newest file that matches it.
+func (c Checker) stat() (os.FileInfo, error) {
+	matches, err := filepath.Glob(c.Path)
+	if err != nil {
+		return nil, err
+	}
+	var newest os.FileInfo
+	for _, name := range matches {
+		info, err := os.Stat(name)
+		if err != nil {
+			return nil, err
+		}
+		if newest == nil || info.ModTime().After(newest.ModTime()) {
+			newest = info
+		}
+	}
+	if newest == nil {
+		return nil, fmt.Errorf("no such file: %s", c.Path)
+	}
+	return newest, nil
+}
+
+// conclude takes the file information and makes the
+// conclusion about the result's status.
+func (c Checker) conclude(result types.Result, info os.FileInfo) types.Result {
+	// Check errors (down)
+	for i := range result.Times {
+		if result.Times[i].Error != "" {
+			result.Down = true
+			return result
+		}
+	}
+
+	// Check requirements (down, degraded)
+	var violations []string
+	if age := time.Since(info.ModTime()); c.MaxAge > 0 && age > c.MaxAge {
+		violations = append(violations, fmt.Sprintf("%s was modified %s ago, want at most %s",
+			info.Name(), age.Round(time.Second), c.MaxAge))
+	}
+	if size := info.Size(); size < c.MinSize {
+		violations = append(violations, fmt.Sprintf("%s is %d bytes, want at least %d", info.Name(), size, c.MinSize))
+	}
+	if size := info.Size(); c.MaxSize > 0 && size > c.MaxSize {
+		violations = append(violations, fmt.Sprintf("%s is %d bytes, want at most %d", info.Name(), size, c.MaxSize))
+	}
+	if len(violations) > 0 {
+		result.Notice = strings.Join(violations, "; ")
+		if c.Raise == "warn" || c.Raise == "warning" {
+			result.Degraded = true
+		} else {
+			result.Down = true
+		}
+		return result
+	}
+
+	result.Healthy = true
+	return result
+}
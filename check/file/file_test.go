@@ -0,0 +1,97 @@
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChecker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkup")
+	if err != nil {
+		t.Fatalf("Cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	old := filepath.Join(dir, "backup-1.tar")
+	fresh := filepath.Join(dir, "backup-2.tar")
+	if err := ioutil.WriteFile(old, make([]byte, 10), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(fresh, make([]byte, 100), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lastWeek := time.Now().Add(-7 * 24 * time.Hour)
+	if err := os.Chtimes(old, lastWeek, lastWeek); err != nil {
+		t.Fatal(err)
+	}
+
+	testName := "TestFile"
+	hc := Checker{Name: testName, Path: filepath.Join(dir, "backup-*.tar"), MaxAge: time.Hour, MinSize: 50, MaxSize: 1000}
+
+	// The newest match is checked
+	result, err := hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Title, testName; got != want {
+		t.Errorf("Expected result.Title='%s', got '%s'", want, got)
+	}
+	if got, want := result.Endpoint, hc.Path; got != want {
+		t.Errorf("Expected result.Endpoint='%s', got '%s'", want, got)
+	}
+	if got, want := result.Healthy, true; got != want {
+		t.Errorf("Expected result.Healthy=%v, got %v (%s)", want, got, result.Notice)
+	}
+
+	// Stale and too small
+	hc.Path = old
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Down, true; got != want {
+		t.Errorf("Expected result.Down=%v, got %v", want, got)
+	}
+	if !strings.Contains(result.Notice, "want at most 1h0m0s") || !strings.Contains(result.Notice, "is 10 bytes, want at least 50") {
+		t.Errorf("Unexpected notice '%s'", result.Notice)
+	}
+
+	hc.Raise = "warn"
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Degraded, true; got != want {
+		t.Errorf("Expected result.Degraded=%v, got %v", want, got)
+	}
+
+	// Too large
+	hc.Path = fresh
+	hc.MaxSize = 99
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Notice, "backup-2.tar is 100 bytes, want at most 99"; got != want {
+		t.Errorf("Expected notice '%s', got '%s'", want, got)
+	}
+
+	// Missing files are always down
+	hc.Path = filepath.Join(dir, "nonexistent-*")
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Down, true; got != want {
+		t.Errorf("Expected result.Down=%v, got %v", want, got)
+	}
+
+	hc.Path = "["
+	if _, err = hc.Check(); err == nil {
+		t.Error("Expected an error for an invalid pattern, didn't get one")
+	}
+}
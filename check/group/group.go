@@ -0,0 +1,230 @@
+package group
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "group"
+
+// Modes of combining the results of the members of a group.
+const (
+	ModeAll    = "all"
+	ModeAny    = "any"
+	ModeQuorum = "quorum"
+)
+
+// Member is a checker in a group. It has the same methods
+// as checkup.Checker.
+type Member interface {
+	Type() string
+	Check() (types.Result, error)
+}
+
+// DecodeFunc decodes the configuration of a member with
+// the given type name.
+type DecodeFunc func(typeName string, config json.RawMessage) (Member, error)
+
+// Checker implements a Checker that combines the results of
+// other checkers into one result. This is useful for
+// redundant services, where some members may be down
+// without the service being down.
+type Checker struct {
+	// Name is the name of the endpoint.
+	Name string `json:"endpoint_name"`
+
+	// Checkers are the configurations of the members,
+	// in the same format as the checkers of a checkup.
+	Checkers []json.RawMessage `json:"checkers"`
+
+	// Mode is how the results of the members are combined:
+	//
+	//   - "all" (the default): the group is down if any
+	//     member is down, and degraded if any is degraded
+	//   - "any": the group is healthy if any member is
+	//     healthy, and down only if all of them are down
+	//   - "quorum": the group is healthy if at least Quorum
+	//     members are healthy, and down if fewer than
+	//     Quorum members are up
+	Mode string `json:"mode,omitempty"`
+
+	// Quorum is the number of members that must be up in
+	// "quorum" mode.
+	Quorum int `json:"quorum,omitempty"`
+
+	// EmitChildren controls whether the results of the
+	// members are stored and reported along with the result
+	// of the group.
+	EmitChildren bool `json:"emit_children,omitempty"`
+
+	// members are the decoded Checkers.
+	members []Member
+
+	// CheckerOptions are the options common to all checkers.
+	types.CheckerOptions
+}
+
+// New creates a new Checker instance based on json config,
+// using decode to decode the configurations of the members.
+func New(config json.RawMessage, decode DecodeFunc) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	if err != nil {
+		return checker, err
+	}
+
+	for i, raw := range checker.Checkers {
+		var typ struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(raw, &typ); err != nil {
+			return checker, fmt.Errorf("%s: checker %d: %v", checker.Name, i, err)
+		}
+		member, err := decode(typ.Type, raw)
+		if err != nil {
+			return checker, fmt.Errorf("%s: checker %d: %v", checker.Name, i, err)
+		}
+		checker.members = append(checker.members, member)
+	}
+	return checker, nil
+}
+
+// Type returns the checker package name
+func (Checker) Type() string {
+	return Type
+}
+
+// EndpointName returns the name of the endpoint
+func (c Checker) EndpointName() string {
+	return c.Name
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	results, err := c.CheckAll()
+	if len(results) == 0 {
+		return types.NewResult(), err
+	}
+	return results[0], err
+}
+
+// CheckAll performs the checks of all members and returns
+// the result of the group, followed by the results of the
+// members if c.EmitChildren is set.
+func (c Checker) CheckAll() ([]types.Result, error) {
+	if c.Mode == "" {
+		c.Mode = ModeAll
+	}
+
+	result := types.NewResult()
+	result.Title = c.Name
+
+	switch c.Mode {
+	case ModeAll, ModeAny:
+	case ModeQuorum:
+		if c.Quorum < 1 || c.Quorum > len(c.members) {
+			return nil, fmt.Errorf("%s: quorum must be between 1 and %d", c.Name, len(c.members))
+		}
+	default:
+		return nil, fmt.Errorf("%s: unknown mode '%s'", c.Name, c.Mode)
+	}
+	if len(c.members) == 0 {
+		return nil, fmt.Errorf("%s: no checkers configured", c.Name)
+	}
+
+	children, err := c.doChecks()
+	if err != nil {
+		return nil, err
+	}
+
+	var titles []string
+	for _, child := range children {
+		titles = append(titles, child.Title)
+	}
+	result.Endpoint = strings.Join(titles, ", ")
+
+	results := []types.Result{c.conclude(result, children)}
+	if c.EmitChildren {
+		for i, member := range c.members {
+			if o, ok := member.(interface{ Options() types.CheckerOptions }); ok {
+				o.Options().Annotate(&children[i])
+			}
+		}
+		results = append(results, children...)
+	}
+	return results, nil
+}
+
+// doChecks checks all members concurrently and returns
+// their results.
+func (c Checker) doChecks() ([]types.Result, error) {
+	results := make([]types.Result, len(c.members))
+	errs := make(types.Errors, len(c.members))
+	wg := sync.WaitGroup{}
+
+	for i, member := range c.members {
+		wg.Add(1)
+		go func(i int, member Member) {
+			results[i], errs[i] = member.Check()
+			wg.Done()
+		}(i, member)
+	}
+	wg.Wait()
+
+	if !errs.Empty() {
+		return results, errs
+	}
+	return results, nil
+}
+
+// conclude combines the results of the members into the
+// result of the group. Each member becomes one attempt,
+// with the median round trip time of the member and, if
+// the member is down, its status as the error.
+func (c Checker) conclude(result types.Result, children []types.Result) types.Result {
+	var healthy, degraded int
+	var failing []string
+	for _, child := range children {
+		attempt := types.Attempt{}
+		if len(child.Times) > 0 {
+			attempt.RTT = child.ComputeStats().Median
+		}
+		switch status := child.Status(); status {
+		case types.StatusHealthy:
+			healthy++
+		case types.StatusDegraded:
+			degraded++
+			failing = append(failing, fmt.Sprintf("%s (%s)", child.Title, status))
+		default:
+			attempt.Error = string(status)
+			failing = append(failing, fmt.Sprintf("%s (%s)", child.Title, status))
+		}
+		result.Times = append(result.Times, attempt)
+	}
+	if len(failing) > 0 {
+		result.Notice = "failing: " + strings.Join(failing, ", ")
+	}
+
+	up := healthy + degraded
+	switch c.Mode {
+	case ModeAny:
+		result.Healthy = healthy > 0
+		result.Degraded = healthy == 0 && degraded > 0
+		result.Down = up == 0
+	case ModeQuorum:
+		result.Healthy = healthy >= c.Quorum
+		result.Degraded = healthy < c.Quorum && up >= c.Quorum
+		result.Down = up < c.Quorum
+	default:
+		result.Healthy = healthy == len(children)
+		result.Degraded = up == len(children) && degraded > 0
+		result.Down = up < len(children)
+	}
+	return result
+}
@@ -0,0 +1,150 @@
+package group
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// fake is a member whose configuration is its result.
+type fake struct {
+	Title  string `json:"endpoint_name"`
+	Status string `json:"status"`
+	Err    string `json:"error"`
+}
+
+func (fake) Type() string { return "fake" }
+
+func (f fake) Check() (types.Result, error) {
+	r := types.NewResult()
+	r.Title = f.Title
+	r.Times = types.Attempts{{RTT: 1}}
+	switch types.StatusText(f.Status) {
+	case types.StatusHealthy:
+		r.Healthy = true
+	case types.StatusDegraded:
+		r.Degraded = true
+	case types.StatusDown:
+		r.Down = true
+	}
+	if f.Err != "" {
+		return r, errors.New(f.Err)
+	}
+	return r, nil
+}
+
+func decode(typeName string, config json.RawMessage) (Member, error) {
+	if typeName != "fake" {
+		return nil, fmt.Errorf("unknown checker type '%s'", typeName)
+	}
+	var f fake
+	err := json.Unmarshal(config, &f)
+	return f, err
+}
+
+func newGroup(t *testing.T, mode string, quorum int, statuses ...string) Checker {
+	var members []string
+	for i, status := range statuses {
+		members = append(members, fmt.Sprintf(`{"type":"fake","endpoint_name":"m%d","status":"%s"}`, i, status))
+	}
+	config := fmt.Sprintf(`{"endpoint_name":"Group","mode":"%s","quorum":%d,"checkers":[%s]}`,
+		mode, quorum, strings.Join(members, ","))
+	c, err := New(json.RawMessage(config), decode)
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	return c
+}
+
+func TestChecker(t *testing.T) {
+	for i, test := range []struct {
+		mode     string
+		quorum   int
+		statuses []string
+		want     types.StatusText
+		notice   string
+	}{
+		{"", 0, []string{"healthy", "healthy"}, types.StatusHealthy, ""},
+		{"all", 0, []string{"healthy", "degraded"}, types.StatusDegraded, "failing: m1 (degraded)"},
+		{"all", 0, []string{"down", "degraded"}, types.StatusDown, "failing: m0 (down), m1 (degraded)"},
+		{"any", 0, []string{"down", "healthy"}, types.StatusHealthy, "failing: m0 (down)"},
+		{"any", 0, []string{"down", "degraded"}, types.StatusDegraded, "failing: m0 (down), m1 (degraded)"},
+		{"any", 0, []string{"down", "down"}, types.StatusDown, "failing: m0 (down), m1 (down)"},
+		{"quorum", 2, []string{"healthy", "healthy", "down"}, types.StatusHealthy, "failing: m2 (down)"},
+		{"quorum", 2, []string{"healthy", "degraded", "down"}, types.StatusDegraded, "failing: m1 (degraded), m2 (down)"},
+		{"quorum", 2, []string{"healthy", "down", "down"}, types.StatusDown, "failing: m1 (down), m2 (down)"},
+	} {
+		c := newGroup(t, test.mode, test.quorum, test.statuses...)
+		result, err := c.Check()
+		if err != nil {
+			t.Fatalf("Test %d: didn't expect an error: %v", i, err)
+		}
+		if got := result.Status(); got != test.want {
+			t.Errorf("Test %d: expected status %s, got %s", i, test.want, got)
+		}
+		if got := result.Notice; got != test.notice {
+			t.Errorf("Test %d: expected notice '%s', got '%s'", i, test.notice, got)
+		}
+		if got, want := len(result.Times), len(test.statuses); got != want {
+			t.Errorf("Test %d: expected %d attempts, got %d", i, want, got)
+		}
+	}
+}
+
+func TestCheckerEmitChildren(t *testing.T) {
+	c := newGroup(t, "all", 0, "healthy", "down")
+	results, err := c.CheckAll()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if got, want := len(results), 1; got != want {
+		t.Fatalf("Expected %d results, got %d", want, got)
+	}
+	if got, want := results[0].Endpoint, "m0, m1"; got != want {
+		t.Errorf("Expected endpoint '%s', got '%s'", want, got)
+	}
+
+	c.EmitChildren = true
+	if results, err = c.CheckAll(); err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if got, want := len(results), 3; got != want {
+		t.Fatalf("Expected %d results, got %d", want, got)
+	}
+	if results[0].Title != "Group" || results[1].Title != "m0" || results[2].Title != "m1" {
+		t.Errorf("Expected group result followed by member results, got %+v", results)
+	}
+}
+
+func TestCheckerErrors(t *testing.T) {
+	for i, config := range []string{
+		`{"checkers":[{"type":"nope"}]}`,
+		`{"checkers":["fake"]}`,
+	} {
+		if _, err := New(json.RawMessage(config), decode); err == nil {
+			t.Errorf("Test %d: expected an error decoding %s", i, config)
+		}
+	}
+
+	for i, c := range []Checker{
+		newGroup(t, "quorum", 3, "healthy", "healthy"),
+		newGroup(t, "most", 0, "healthy"),
+		newGroup(t, "all", 0),
+	} {
+		if _, err := c.Check(); err == nil {
+			t.Errorf("Test %d: expected an error", i)
+		}
+	}
+
+	c, err := New(json.RawMessage(`{"checkers":[{"type":"fake","error":"boom"}]}`), decode)
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if _, err := c.Check(); err == nil || err.Error() != "boom" {
+		t.Errorf("Expected member error, got %v", err)
+	}
+}
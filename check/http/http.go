@@ -56,6 +56,15 @@ type Checker struct {
 	// make to the endpoint in a single check.
 	Attempts int `json:"attempts,omitempty"`
 
+	// Retries is how many times to retry a failed attempt
+	// before counting it as failed.
+	Retries int `json:"retries,omitempty"`
+
+	// RetryBackoff is how long to wait before the first
+	// retry of an attempt. The wait doubles for each
+	// further retry.
+	RetryBackoff time.Duration `json:"retry_backoff,omitempty"`
+
 	// AttemptSpacing spaces out each attempt in a check
 	// by this duration to avoid hitting a remote too
 	// quickly in succession. By default, no waiting
@@ -70,6 +79,13 @@ type Checker struct {
 	// Headers contains headers to added to the request
 	// that is sent for the check
 	Headers http.Header `json:"headers,omitempty"`
+
+	// Policy is how the status of the endpoint is
+	// concluded from the attempts.
+	types.Policy
+
+	// CheckerOptions are the options common to all checkers.
+	types.CheckerOptions
 }
 
 // New creates a new Checker instance based on json config
@@ -84,6 +100,11 @@ func (Checker) Type() string {
 	return Type
 }
 
+// EndpointName returns the name of the endpoint
+func (c Checker) EndpointName() string {
+	return c.Name
+}
+
 // Check performs checks using c according to its configuration.
 // An error is only returned if there is a configuration error.
 func (c Checker) Check() (types.Result, error) {
@@ -101,6 +122,10 @@ func (c Checker) Check() (types.Result, error) {
 	result.Title = c.Name
 	result.Endpoint = c.URL
 
+	if err := c.Policy.Validate(); err != nil {
+		return result, err
+	}
+
 	req, err := http.NewRequest("GET", c.URL, nil)
 	if err != nil {
 		return result, err
@@ -118,25 +143,16 @@ func (c Checker) Check() (types.Result, error) {
 
 	result.Times = c.doChecks(req)
 
-	return c.conclude(result), nil
+	return c.Policy.Conclude(result, c.ThresholdRTT), nil
 }
 
 // doChecks executes req using c.Client and returns each attempt.
 func (c Checker) doChecks(req *http.Request) types.Attempts {
 	checks := make(types.Attempts, c.Attempts)
 	for i := 0; i < c.Attempts; i++ {
-		start := time.Now()
-		resp, err := c.Client.Do(req)
-		checks[i].RTT = time.Since(start)
-		if err != nil {
-			checks[i].Error = err.Error()
-			continue
-		}
-		err = c.checkDown(resp)
-		if err != nil {
-			checks[i].Error = err.Error()
-		}
-		resp.Body.Close()
+		checks[i] = types.Retry(c.Retries, c.RetryBackoff, func() types.Attempt {
+			return c.doCheck(req)
+		})
 		if c.AttemptSpacing > 0 {
 			time.Sleep(c.AttemptSpacing)
 		}
@@ -144,33 +160,22 @@ func (c Checker) doChecks(req *http.Request) types.Attempts {
 	return checks
 }
 
-// conclude takes the data in result from the attempts and
-// computes remaining values needed to fill out the result.
-// It detects degraded (high-latency) responses and makes
-// the conclusion about the result's status.
-func (c Checker) conclude(result types.Result) types.Result {
-	result.ThresholdRTT = c.ThresholdRTT
-
-	// Check errors (down)
-	for i := range result.Times {
-		if result.Times[i].Error != "" {
-			result.Down = true
-			return result
-		}
+// doCheck executes req using c.Client once.
+func (c Checker) doCheck(req *http.Request) types.Attempt {
+	var check types.Attempt
+	start := time.Now()
+	resp, err := c.Client.Do(req)
+	check.RTT = time.Since(start)
+	if err != nil {
+		check.Error = err.Error()
+		return check
 	}
-
-	// Check round trip time (degraded)
-	if c.ThresholdRTT > 0 {
-		stats := result.ComputeStats()
-		if stats.Median > c.ThresholdRTT {
-			result.Notice = fmt.Sprintf("median round trip time exceeded threshold (%s)", c.ThresholdRTT)
-			result.Degraded = true
-			return result
-		}
+	err = c.checkDown(resp)
+	if err != nil {
+		check.Error = err.Error()
 	}
-
-	result.Healthy = true
-	return result
+	resp.Body.Close()
+	return check
 }
 
 // checkDown checks whether the endpoint is down based on resp and
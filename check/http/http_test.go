@@ -147,3 +147,38 @@ func TestChecker(t *testing.T) {
 		t.Errorf("Expected result.Down=%v, got %v", want, got)
 	}
 }
+
+func TestCheckerRetries(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	hc := Checker{Name: "Test", URL: srv.URL, Attempts: 1, Retries: 2, RetryBackoff: time.Millisecond}
+
+	result, err := hc.Check()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if !result.Healthy {
+		t.Errorf("Expected result to be healthy after retries, got %+v", result)
+	}
+	if got, want := result.Times[0].Retries, 2; got != want {
+		t.Errorf("Expected %d retries, got %d", want, got)
+	}
+
+	requests = -10
+	result, err = hc.Check()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if !result.Down {
+		t.Errorf("Expected result to be down after running out of retries, got %+v", result)
+	}
+	if got, want := requests+10, 3; got != want {
+		t.Errorf("Expected %d requests, got %d", want, got)
+	}
+}
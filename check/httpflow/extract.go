@@ -0,0 +1,96 @@
+package httpflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// extract returns the value described by ex from a response
+// with the given header and body.
+func extract(ex Extraction, header http.Header, body []byte) (string, error) {
+	switch {
+	case ex.Header != "":
+		v := header.Get(ex.Header)
+		if v == "" {
+			return "", fmt.Errorf("no header %s in response", ex.Header)
+		}
+		return v, nil
+	case ex.Regex != "":
+		re, err := regexp.Compile(ex.Regex)
+		if err != nil {
+			return "", err
+		}
+		m := re.FindSubmatch(body)
+		if m == nil {
+			return "", fmt.Errorf("no match for %s", ex.Regex)
+		}
+		if len(m) > 1 {
+			return string(m[1]), nil
+		}
+		return string(m[0]), nil
+	case ex.JSONPath != "":
+		var doc interface{}
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return "", fmt.Errorf("decoding JSON response: %v", err)
+		}
+		v, err := lookup(doc, ex.JSONPath)
+		if err != nil {
+			return "", err
+		}
+		switch v := v.(type) {
+		case string:
+			return v, nil
+		case nil:
+			return "", fmt.Errorf("%s is null", ex.JSONPath)
+		default:
+			b, err := json.Marshal(v)
+			return string(b), err
+		}
+	}
+	return "", fmt.Errorf("no json_path, regex or header configured")
+}
+
+// lookup evaluates a simple JSONPath, consisting of object
+// keys separated by dots and array indexes in brackets, such
+// as "$.data.items[0].id", against doc.
+func lookup(doc interface{}, path string) (interface{}, error) {
+	p := strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	v := doc
+	for p != "" {
+		if p[0] == '[' {
+			end := strings.IndexByte(p, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %s: unterminated index", path)
+			}
+			i, err := strconv.Atoi(p[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid path %s: %v", path, err)
+			}
+			arr, ok := v.([]interface{})
+			if !ok || i < 0 || i >= len(arr) {
+				return nil, fmt.Errorf("%s not found", path)
+			}
+			v = arr[i]
+			p = strings.TrimPrefix(p[end+1:], ".")
+			continue
+		}
+
+		end := strings.IndexAny(p, ".[")
+		if end < 0 {
+			end = len(p)
+		}
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s not found", path)
+		}
+		if v, ok = obj[p[:end]]; !ok {
+			return nil, fmt.Errorf("%s not found", path)
+		}
+		p = strings.TrimPrefix(p[end:], ".")
+	}
+	return v, nil
+}
@@ -0,0 +1,324 @@
+package httpflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	checkhttp "github.com/sourcegraph/checkup/check/http"
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type is the checker type name used in configuration.
+const Type = "http_flow"
+
+// Checker implements a Checker for multi-step HTTP
+// transactions, such as logging in and then checking out.
+// The steps run in order and share a cookie jar. Values can
+// be extracted from one response into variables, which are
+// substituted as {{name}} in the URLs, headers and bodies of
+// later steps. Each step is recorded as one attempt, and the
+// flow stops at the first failing step.
+type Checker struct {
+	// Name is the name of the endpoint.
+	Name string `json:"endpoint_name"`
+
+	// URL is the URL of the endpoint. Step URLs that are
+	// relative are resolved against it.
+	URL string `json:"endpoint_url"`
+
+	// Steps are the requests to make, in order.
+	Steps []Step `json:"steps"`
+
+	// Variables are the initial values of variables.
+	Variables map[string]string `json:"variables,omitempty"`
+
+	// ThresholdRTT is the maximum time that all of the
+	// steps together may take for a healthy endpoint.
+	ThresholdRTT time.Duration `json:"threshold_rtt,omitempty"`
+
+	// Headers contains headers to add to every request.
+	Headers http.Header `json:"headers,omitempty"`
+
+	// Client is the http.Client with which to make
+	// requests. A copy of it with a fresh cookie jar is
+	// used for each check. If not set, the
+	// DefaultHTTPClient of the http checker is used.
+	Client *http.Client `json:"-"`
+
+	// CheckerOptions are the options common to all checkers.
+	types.CheckerOptions
+}
+
+// Step is a single request of a flow.
+type Step struct {
+	// Name identifies the step in the result notice.
+	Name string `json:"name"`
+
+	// Method is the HTTP method. Default is GET.
+	Method string `json:"method,omitempty"`
+
+	// URL is the URL to request.
+	URL string `json:"url"`
+
+	// Headers contains headers to add to the request.
+	Headers http.Header `json:"headers,omitempty"`
+
+	// Body is the request body.
+	Body string `json:"body,omitempty"`
+
+	// UpStatus is the HTTP status code expected in the
+	// response. Default is http.StatusOK.
+	UpStatus int `json:"up_status,omitempty"`
+
+	// MustContain is a string that the response body
+	// must contain for the step to succeed.
+	MustContain string `json:"must_contain,omitempty"`
+
+	// MustNotContain is a string that the response
+	// body must NOT contain for the step to succeed.
+	MustNotContain string `json:"must_not_contain,omitempty"`
+
+	// Extract lists the values to extract from the
+	// response into variables.
+	Extract []Extraction `json:"extract,omitempty"`
+}
+
+// Extraction extracts a value from a response into a
+// variable. Exactly one of JSONPath, Regex and Header
+// should be set. A step fails if the value is not found.
+type Extraction struct {
+	// Var is the name of the variable to set.
+	Var string `json:"var"`
+
+	// JSONPath is a path into a JSON response body,
+	// such as "$.data.items[0].id".
+	JSONPath string `json:"json_path,omitempty"`
+
+	// Regex is a regular expression to match against the
+	// response body. The value is its first capturing
+	// group, or the whole match if it has none.
+	Regex string `json:"regex,omitempty"`
+
+	// Header is the name of a response header.
+	Header string `json:"header,omitempty"`
+}
+
+// New creates a new Checker instance based on json config
+func New(config json.RawMessage) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	return checker, err
+}
+
+// Type returns the checker type name
+func (Checker) Type() string {
+	return Type
+}
+
+// EndpointName returns the name of the endpoint
+func (c Checker) EndpointName() string {
+	return c.Name
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	result := types.NewResult()
+	result.Title = c.Name
+	result.Endpoint = c.URL
+
+	if len(c.Steps) == 0 {
+		return result, fmt.Errorf("no steps configured")
+	}
+	for _, step := range c.Steps {
+		for _, ex := range step.Extract {
+			if ex.Regex == "" {
+				continue
+			}
+			if _, err := regexp.Compile(ex.Regex); err != nil {
+				return result, fmt.Errorf("step '%s': invalid regex: %v", step.Name, err)
+			}
+		}
+	}
+
+	client, err := c.client()
+	if err != nil {
+		return result, err
+	}
+
+	var failed string
+	result.Times, failed = c.doChecks(client)
+
+	return c.conclude(result, failed), nil
+}
+
+// client returns a copy of the configured client with a
+// fresh cookie jar.
+func (c Checker) client() (*http.Client, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	client := new(http.Client)
+	if c.Client != nil {
+		*client = *c.Client
+	} else {
+		*client = *checkhttp.DefaultHTTPClient
+	}
+	client.Jar = jar
+	return client, nil
+}
+
+// doChecks runs the steps in order and returns an attempt
+// for each step that was run, along with the name of the
+// step that failed, if any.
+func (c Checker) doChecks(client *http.Client) (types.Attempts, string) {
+	vars := make(map[string]string)
+	for k, v := range c.Variables {
+		vars[k] = v
+	}
+
+	var checks types.Attempts
+	for i, step := range c.Steps {
+		name := step.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		start := time.Now()
+		err := c.doStep(client, step, vars)
+		checks = append(checks, types.Attempt{RTT: time.Since(start)})
+		if err != nil {
+			checks[i].Error = fmt.Sprintf("%s: %v", name, err)
+			return checks, name
+		}
+	}
+	return checks, ""
+}
+
+// doStep performs step, adding extracted values to vars.
+func (c Checker) doStep(client *http.Client, step Step, vars map[string]string) error {
+	method := step.Method
+	if method == "" {
+		method = "GET"
+	}
+	upStatus := step.UpStatus
+	if upStatus == 0 {
+		upStatus = http.StatusOK
+	}
+
+	target, err := c.resolve(expand(step.URL, vars))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(method, target, strings.NewReader(expand(step.Body, vars)))
+	if err != nil {
+		return err
+	}
+	for _, headers := range []http.Header{c.Headers, step.Headers} {
+		for key, header := range headers {
+			value := expand(strings.Join(header, ", "), vars)
+			req.Header.Set(key, value)
+			// net/http has special Host field which we'll fill out
+			if strings.ToLower(key) == "host" {
+				req.Host = value
+			}
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != upStatus {
+		return fmt.Errorf("response status %s", resp.Status)
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading response body: %v", err)
+	}
+	if step.MustContain != "" && !strings.Contains(string(respBody), step.MustContain) {
+		return fmt.Errorf("response does not contain '%s'", step.MustContain)
+	}
+	if step.MustNotContain != "" && strings.Contains(string(respBody), step.MustNotContain) {
+		return fmt.Errorf("response contains '%s'", step.MustNotContain)
+	}
+
+	for _, ex := range step.Extract {
+		value, err := extract(ex, resp.Header, respBody)
+		if err != nil {
+			return fmt.Errorf("extracting %s: %v", ex.Var, err)
+		}
+		vars[ex.Var] = value
+	}
+	return nil
+}
+
+// resolve resolves ref against c.URL.
+func (c Checker) resolve(ref string) (string, error) {
+	if c.URL == "" {
+		return ref, nil
+	}
+	base, err := url.Parse(c.URL)
+	if err != nil {
+		return "", err
+	}
+	u, err := base.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// conclude takes the data in result from the attempts and
+// computes remaining values needed to fill out the result.
+// It detects degraded (slow) flows and makes the conclusion
+// about the result's status.
+func (c Checker) conclude(result types.Result, failed string) types.Result {
+	result.ThresholdRTT = c.ThresholdRTT
+
+	// Check errors (down)
+	for i := range result.Times {
+		if result.Times[i].Error != "" {
+			result.Notice = fmt.Sprintf("step '%s' failed", failed)
+			result.Down = true
+			return result
+		}
+	}
+
+	// Check total time (degraded)
+	if c.ThresholdRTT > 0 {
+		stats := result.ComputeStats()
+		if stats.Total > c.ThresholdRTT {
+			result.Notice = fmt.Sprintf("total time of all steps exceeded threshold (%s)", c.ThresholdRTT)
+			result.Degraded = true
+			return result
+		}
+	}
+
+	result.Healthy = true
+	return result
+}
+
+// varPattern matches a variable reference like {{token}}.
+var varPattern = regexp.MustCompile(`{{\s*([A-Za-z0-9_.-]+)\s*}}`)
+
+// expand replaces variable references in s with their
+// values. Unknown variables are left as they are.
+func expand(s string, vars map[string]string) string {
+	return varPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		name := varPattern.FindStringSubmatch(ref)[1]
+		if v, ok := vars[name]; ok {
+			return v
+		}
+		return ref
+	})
+}
@@ -0,0 +1,167 @@
+package httpflow
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChecker(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Method != "POST" || string(body) != `{"user":"probe"}` {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s3cr3t"})
+		w.Header().Set("X-Request-Id", "42")
+		fmt.Fprint(w, `{"data": {"items": [{"id": 7}], "token": "abc"}}`)
+	})
+	mux.HandleFunc("/items/", func(w http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie("session"); err != nil || c.Value != "s3cr3t" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer abc" || r.Header.Get("X-Request-Id") != "42" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, "<p>item %s, price 12.50</p>", strings.TrimPrefix(r.URL.Path, "/items/"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := Checker{
+		Name: "Test",
+		URL:  srv.URL,
+		Steps: []Step{
+			{
+				Name:   "login",
+				Method: "POST",
+				URL:    "/login",
+				Body:   `{"user":"{{user}}"}`,
+				Extract: []Extraction{
+					{Var: "token", JSONPath: "$.data.token"},
+					{Var: "id", JSONPath: "$.data.items[0].id"},
+					{Var: "request", Header: "X-Request-Id"},
+				},
+			},
+			{
+				Name: "item",
+				URL:  "/items/{{id}}",
+				Headers: http.Header{
+					"Authorization": {"Bearer {{token}}"},
+					"X-Request-Id":  {"{{request}}"},
+				},
+				Extract: []Extraction{{Var: "price", Regex: `price ([0-9.]+)`}},
+			},
+			{
+				Name:        "price",
+				URL:         "/items/{{price}}",
+				Headers:     http.Header{"Authorization": {"Bearer {{token}}"}, "X-Request-Id": {"42"}},
+				MustContain: "item 12.50",
+			},
+		},
+		Variables: map[string]string{"user": "probe"},
+	}
+
+	result, err := c.Check()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Endpoint, srv.URL; got != want {
+		t.Errorf("Expected result.Endpoint='%s', got '%s'", want, got)
+	}
+	if !result.Healthy {
+		t.Fatalf("Expected result.Healthy=true, got %+v", result)
+	}
+	if got, want := len(result.Times), len(c.Steps); got != want {
+		t.Errorf("Expected %d attempts, got %d", want, got)
+	}
+
+	// the session cookie must not leak into the next check
+	c.Steps = c.Steps[1:]
+	result, err = c.Check()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if !result.Down {
+		t.Errorf("Expected result.Down=true, got %+v", result)
+	}
+	if got, want := result.Notice, "step 'item' failed"; got != want {
+		t.Errorf("Expected result.Notice='%s', got '%s'", want, got)
+	}
+	if got, want := len(result.Times), 1; got != want {
+		t.Errorf("Expected %d attempts, got %d", want, got)
+	}
+	if got := result.Times[0].Error; !strings.Contains(got, "401") {
+		t.Errorf("Expected error to contain the status, got '%s'", got)
+	}
+
+	// degraded when the flow is too slow
+	c.Steps = []Step{{Name: "login", Method: "POST", URL: "/login", Body: `{"user":"probe"}`}}
+	c.ThresholdRTT = time.Nanosecond
+	result, err = c.Check()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if !result.Degraded {
+		t.Errorf("Expected result.Degraded=true, got %+v", result)
+	}
+}
+
+func TestExtractFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": []}`)
+	}))
+	defer srv.Close()
+
+	c := Checker{URL: srv.URL, Steps: []Step{
+		{URL: "/", Extract: []Extraction{{Var: "id", JSONPath: "$.data[0].id"}}},
+	}}
+	result, err := c.Check()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Notice, "step '#1' failed"; got != want {
+		t.Errorf("Expected result.Notice='%s', got '%s'", want, got)
+	}
+	if got, want := result.Times[0].Error, "#1: extracting id: $.data[0].id not found"; got != want {
+		t.Errorf("Expected error '%s', got '%s'", want, got)
+	}
+
+	c.Steps[0].Extract[0] = Extraction{Var: "id", Regex: "("}
+	if _, err := c.Check(); err == nil {
+		t.Errorf("Expected an error for an invalid regex")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	doc := map[string]interface{}{
+		"a": []interface{}{map[string]interface{}{"b": "c"}, 1.0},
+	}
+	for path, want := range map[string]interface{}{
+		"$.a[0].b": "c",
+		"a[1]":     1.0,
+		"$.a[0]":   doc["a"].([]interface{})[0],
+	} {
+		got, err := lookup(doc, path)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", path, err)
+			continue
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%s: expected %v, got %v", path, want, got)
+		}
+	}
+	for _, path := range []string{"$.x", "$.a[2]", "$.a.b", "$.a[x]", "$.a[0"} {
+		if _, err := lookup(doc, path); err == nil {
+			t.Errorf("%s: expected an error", path)
+		}
+	}
+}
@@ -0,0 +1,415 @@
+package kafka
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/Shopify/sarama"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "kafka"
+
+// Checker implements a Checker for Kafka clusters. It
+// fetches the cluster metadata and can optionally produce
+// a message to a topic and consume it back, and check the
+// lag of a consumer group.
+type Checker struct {
+	// Name is the name of the endpoint.
+	Name string `json:"endpoint_name"`
+
+	// URL is a comma-separated list of host:port addresses
+	// of the brokers to bootstrap from.
+	URL string `json:"endpoint_url"`
+
+	// Version is the Kafka version of the cluster, such as
+	// "2.4.0". Default is the oldest version that sarama
+	// supports.
+	Version string `json:"version,omitempty"`
+
+	// TLSEnabled controls whether to enable TLS or not.
+	TLSEnabled bool `json:"tls,omitempty"`
+
+	// TLSSkipVerify controls whether to skip server TLS
+	// certificate validation or not.
+	TLSSkipVerify bool `json:"tls_skip_verify,omitempty"`
+
+	// TLSCAFile is the Certificate Authority used
+	// to validate the server TLS certificate.
+	TLSCAFile string `json:"tls_ca_file,omitempty"`
+
+	// Username and Password are the SASL/PLAIN credentials
+	// to connect with, if set.
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+
+	// MinBrokers is the minimum number of brokers that the
+	// cluster metadata must list. Default is 1.
+	MinBrokers int `json:"min_brokers,omitempty"`
+
+	// Topic is a topic to produce a probe message to and
+	// consume it back from. If empty, no message is
+	// produced.
+	Topic string `json:"topic,omitempty"`
+
+	// ConsumerGroup is the consumer group whose lag to
+	// check on LagTopics.
+	ConsumerGroup string `json:"consumer_group,omitempty"`
+
+	// LagTopics are the topics on which to check the lag of
+	// ConsumerGroup.
+	LagTopics []string `json:"lag_topics,omitempty"`
+
+	// MaxLag is the maximum total number of messages that
+	// ConsumerGroup may be behind on LagTopics.
+	MaxLag int64 `json:"max_lag,omitempty"`
+
+	// Raise is a string that tells us if we should mark
+	// the endpoint as down when the lag exceeds MaxLag
+	// ("error" - the default), or if we should just mark
+	// it as degraded ("warn" or "warning").
+	Raise string `json:"raise,omitempty"`
+
+	// Timeout is the maximum time to wait for connecting
+	// and for each request. Default is 10 seconds.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// ThresholdRTT is the maximum round trip time to
+	// allow for a healthy endpoint. It covers connecting,
+	// fetching metadata and the produce/consume round trip.
+	ThresholdRTT time.Duration `json:"threshold_rtt,omitempty"`
+
+	// Attempts is how many times to connect to the
+	// cluster in a single check.
+	Attempts int `json:"attempts,omitempty"`
+
+	// Policy is how the status of the endpoint is
+	// concluded from the attempts.
+	types.Policy
+
+	// CheckerOptions are the options common to all checkers.
+	types.CheckerOptions
+}
+
+// New creates a new Checker instance based on json config
+func New(config json.RawMessage) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	return checker, err
+}
+
+// Type returns the checker package name
+func (Checker) Type() string {
+	return Type
+}
+
+// EndpointName returns the name of the endpoint
+func (c Checker) EndpointName() string {
+	return c.Name
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	if c.Attempts < 1 {
+		c.Attempts = 1
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 10 * time.Second
+	}
+	if c.MinBrokers == 0 {
+		c.MinBrokers = 1
+	}
+
+	result := types.NewResult()
+	result.Title = c.Name
+	result.Endpoint = c.URL
+
+	if err := c.Policy.Validate(); err != nil {
+		return result, err
+	}
+
+	config, err := c.config()
+	if err != nil {
+		return result, err
+	}
+	if c.ConsumerGroup != "" && len(c.LagTopics) == 0 {
+		return result, fmt.Errorf("no lag_topics configured for consumer group %s", c.ConsumerGroup)
+	}
+
+	var probes []probe
+	result.Times, probes = c.doChecks(config)
+
+	return c.conclude(result, probes), nil
+}
+
+// config returns the sarama configuration to connect with.
+func (c Checker) config() (*sarama.Config, error) {
+	config := sarama.NewConfig()
+	config.ClientID = "checkup"
+	config.Net.DialTimeout = c.Timeout
+	config.Net.ReadTimeout = c.Timeout
+	config.Net.WriteTimeout = c.Timeout
+	config.Metadata.Retry.Max = 0
+	config.Producer.Return.Successes = true
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Retry.Max = 0
+	config.Producer.Timeout = c.Timeout
+
+	if c.Version != "" {
+		version, err := sarama.ParseKafkaVersion(c.Version)
+		if err != nil {
+			return nil, err
+		}
+		config.Version = version
+	}
+	if c.TLSEnabled {
+		config.Net.TLS.Enable = true
+		config.Net.TLS.Config = &tls.Config{InsecureSkipVerify: c.TLSSkipVerify}
+		if c.TLSCAFile != "" {
+			rootPEM, err := ioutil.ReadFile(c.TLSCAFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read root certificate: %v", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(rootPEM) {
+				return nil, fmt.Errorf("failed to parse root certificate")
+			}
+			config.Net.TLS.Config.RootCAs = pool
+		}
+	}
+	if c.Username != "" {
+		config.Net.SASL.Enable = true
+		config.Net.SASL.User = c.Username
+		config.Net.SASL.Password = c.Password
+	}
+	return config, config.Validate()
+}
+
+// probe holds the outcome of one attempt.
+type probe struct {
+	connect   time.Duration
+	roundTrip time.Duration
+	brokers   int
+	lag       int64
+}
+
+// doChecks executes and returns each attempt, along with
+// its outcome.
+func (c Checker) doChecks(config *sarama.Config) (types.Attempts, []probe) {
+	checks := make(types.Attempts, c.Attempts)
+	probes := make([]probe, c.Attempts)
+	for i := 0; i < c.Attempts; i++ {
+		start := time.Now()
+		err := c.doProbe(config, &probes[i])
+		checks[i].RTT = time.Since(start)
+		if err != nil {
+			checks[i].Error = err.Error()
+		}
+	}
+	return checks, probes
+}
+
+// doProbe connects to the cluster and performs the
+// configured checks, recording the outcome in p.
+func (c Checker) doProbe(config *sarama.Config, p *probe) error {
+	start := time.Now()
+	client, err := sarama.NewClient(strings.Split(c.URL, ","), config)
+	p.connect = time.Since(start)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	p.brokers = len(client.Brokers())
+
+	if c.Topic != "" {
+		start := time.Now()
+		err := c.roundTrip(client)
+		p.roundTrip = time.Since(start)
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.ConsumerGroup != "" {
+		if p.lag, err = c.lag(client); err != nil {
+			return fmt.Errorf("consumer group %s: %v", c.ConsumerGroup, err)
+		}
+	}
+	return nil
+}
+
+// roundTrip produces a probe message to c.Topic and waits
+// until it is consumed back.
+func (c Checker) roundTrip(client sarama.Client) error {
+	producer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		return err
+	}
+	defer producer.Close()
+
+	payload := newPayload()
+	partition, offset, err := producer.SendMessage(&sarama.ProducerMessage{
+		Topic: c.Topic,
+		Value: sarama.ByteEncoder(payload),
+	})
+	if err != nil {
+		return fmt.Errorf("producing to %s: %v", c.Topic, err)
+	}
+
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		return err
+	}
+	defer consumer.Close()
+	pc, err := consumer.ConsumePartition(c.Topic, partition, offset)
+	if err != nil {
+		return fmt.Errorf("consuming from %s: %v", c.Topic, err)
+	}
+	defer pc.Close()
+
+	timeout := time.After(c.Timeout)
+	for {
+		select {
+		case msg := <-pc.Messages():
+			if bytes.Equal(msg.Value, payload) {
+				return nil
+			}
+		case err := <-pc.Errors():
+			return fmt.Errorf("consuming from %s: %v", c.Topic, err.Err)
+		case <-timeout:
+			return fmt.Errorf("probe message not consumed within %s", c.Timeout)
+		}
+	}
+}
+
+// lag returns the total number of messages that
+// c.ConsumerGroup is behind on c.LagTopics. Partitions
+// without a committed offset are not counted.
+func (c Checker) lag(client sarama.Client) (int64, error) {
+	topicPartitions := make(map[string][]int32)
+	for _, topic := range c.LagTopics {
+		partitions, err := client.Partitions(topic)
+		if err != nil {
+			return 0, err
+		}
+		topicPartitions[topic] = partitions
+	}
+
+	admin, err := sarama.NewClusterAdminFromClient(client)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := admin.ListConsumerGroupOffsets(c.ConsumerGroup, topicPartitions)
+	if err != nil {
+		return 0, err
+	}
+
+	var lag int64
+	for topic, partitions := range topicPartitions {
+		for _, partition := range partitions {
+			block := resp.GetBlock(topic, partition)
+			if block == nil || block.Offset < 0 {
+				continue
+			}
+			if block.Err != sarama.ErrNoError {
+				return 0, block.Err
+			}
+			newest, err := client.GetOffset(topic, partition, sarama.OffsetNewest)
+			if err != nil {
+				return 0, err
+			}
+			if newest > block.Offset {
+				lag += newest - block.Offset
+			}
+		}
+	}
+	return lag, nil
+}
+
+// conclude takes the data in result from the attempts and
+// computes remaining values needed to fill out the result.
+// It detects degraded (high-latency) responses and makes
+// the conclusion about the result's status.
+func (c Checker) conclude(result types.Result, probes []probe) types.Result {
+	result.ThresholdRTT = c.ThresholdRTT
+
+	// Check errors (down)
+	if c.Policy.Failed(result.Times) {
+		result.Down = true
+		return result
+	}
+
+	// the policy may tolerate some failed attempts
+	var succeeded []probe
+	for i := range result.Times {
+		if result.Times[i].Error == "" {
+			succeeded = append(succeeded, probes[i])
+		}
+	}
+	probes = succeeded
+
+	var connect, roundTrip time.Duration
+	for _, p := range probes {
+		connect += p.connect
+		roundTrip += p.roundTrip
+	}
+	n := time.Duration(len(probes))
+	result.Notice = fmt.Sprintf("connect %s", connect/n)
+	if c.Topic != "" {
+		result.Notice += fmt.Sprintf(", round trip %s", roundTrip/n)
+	}
+
+	// Check broker count (down)
+	for _, p := range probes {
+		if p.brokers < c.MinBrokers {
+			result.Notice = fmt.Sprintf("%d brokers available, want at least %d", p.brokers, c.MinBrokers)
+			result.Down = true
+			return result
+		}
+	}
+
+	// Check consumer group lag (down, degraded)
+	if c.ConsumerGroup != "" {
+		for _, p := range probes {
+			if p.lag > c.MaxLag {
+				result.Notice = fmt.Sprintf("consumer group %s is %d messages behind, want at most %d",
+					c.ConsumerGroup, p.lag, c.MaxLag)
+				if c.Raise == "warn" || c.Raise == "warning" {
+					result.Degraded = true
+				} else {
+					result.Down = true
+				}
+				return result
+			}
+		}
+	}
+
+	// Check round trip time (degraded)
+	if notice := c.Policy.Slow(result, c.ThresholdRTT); notice != "" {
+		result.Notice = notice
+		result.Degraded = true
+		return result
+	}
+
+	result.Healthy = true
+	return result
+}
+
+// newPayload returns a unique probe message. It may be
+// replaced for mocking in tests.
+var newPayload = func() []byte {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return []byte("checkup probe " + hex.EncodeToString(b))
+}
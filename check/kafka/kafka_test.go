@@ -0,0 +1,122 @@
+package kafka
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+func newMockBroker(t *testing.T) *sarama.MockBroker {
+	broker := sarama.NewMockBroker(t, 1)
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetController(broker.BrokerID()).
+			SetLeader("probes", 0, broker.BrokerID()).
+			SetLeader("orders", 0, broker.BrokerID()),
+		"ProduceRequest": sarama.NewMockProduceResponse(t).SetVersion(3),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).SetVersion(1).
+			SetOffset("probes", 0, sarama.OffsetOldest, 0).
+			SetOffset("probes", 0, sarama.OffsetNewest, 1).
+			SetOffset("orders", 0, sarama.OffsetNewest, 120),
+		"FetchRequest": sarama.NewMockFetchResponse(t, 1).SetVersion(4).
+			SetMessage("probes", 0, 0, sarama.StringEncoder("checkup probe test")),
+		"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(t).
+			SetCoordinator(sarama.CoordinatorGroup, "billing", broker),
+		"OffsetFetchRequest": sarama.NewMockOffsetFetchResponse(t).
+			SetOffset("billing", "orders", 0, 100, "", sarama.ErrNoError),
+	})
+	return broker
+}
+
+func TestChecker(t *testing.T) {
+	newPayload = func() []byte { return []byte("checkup probe test") }
+	broker := newMockBroker(t)
+	defer broker.Close()
+
+	c := Checker{Name: "Test", URL: broker.Addr(), Version: "1.0.0", Topic: "probes", Timeout: time.Second}
+	result, err := c.Check()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Endpoint, c.URL; got != want {
+		t.Errorf("Expected result.Endpoint='%s', got '%s'", want, got)
+	}
+	if !result.Healthy {
+		t.Fatalf("Expected result.Healthy=true, got %+v", result)
+	}
+	if !strings.HasPrefix(result.Notice, "connect ") || !strings.Contains(result.Notice, ", round trip ") {
+		t.Errorf("Expected notice with connect and round trip latency, got '%s'", result.Notice)
+	}
+
+	// Broker count (down)
+	c.MinBrokers = 3
+	if result, err = c.Check(); err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Notice, "1 brokers available, want at least 3"; !result.Down || got != want {
+		t.Errorf("Expected result.Down=true with notice '%s', got %+v", want, result)
+	}
+
+	// Consumer group lag (degraded)
+	c = Checker{URL: broker.Addr(), Version: "1.0.0", ConsumerGroup: "billing", LagTopics: []string{"orders"},
+		MaxLag: 10, Raise: "warn"}
+	if result, err = c.Check(); err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Notice, "consumer group billing is 20 messages behind, want at most 10"; !result.Degraded || got != want {
+		t.Errorf("Expected result.Degraded=true with notice '%s', got %+v", want, result)
+	}
+	c.MaxLag = 20
+	if result, err = c.Check(); err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if !result.Healthy {
+		t.Errorf("Expected result.Healthy=true, got %+v", result)
+	}
+
+	// Configuration errors
+	for _, c := range []Checker{
+		{URL: broker.Addr(), Version: "x"},
+		{URL: broker.Addr(), ConsumerGroup: "billing"},
+	} {
+		if _, err := c.Check(); err == nil {
+			t.Errorf("%+v: expected an error", c)
+		}
+	}
+}
+
+func TestCheckerRoundTripTimeout(t *testing.T) {
+	newPayload = func() []byte { return []byte("checkup probe other") }
+	broker := newMockBroker(t)
+	defer broker.Close()
+
+	c := Checker{URL: broker.Addr(), Version: "1.0.0", Topic: "probes", Timeout: 200 * time.Millisecond}
+	result, err := c.Check()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if !result.Down {
+		t.Errorf("Expected result.Down=true, got %+v", result)
+	}
+	if got, want := result.Times[0].Error, "probe message not consumed within 200ms"; got != want {
+		t.Errorf("Expected error '%s', got '%s'", want, got)
+	}
+}
+
+func TestCheckerUnreachable(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	addr := broker.Addr()
+	broker.Close()
+
+	c := Checker{URL: addr, Timeout: 200 * time.Millisecond}
+	result, err := c.Check()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if !result.Down {
+		t.Errorf("Expected result.Down=true, got %+v", result)
+	}
+}
@@ -0,0 +1,363 @@
+package ldap
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "ldap"
+
+// Checker implements a Checker for LDAP directories. It
+// connects to the server, binds with the configured
+// credentials and runs a search, asserting on the entries
+// that are returned.
+type Checker struct {
+	// Name is the name of the endpoint.
+	Name string `json:"endpoint_name"`
+
+	// URL is the URL of the server, such as
+	// "ldap://ldap.example.com" or "ldaps://ldap.example.com".
+	URL string `json:"endpoint_url"`
+
+	// StartTLS controls whether to upgrade ldap://
+	// connections to TLS with the StartTLS operation.
+	StartTLS bool `json:"start_tls,omitempty"`
+
+	// TLSSkipVerify controls whether to skip server TLS
+	// certificate validation or not.
+	TLSSkipVerify bool `json:"tls_skip_verify,omitempty"`
+
+	// TLSCAFile is the Certificate Authority used
+	// to validate the server TLS certificate.
+	TLSCAFile string `json:"tls_ca_file,omitempty"`
+
+	// BindDN and BindPassword are the credentials to bind
+	// with. If BindDN is empty, no bind is performed.
+	BindDN       string `json:"bind_dn,omitempty"`
+	BindPassword string `json:"bind_password,omitempty"`
+
+	// BaseDN is the base of the search. If both BaseDN
+	// and Filter are empty, no search is performed.
+	BaseDN string `json:"base_dn,omitempty"`
+
+	// Filter is the search filter. Default is
+	// "(objectClass=*)".
+	Filter string `json:"filter,omitempty"`
+
+	// Scope is the search scope: "base", "one" or "sub".
+	// Default is "sub".
+	Scope string `json:"scope,omitempty"`
+
+	// MinEntries is the minimum number of entries that the
+	// search must return. Default is 1.
+	MinEntries int `json:"min_entries,omitempty"`
+
+	// MaxEntries is the maximum number of entries that the
+	// search may return. If zero, there is no maximum.
+	MaxEntries int `json:"max_entries,omitempty"`
+
+	// Attributes maps attribute names to values that at
+	// least one of the returned entries must have.
+	Attributes map[string]string `json:"attributes,omitempty"`
+
+	// Timeout is the maximum time to wait for the
+	// connection and for each operation. Default is
+	// 5 seconds.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// ThresholdRTT is the maximum round trip time to
+	// allow for a healthy endpoint. It covers connecting,
+	// binding and searching.
+	ThresholdRTT time.Duration `json:"threshold_rtt,omitempty"`
+
+	// Attempts is how many times to connect, bind and
+	// search in a single check.
+	Attempts int `json:"attempts,omitempty"`
+
+	// Policy is how the status of the endpoint is
+	// concluded from the attempts.
+	types.Policy
+
+	// CheckerOptions are the options common to all checkers.
+	types.CheckerOptions
+}
+
+// New creates a new Checker instance based on json config
+func New(config json.RawMessage) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	return checker, err
+}
+
+// Type returns the checker package name
+func (Checker) Type() string {
+	return Type
+}
+
+// EndpointName returns the name of the endpoint
+func (c Checker) EndpointName() string {
+	return c.Name
+}
+
+// scopes maps the configurable scopes to ldap scopes.
+var scopes = map[string]int{
+	"base": ldap.ScopeBaseObject,
+	"one":  ldap.ScopeSingleLevel,
+	"sub":  ldap.ScopeWholeSubtree,
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	if c.Attempts < 1 {
+		c.Attempts = 1
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 5 * time.Second
+	}
+	if c.Scope == "" {
+		c.Scope = "sub"
+	}
+	if c.MinEntries == 0 {
+		c.MinEntries = 1
+	}
+
+	result := types.NewResult()
+	result.Title = c.Name
+	result.Endpoint = c.URL
+
+	if err := c.Policy.Validate(); err != nil {
+		return result, err
+	}
+
+	if _, ok := scopes[c.Scope]; !ok {
+		return result, fmt.Errorf("invalid scope '%s'", c.Scope)
+	}
+	if c.search() {
+		if _, err := ldap.CompileFilter(c.filter()); err != nil {
+			return result, fmt.Errorf("invalid filter: %v", err)
+		}
+	}
+	tlsConfig, err := c.tlsConfig()
+	if err != nil {
+		return result, err
+	}
+
+	var ops []operation
+	result.Times, ops = c.doChecks(tlsConfig)
+
+	return c.conclude(result, ops), nil
+}
+
+// search returns whether c is configured to search.
+func (c Checker) search() bool {
+	return c.BaseDN != "" || c.Filter != ""
+}
+
+// filter returns the search filter of c.
+func (c Checker) filter() string {
+	if c.Filter == "" {
+		return "(objectClass=*)"
+	}
+	return c.Filter
+}
+
+// tlsConfig returns the TLS configuration to connect with.
+func (c Checker) tlsConfig() (*tls.Config, error) {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		ServerName:         u.Hostname(),
+		InsecureSkipVerify: c.TLSSkipVerify,
+	}
+	if c.TLSCAFile != "" {
+		rootPEM, err := ioutil.ReadFile(c.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read root certificate: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(rootPEM) {
+			return nil, fmt.Errorf("failed to parse root certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// operation holds the outcome of the LDAP operations of
+// one attempt.
+type operation struct {
+	bind    time.Duration
+	search  time.Duration
+	entries []*ldap.Entry
+}
+
+// doChecks executes and returns each attempt, along with the
+// outcome of its operations.
+func (c Checker) doChecks(tlsConfig *tls.Config) (types.Attempts, []operation) {
+	checks := make(types.Attempts, c.Attempts)
+	ops := make([]operation, c.Attempts)
+	for i := 0; i < c.Attempts; i++ {
+		start := time.Now()
+		err := c.doOperations(tlsConfig, &ops[i])
+		checks[i].RTT = time.Since(start)
+		if err != nil {
+			checks[i].Error = err.Error()
+		}
+	}
+	return checks, ops
+}
+
+// doOperations connects, binds and searches, recording the
+// latency of the bind and search in op.
+func (c Checker) doOperations(tlsConfig *tls.Config, op *operation) error {
+	conn, err := ldap.DialURL(c.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: c.Timeout}),
+		ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetTimeout(c.Timeout)
+
+	if c.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("starttls: %v", err)
+		}
+	}
+
+	if c.BindDN != "" {
+		start := time.Now()
+		err := conn.Bind(c.BindDN, c.BindPassword)
+		op.bind = time.Since(start)
+		if err != nil {
+			return fmt.Errorf("bind: %v", err)
+		}
+	}
+
+	if c.search() {
+		attributes := []string{"1.1"} // no attributes
+		if len(c.Attributes) > 0 {
+			attributes = attributes[:0]
+			for name := range c.Attributes {
+				attributes = append(attributes, name)
+			}
+		}
+		req := ldap.NewSearchRequest(c.BaseDN, scopes[c.Scope], ldap.NeverDerefAliases,
+			0, int(c.Timeout/time.Second), false, c.filter(), attributes, nil)
+		start := time.Now()
+		res, err := conn.Search(req)
+		op.search = time.Since(start)
+		if err != nil {
+			return fmt.Errorf("search: %v", err)
+		}
+		op.entries = res.Entries
+	}
+	return nil
+}
+
+// conclude takes the data in result from the attempts and
+// computes remaining values needed to fill out the result.
+// It checks the search results, detects degraded
+// (high-latency) responses and makes the conclusion about
+// the result's status.
+func (c Checker) conclude(result types.Result, ops []operation) types.Result {
+	result.ThresholdRTT = c.ThresholdRTT
+
+	// Check errors (down)
+	if c.Policy.Failed(result.Times) {
+		result.Down = true
+		return result
+	}
+
+	// the policy may tolerate some failed attempts
+	var succeeded []operation
+	for i := range result.Times {
+		if result.Times[i].Error == "" {
+			succeeded = append(succeeded, ops[i])
+		}
+	}
+	ops = succeeded
+
+	var bind, search time.Duration
+	for _, op := range ops {
+		bind += op.bind
+		search += op.search
+	}
+	n := time.Duration(len(ops))
+	result.Notice = fmt.Sprintf("bind %s, search %s", bind/n, search/n)
+
+	// Check search results (down)
+	if c.search() {
+		for _, op := range ops {
+			if violations := c.checkEntries(op.entries); len(violations) > 0 {
+				result.Notice = strings.Join(violations, "; ")
+				result.Down = true
+				return result
+			}
+		}
+	}
+
+	// Check round trip time (degraded)
+	if notice := c.Policy.Slow(result, c.ThresholdRTT); notice != "" {
+		result.Notice = notice
+		result.Degraded = true
+		return result
+	}
+
+	result.Healthy = true
+	return result
+}
+
+// checkEntries returns a description of each way in which
+// entries do not meet the requirements of c.
+func (c Checker) checkEntries(entries []*ldap.Entry) []string {
+	var violations []string
+	if n := len(entries); n < c.MinEntries || (c.MaxEntries > 0 && n > c.MaxEntries) {
+		want := fmt.Sprintf("at least %d", c.MinEntries)
+		if c.MaxEntries > 0 {
+			want = fmt.Sprintf("%d to %d", c.MinEntries, c.MaxEntries)
+		}
+		violations = append(violations, fmt.Sprintf("%d entries found, want %s", n, want))
+	}
+
+	var names []string
+	for name := range c.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !hasValue(entries, name, c.Attributes[name]) {
+			violations = append(violations, fmt.Sprintf("no entry has %s=%s", name, c.Attributes[name]))
+		}
+	}
+	return violations
+}
+
+// hasValue returns whether any of entries has the value
+// for the attribute name.
+func hasValue(entries []*ldap.Entry, name, value string) bool {
+	for _, entry := range entries {
+		for _, v := range entry.GetEqualFoldAttributeValues(name) {
+			if v == value {
+				return true
+			}
+		}
+	}
+	return false
+}
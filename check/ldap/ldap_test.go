@@ -0,0 +1,233 @@
+package ldap
+
+import (
+	"crypto/tls"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+// server is a minimal in-process LDAP server with a fixed
+// directory below dc=example,dc=com.
+type server struct {
+	password  string
+	tlsConfig *tls.Config
+	entries   map[string]map[string]string
+}
+
+func (s *server) listen(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return l.Addr().String()
+}
+
+func (s *server) serve(conn net.Conn) {
+	defer func() { conn.Close() }()
+	for {
+		p, err := ber.ReadPacket(conn)
+		if err != nil || len(p.Children) < 2 {
+			return
+		}
+		id := p.Children[0].Value.(int64)
+		op := p.Children[1]
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			code := ldap.LDAPResultSuccess
+			if op.Children[2].Data.String() != s.password {
+				code = ldap.LDAPResultInvalidCredentials
+			}
+			conn.Write(response(id, ldap.ApplicationBindResponse, code))
+		case ldap.ApplicationSearchRequest:
+			base := op.Children[0].Value.(string)
+			if !strings.HasSuffix(base, "dc=example,dc=com") {
+				conn.Write(response(id, ldap.ApplicationSearchResultDone, ldap.LDAPResultNoSuchObject))
+				continue
+			}
+			for dn, attrs := range s.entries {
+				conn.Write(entry(id, dn, attrs))
+			}
+			conn.Write(response(id, ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess))
+		case ldap.ApplicationExtendedRequest:
+			conn.Write(response(id, ldap.ApplicationExtendedResponse, ldap.LDAPResultSuccess))
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+		default:
+			return
+		}
+	}
+}
+
+func envelope(id int64, op *ber.Packet) []byte {
+	p := ber.NewSequence("LDAP Response")
+	p.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, "MessageID"))
+	p.AppendChild(op)
+	return p.Bytes()
+}
+
+func response(id int64, tag ber.Tag, code int) []byte {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Response")
+	op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), "resultCode"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matchedDN"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "diagnosticMessage"))
+	return envelope(id, op)
+}
+
+func entry(id int64, dn string, attrs map[string]string) []byte {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "Entry")
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, dn, "objectName"))
+	list := ber.NewSequence("attributes")
+	for name, value := range attrs {
+		attr := ber.NewSequence("attribute")
+		attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "type"))
+		vals := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "vals")
+		vals.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "value"))
+		attr.AppendChild(vals)
+		list.AppendChild(attr)
+	}
+	op.AppendChild(list)
+	return envelope(id, op)
+}
+
+func TestChecker(t *testing.T) {
+	s := &server{
+		password: "secret",
+		entries: map[string]map[string]string{
+			"uid=alice,ou=people,dc=example,dc=com": {"uid": "alice", "mail": "alice@example.com"},
+			"uid=bob,ou=people,dc=example,dc=com":   {"uid": "bob", "mail": "bob@example.com"},
+		},
+	}
+	addr := s.listen(t)
+
+	c := Checker{
+		Name:         "Test",
+		URL:          "ldap://" + addr,
+		BindDN:       "cn=checkup,dc=example,dc=com",
+		BindPassword: "secret",
+		BaseDN:       "ou=people,dc=example,dc=com",
+		Filter:       "(objectClass=person)",
+		MinEntries:   2,
+		Attributes:   map[string]string{"mail": "bob@example.com"},
+		Attempts:     2,
+	}
+	result, err := c.Check()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Endpoint, c.URL; got != want {
+		t.Errorf("Expected result.Endpoint='%s', got '%s'", want, got)
+	}
+	if !result.Healthy {
+		t.Errorf("Expected result.Healthy=true, got %+v", result)
+	}
+	if got, want := len(result.Times), c.Attempts; got != want {
+		t.Errorf("Expected %d attempts, got %d", want, got)
+	}
+	if !strings.HasPrefix(result.Notice, "bind ") || !strings.Contains(result.Notice, ", search ") {
+		t.Errorf("Expected notice with bind and search latency, got '%s'", result.Notice)
+	}
+
+	// Search assertions (down)
+	c.MaxEntries = 1
+	c.Attributes = map[string]string{"mail": "carol@example.com"}
+	if result, err = c.Check(); err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if !result.Down {
+		t.Errorf("Expected result.Down=true, got %+v", result)
+	}
+	if got, want := result.Notice, "2 entries found, want 2 to 1; no entry has mail=carol@example.com"; got != want {
+		t.Errorf("Expected result.Notice='%s', got '%s'", want, got)
+	}
+
+	// Failing search
+	c = Checker{URL: "ldap://" + addr, BaseDN: "dc=example,dc=org"}
+	if result, err = c.Check(); err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if !result.Down || !strings.HasPrefix(result.Times[0].Error, "search: ") {
+		t.Errorf("Expected result.Down=true with search error, got %+v", result)
+	}
+
+	// Bad credentials
+	c = Checker{URL: "ldap://" + addr, BindDN: "cn=checkup,dc=example,dc=com", BindPassword: "wrong"}
+	if result, err = c.Check(); err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if !result.Down || !strings.HasPrefix(result.Times[0].Error, "bind: ") {
+		t.Errorf("Expected result.Down=true with bind error, got %+v", result)
+	}
+
+	// Slow responses (degraded)
+	c = Checker{URL: "ldap://" + addr, BaseDN: "dc=example,dc=com", ThresholdRTT: time.Nanosecond}
+	if result, err = c.Check(); err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if !result.Degraded {
+		t.Errorf("Expected result.Degraded=true, got %+v", result)
+	}
+
+	// Configuration errors
+	for _, c := range []Checker{
+		{URL: "ldap://" + addr, Scope: "all"},
+		{URL: "ldap://" + addr, Filter: "objectClass=*("},
+	} {
+		if _, err := c.Check(); err == nil {
+			t.Errorf("%+v: expected an error", c)
+		}
+	}
+}
+
+func TestCheckerStartTLS(t *testing.T) {
+	cert, err := tls.LoadX509KeyPair("testdata/leaf.pem", "testdata/leaf.key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &server{
+		tlsConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+		entries:   map[string]map[string]string{"dc=example,dc=com": {"dc": "example"}},
+	}
+	addr := s.listen(t)
+
+	c := Checker{
+		Name:      "Test",
+		URL:       "ldap://" + addr,
+		StartTLS:  true,
+		TLSCAFile: "testdata/root.pem",
+		BaseDN:    "dc=example,dc=com",
+		Scope:     "base",
+	}
+	result, err := c.Check()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if !result.Healthy {
+		t.Errorf("Expected result.Healthy=true, got %+v", result)
+	}
+
+	// Untrusted certificate
+	c.TLSCAFile = ""
+	if result, err = c.Check(); err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if !result.Down || !strings.HasPrefix(result.Times[0].Error, "starttls: ") {
+		t.Errorf("Expected result.Down=true with starttls error, got %+v", result)
+	}
+}
@@ -0,0 +1,194 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Assertion is a comparison of the samples of a metric
+// against a threshold.
+type Assertion struct {
+	// Expr is the comparison, of the form
+	// `name{label="value",...} op threshold`, where op is
+	// one of ==, !=, <, <=, >, >= and the label matchers
+	// (which may also use !=) are optional. For example:
+	// `up == 1` or `queue_depth{queue="jobs"} < 1000`.
+	// Every sample that matches must satisfy the
+	// comparison, and at least one sample must match.
+	Expr string `json:"expr"`
+
+	// Raise is a string that tells us if a violated
+	// assertion should mark the endpoint as down ("error"
+	// - the default), or just as degraded ("warn" or
+	// "warning").
+	Raise string `json:"raise,omitempty"`
+}
+
+// warning returns whether a violation of a should only
+// degrade the endpoint.
+func (a Assertion) warning() bool {
+	return a.Raise == "warn" || a.Raise == "warning"
+}
+
+// expr is a parsed Assertion.Expr.
+type expr struct {
+	name      string
+	matchers  []matcher
+	op        string
+	threshold float64
+}
+
+// matcher matches a label of a sample.
+type matcher struct {
+	label  string
+	value  string
+	negate bool
+}
+
+// operators are the supported comparison operators;
+// two-character operators come first so they match first.
+var operators = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// parseExpr parses s as an assertion expression.
+func parseExpr(s string) (expr, error) {
+	var e expr
+	rest := strings.TrimSpace(s)
+
+	i := strings.IndexFunc(rest, func(r rune) bool {
+		return !(r == '_' || r == ':' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+	if i <= 0 {
+		return e, fmt.Errorf("invalid assertion '%s': missing metric name or comparison", s)
+	}
+	e.name, rest = rest[:i], strings.TrimSpace(rest[i:])
+
+	if strings.HasPrefix(rest, "{") {
+		var err error
+		e.matchers, rest, err = parseMatchers(rest[1:])
+		if err != nil {
+			return e, fmt.Errorf("invalid assertion '%s': %v", s, err)
+		}
+		rest = strings.TrimSpace(rest)
+	}
+
+	for _, op := range operators {
+		if strings.HasPrefix(rest, op) {
+			e.op, rest = op, rest[len(op):]
+			break
+		}
+	}
+	if e.op == "" {
+		return e, fmt.Errorf("invalid assertion '%s': missing comparison operator", s)
+	}
+
+	v, err := parseValue(strings.TrimSpace(rest))
+	if err != nil {
+		return e, fmt.Errorf("invalid assertion '%s': %v", s, err)
+	}
+	e.threshold = v
+	return e, nil
+}
+
+// parseMatchers parses label matchers up to and including
+// the closing brace, returning the remainder of s.
+func parseMatchers(s string) ([]matcher, string, error) {
+	var matchers []matcher
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if strings.HasPrefix(s, "}") {
+			return matchers, s[1:], nil
+		}
+		eq := strings.Index(s, "=")
+		if eq <= 0 {
+			return nil, "", fmt.Errorf("malformed label matcher in '%s'", s)
+		}
+		var m matcher
+		m.label = s[:eq]
+		if strings.HasSuffix(m.label, "!") {
+			m.label, m.negate = m.label[:len(m.label)-1], true
+		}
+		m.label = strings.TrimSpace(m.label)
+		rest := strings.TrimSpace(s[eq+1:])
+		if !strings.HasPrefix(rest, `"`) {
+			return nil, "", fmt.Errorf("label value must be quoted in '%s'", s)
+		}
+		var err error
+		m.value, s, err = unquote(rest)
+		if err != nil {
+			return nil, "", err
+		}
+		matchers = append(matchers, m)
+	}
+}
+
+// matches returns whether smp is selected by e.
+func (e expr) matches(smp sample) bool {
+	if smp.name != e.name {
+		return false
+	}
+	for _, m := range e.matchers {
+		if (smp.labels[m.label] == m.value) == m.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// compare returns whether v satisfies the comparison of e.
+func (e expr) compare(v float64) bool {
+	switch e.op {
+	case "==":
+		return v == e.threshold
+	case "!=":
+		return v != e.threshold
+	case "<":
+		return v < e.threshold
+	case "<=":
+		return v <= e.threshold
+	case ">":
+		return v > e.threshold
+	case ">=":
+		return v >= e.threshold
+	}
+	return false
+}
+
+// evaluate returns a non-nil error describing the violation
+// if samples do not satisfy e.
+func (e expr) evaluate(samples []sample) error {
+	var matched bool
+	for _, smp := range samples {
+		if !e.matches(smp) {
+			continue
+		}
+		matched = true
+		if !e.compare(smp.value) {
+			return fmt.Errorf("got %s%s = %s", smp.name, formatLabels(smp.labels),
+				strconv.FormatFloat(smp.value, 'g', -1, 64))
+		}
+	}
+	if !matched {
+		return fmt.Errorf("no samples found")
+	}
+	return nil
+}
+
+// formatLabels renders labels in exposition format, sorted
+// by label name.
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf("%s=%q", name, labels[name])
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
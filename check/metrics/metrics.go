@@ -0,0 +1,215 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	checkhttp "github.com/sourcegraph/checkup/check/http"
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "metrics"
+
+// Checker implements a Checker that scrapes a Prometheus or
+// OpenMetrics text endpoint and evaluates assertions on the
+// scraped samples.
+type Checker struct {
+	// Name is the name of the endpoint.
+	Name string `json:"endpoint_name"`
+
+	// URL is the URL of the metrics endpoint.
+	URL string `json:"endpoint_url"`
+
+	// Assertions are evaluated against the samples of the
+	// last successful scrape. A violated assertion marks
+	// the endpoint as down or degraded, according to its
+	// Raise setting.
+	Assertions []Assertion `json:"assertions,omitempty"`
+
+	// ThresholdRTT is the maximum round trip time to
+	// allow for a healthy endpoint. If non-zero and a
+	// request takes longer than ThresholdRTT, the
+	// endpoint will be considered unhealthy. Note that
+	// this duration includes any in-between network
+	// latency.
+	ThresholdRTT time.Duration `json:"threshold_rtt,omitempty"`
+
+	// Attempts is how many requests the client will
+	// make to the endpoint in a single check.
+	Attempts int `json:"attempts,omitempty"`
+
+	// AttemptSpacing spaces out each attempt in a check
+	// by this duration to avoid hitting a remote too
+	// quickly in succession. By default, no waiting
+	// occurs between attempts.
+	AttemptSpacing time.Duration `json:"attempt_spacing,omitempty"`
+
+	// Client is the http.Client with which to make
+	// requests. If not set, the DefaultHTTPClient of
+	// the http checker is used.
+	Client *http.Client `json:"-"`
+
+	// Headers contains headers to added to the request
+	// that is sent for the check
+	Headers http.Header `json:"headers,omitempty"`
+
+	// Policy is how the status of the endpoint is
+	// concluded from the attempts.
+	types.Policy
+
+	// CheckerOptions are the options common to all checkers.
+	types.CheckerOptions
+}
+
+// New creates a new Checker instance based on json config
+func New(config json.RawMessage) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	return checker, err
+}
+
+// Type returns the checker package name
+func (Checker) Type() string {
+	return Type
+}
+
+// EndpointName returns the name of the endpoint
+func (c Checker) EndpointName() string {
+	return c.Name
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	if c.Attempts < 1 {
+		c.Attempts = 1
+	}
+	if c.Client == nil {
+		c.Client = checkhttp.DefaultHTTPClient
+	}
+
+	result := types.NewResult()
+	result.Title = c.Name
+	result.Endpoint = c.URL
+
+	if err := c.Policy.Validate(); err != nil {
+		return result, err
+	}
+
+	exprs := make([]expr, len(c.Assertions))
+	for i, a := range c.Assertions {
+		e, err := parseExpr(a.Expr)
+		if err != nil {
+			return result, err
+		}
+		exprs[i] = e
+	}
+
+	req, err := http.NewRequest("GET", c.URL, nil)
+	if err != nil {
+		return result, err
+	}
+	req.Header.Set("Accept", "application/openmetrics-text;version=1.0.0,text/plain;version=0.0.4;q=0.5,*/*;q=0.1")
+
+	if c.Headers != nil {
+		for key, header := range c.Headers {
+			req.Header.Add(key, strings.Join(header, ", "))
+			// net/http has special Host field which we'll fill out
+			if strings.ToLower(key) == "host" {
+				req.Host = header[0]
+			}
+		}
+	}
+
+	attempts, samples := c.doChecks(req)
+	result.Times = attempts
+
+	return c.conclude(result, exprs, samples), nil
+}
+
+// doChecks scrapes req using c.Client and returns each attempt
+// along with the samples of the last successful scrape.
+func (c Checker) doChecks(req *http.Request) (types.Attempts, []sample) {
+	var samples []sample
+	checks := make(types.Attempts, c.Attempts)
+	for i := 0; i < c.Attempts; i++ {
+		start := time.Now()
+		s, err := c.scrape(req)
+		checks[i].RTT = time.Since(start)
+		if err != nil {
+			checks[i].Error = err.Error()
+		} else {
+			samples = s
+		}
+		if c.AttemptSpacing > 0 {
+			time.Sleep(c.AttemptSpacing)
+		}
+	}
+	return checks, samples
+}
+
+// scrape executes req and parses the response body.
+func (c Checker) scrape(req *http.Request) ([]sample, error) {
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("response status %s", resp.Status)
+	}
+	samples, err := parse(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("parsing metrics: %v", err)
+	}
+	return samples, nil
+}
+
+// conclude takes the data in result from the attempts and
+// computes remaining values needed to fill out the result.
+// It evaluates the assertions and makes the conclusion
+// about the result's status.
+func (c Checker) conclude(result types.Result, exprs []expr, samples []sample) types.Result {
+	result.ThresholdRTT = c.ThresholdRTT
+
+	// Check errors (down)
+	if c.Policy.Failed(result.Times) {
+		result.Down = true
+		return result
+	}
+
+	// Check assertions (down, degraded)
+	var violations []string
+	warningsOnly := true
+	for i, e := range exprs {
+		if err := e.evaluate(samples); err != nil {
+			violations = append(violations, fmt.Sprintf("%s: %v", c.Assertions[i].Expr, err))
+			if !c.Assertions[i].warning() {
+				warningsOnly = false
+			}
+		}
+	}
+	if len(violations) > 0 {
+		result.Notice = "assertion failed: " + strings.Join(violations, "; ")
+		if warningsOnly {
+			result.Degraded = true
+		} else {
+			result.Down = true
+		}
+		return result
+	}
+
+	// Check round trip time (degraded)
+	if notice := c.Policy.Slow(result, c.ThresholdRTT); notice != "" {
+		result.Notice = notice
+		result.Degraded = true
+		return result
+	}
+
+	result.Healthy = true
+	return result
+}
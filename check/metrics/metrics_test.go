@@ -0,0 +1,159 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const exposition = `# HELP up Whether the service is up.
+# TYPE up gauge
+up 1
+# HELP queue_depth Number of queued items.
+# TYPE queue_depth gauge
+queue_depth{queue="jobs"} 250
+queue_depth{queue="mail",priority="high"} 1500 1585699200000
+http_requests_total{path="/say \"hi\"\n"} 7
+temperature_celsius -Inf
+# EOF
+`
+
+func TestChecker(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, exposition)
+	}))
+	defer srv.Close()
+
+	hc := Checker{
+		Name:     "Test",
+		URL:      srv.URL,
+		Attempts: 2,
+		Headers:  http.Header{"Authorization": []string{"Bearer token"}},
+		Assertions: []Assertion{
+			{Expr: "up == 1"},
+			{Expr: `queue_depth{queue="jobs"} < 1000`},
+		},
+	}
+
+	result, err := hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Title, "Test"; got != want {
+		t.Errorf("Expected result.Title='%s', got '%s'", want, got)
+	}
+	if got, want := result.Healthy, true; got != want {
+		t.Errorf("Expected result.Healthy=%v, got %v (%s %v)", want, got, result.Notice, result.Times)
+	}
+	if got, want := len(result.Times), hc.Attempts; got != want {
+		t.Errorf("Expected %d attempts, got %d", want, got)
+	}
+
+	// A violated warning assertion degrades
+	hc.Assertions = append(hc.Assertions, Assertion{Expr: `queue_depth{queue!="jobs"} < 1000`, Raise: "warn"})
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Degraded, true; got != want {
+		t.Errorf("Expected result.Degraded=%v, got %v", want, got)
+	}
+	if want := `assertion failed: queue_depth{queue!="jobs"} < 1000: got queue_depth{priority="high",queue="mail"} = 1500`; result.Notice != want {
+		t.Errorf("Expected notice '%s', got '%s'", want, result.Notice)
+	}
+
+	// A violated error assertion takes the endpoint down
+	hc.Assertions = append(hc.Assertions, Assertion{Expr: "missing_metric > 0"})
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Down, true; got != want {
+		t.Errorf("Expected result.Down=%v, got %v", want, got)
+	}
+	if !strings.Contains(result.Notice, "missing_metric > 0: no samples found") {
+		t.Errorf("Expected notice to mention missing metric, got '%s'", result.Notice)
+	}
+
+	// Invalid assertions are configuration errors
+	hc.Assertions = []Assertion{{Expr: "up = 1"}}
+	if _, err = hc.Check(); err == nil {
+		t.Error("Expected an error for an invalid assertion, didn't get one")
+	}
+
+	// Scrape failures take the endpoint down
+	hc.Assertions = nil
+	hc.Headers = nil
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Down, true; got != want {
+		t.Errorf("Expected result.Down=%v, got %v", want, got)
+	}
+
+	hc.Headers = http.Header{"Authorization": []string{"Bearer token"}}
+	hc.ThresholdRTT = 1 * time.Nanosecond
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Degraded, true; got != want {
+		t.Errorf("Expected result.Degraded=%v, got %v", want, got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	samples, err := parse(strings.NewReader(exposition))
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if got, want := len(samples), 5; got != want {
+		t.Fatalf("Expected %d samples, got %d", want, got)
+	}
+	if got, want := samples[2].labels["priority"], "high"; got != want {
+		t.Errorf("Expected label priority='%s', got '%s'", want, got)
+	}
+	if got, want := samples[2].value, 1500.0; got != want {
+		t.Errorf("Expected value %v, got %v", want, got)
+	}
+	if got, want := samples[3].labels["path"], "/say \"hi\"\n"; got != want {
+		t.Errorf("Expected escaped label value %q, got %q", want, got)
+	}
+	if !math.IsInf(samples[4].value, -1) {
+		t.Errorf("Expected -Inf, got %v", samples[4].value)
+	}
+
+	for _, bad := range []string{"up", `up{job="x} 1`, "up one"} {
+		if _, err := parse(strings.NewReader(bad)); err == nil {
+			t.Errorf("Expected an error parsing '%s'", bad)
+		}
+	}
+}
+
+func TestParseExpr(t *testing.T) {
+	e, err := parseExpr(`queue_depth{queue="jobs", priority!="low"} >= 1e3`)
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if e.name != "queue_depth" || e.op != ">=" || e.threshold != 1000 || len(e.matchers) != 2 {
+		t.Errorf("Unexpected parse result: %+v", e)
+	}
+	if m := e.matchers[1]; m.label != "priority" || m.value != "low" || !m.negate {
+		t.Errorf("Unexpected matcher: %+v", m)
+	}
+
+	for _, bad := range []string{"", "== 1", "up", "up => 1", `up{job=x} == 1`, "up == one"} {
+		if _, err := parseExpr(bad); err == nil {
+			t.Errorf("Expected an error parsing '%s'", bad)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// sample is a single sample of a metric.
+type sample struct {
+	name   string
+	labels map[string]string
+	value  float64
+}
+
+// parse parses the Prometheus text exposition format, which
+// is also accepted for OpenMetrics. Comments, HELP and TYPE
+// lines as well as timestamps are ignored.
+func parse(r io.Reader) ([]sample, error) {
+	var samples []sample
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		s, err := parseSample(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNum, err)
+		}
+		samples = append(samples, s)
+	}
+	return samples, scanner.Err()
+}
+
+// parseSample parses a line of the form
+// name{label="value",...} value [timestamp].
+func parseSample(line string) (sample, error) {
+	s := sample{labels: map[string]string{}}
+
+	i := strings.IndexAny(line, "{ \t")
+	if i <= 0 {
+		return s, fmt.Errorf("missing value")
+	}
+	s.name, line = line[:i], line[i:]
+
+	if line[0] == '{' {
+		var err error
+		s.labels, line, err = parseLabels(line[1:], "}")
+		if err != nil {
+			return s, err
+		}
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return s, fmt.Errorf("missing value")
+	}
+	v, err := parseValue(fields[0])
+	if err != nil {
+		return s, err
+	}
+	s.value = v
+	return s, nil
+}
+
+// parseLabels parses a comma-separated list of name="value"
+// pairs, up to and including end. It returns the labels
+// and the remainder of s.
+func parseLabels(s, end string) (map[string]string, string, error) {
+	labels := make(map[string]string)
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if strings.HasPrefix(s, end) {
+			return labels, s[len(end):], nil
+		}
+		eq := strings.Index(s, "=")
+		if eq <= 0 || len(s) < eq+2 || s[eq+1] != '"' {
+			return nil, "", fmt.Errorf("malformed label in '%s'", s)
+		}
+		name := strings.TrimSpace(s[:eq])
+		value, rest, err := unquote(s[eq+1:])
+		if err != nil {
+			return nil, "", err
+		}
+		labels[name] = value
+		s = rest
+	}
+}
+
+// unquote reads a double-quoted label value at the start
+// of s, returning it and the remainder of s.
+func unquote(s string) (string, string, error) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			return b.String(), s[i+1:], nil
+		case '\\':
+			i++
+			if i == len(s) {
+				return "", "", fmt.Errorf("unterminated label value")
+			}
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(s[i])
+			}
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", "", fmt.Errorf("unterminated label value")
+}
+
+// parseValue parses a sample value, including the special
+// values used by the exposition format.
+func parseValue(s string) (float64, error) {
+	switch s {
+	case "+Inf", "Inf":
+		return math.Inf(1), nil
+	case "-Inf":
+		return math.Inf(-1), nil
+	case "NaN":
+		return math.NaN(), nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value '%s'", s)
+	}
+	return v, nil
+}
@@ -0,0 +1,339 @@
+package mqtt
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "mqtt"
+
+// Checker implements a Checker for MQTT brokers. It
+// connects to the broker, subscribes to a probe topic and
+// publishes a unique message to it, measuring how long the
+// message takes to be delivered back.
+type Checker struct {
+	// Name is the name of the endpoint.
+	Name string `json:"endpoint_name"`
+
+	// URL is the URL of the broker. The scheme selects the
+	// transport: "tcp" (or "mqtt"), "ssl" (or "tls",
+	// "mqtts") for TLS, and "ws" or "wss" for WebSockets.
+	// If the port is omitted, 1883 or 8883 is used for
+	// TCP and TLS connections.
+	URL string `json:"endpoint_url"`
+
+	// ClientID is the MQTT client identifier. Default is
+	// "checkup-" followed by a random string.
+	ClientID string `json:"client_id,omitempty"`
+
+	// Username and Password are the credentials to
+	// connect with.
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+
+	// Topic is the topic to subscribe and publish probe
+	// messages to. Default is "checkup/" followed by the
+	// client identifier.
+	Topic string `json:"topic,omitempty"`
+
+	// QoS is the quality of service level, 0 or 1, to
+	// subscribe and publish with.
+	QoS byte `json:"qos,omitempty"`
+
+	// TLSSkipVerify controls whether to skip server TLS
+	// certificate validation or not.
+	TLSSkipVerify bool `json:"tls_skip_verify,omitempty"`
+
+	// TLSCAFile is the Certificate Authority used
+	// to validate the server TLS certificate.
+	TLSCAFile string `json:"tls_ca_file,omitempty"`
+
+	// TLSCertFile and TLSKeyFile are the PEM files of the
+	// client certificate and key to present to the broker.
+	TLSCertFile string `json:"tls_cert_file,omitempty"`
+	TLSKeyFile  string `json:"tls_key_file,omitempty"`
+
+	// Timeout is the maximum time to wait for connecting
+	// to the broker and for each probe message to be
+	// delivered. Default is 5 seconds.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// ThresholdRTT is the maximum time from publishing
+	// a probe message to receiving it to allow for a
+	// healthy endpoint.
+	ThresholdRTT time.Duration `json:"threshold_rtt,omitempty"`
+
+	// Attempts is how many probe messages to publish
+	// in a single check.
+	Attempts int `json:"attempts,omitempty"`
+
+	// Policy is how the status of the endpoint is
+	// concluded from the attempts.
+	types.Policy
+
+	// CheckerOptions are the options common to all checkers.
+	types.CheckerOptions
+}
+
+// New creates a new Checker instance based on json config
+func New(config json.RawMessage) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	return checker, err
+}
+
+// Type returns the checker package name
+func (Checker) Type() string {
+	return Type
+}
+
+// EndpointName returns the name of the endpoint
+func (c Checker) EndpointName() string {
+	return c.Name
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	if c.Attempts < 1 {
+		c.Attempts = 1
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 5 * time.Second
+	}
+	if c.ClientID == "" {
+		c.ClientID = "checkup-" + randomString()
+	}
+	if c.Topic == "" {
+		c.Topic = "checkup/" + c.ClientID
+	}
+
+	result := types.NewResult()
+	result.Title = c.Name
+	result.Endpoint = c.URL
+
+	if err := c.Policy.Validate(); err != nil {
+		return result, err
+	}
+
+	if c.QoS > 1 {
+		return result, fmt.Errorf("unsupported qos %d", c.QoS)
+	}
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return result, err
+	}
+	tlsConfig, err := c.tlsConfig()
+	if err != nil {
+		return result, err
+	}
+
+	result.Times = c.doChecks(u, tlsConfig)
+
+	return c.Policy.Conclude(result, c.ThresholdRTT), nil
+}
+
+// tlsConfig returns the TLS configuration to connect with.
+func (c Checker) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: c.TLSSkipVerify}
+	if c.TLSCAFile != "" {
+		rootPEM, err := ioutil.ReadFile(c.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read root certificate: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(rootPEM) {
+			return nil, fmt.Errorf("failed to parse root certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if c.TLSCertFile != "" || c.TLSKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.TLSCertFile, c.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// doChecks connects to the broker and executes and returns
+// each attempt. If the session cannot be set up, a single
+// failed attempt is returned.
+func (c Checker) doChecks(u *url.URL, tlsConfig *tls.Config) types.Attempts {
+	start := time.Now()
+	s, err := c.open(u, tlsConfig)
+	if err != nil {
+		return types.Attempts{{RTT: time.Since(start), Error: err.Error()}}
+	}
+	defer s.close()
+
+	token := randomString()
+	checks := make(types.Attempts, c.Attempts)
+	for i := 0; i < c.Attempts; i++ {
+		payload := []byte(fmt.Sprintf("checkup probe %s %d", token, i))
+		start := time.Now()
+		err := s.roundTrip(uint16(i+2), payload)
+		checks[i].RTT = time.Since(start)
+		if err != nil {
+			checks[i].Error = err.Error()
+		}
+	}
+	return checks
+}
+
+// session is a connection to a broker that is subscribed
+// to the probe topic.
+type session struct {
+	c    Checker
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// open connects to the broker at u and subscribes to the
+// probe topic.
+func (c Checker) open(u *url.URL, tlsConfig *tls.Config) (*session, error) {
+	conn, err := c.dial(u, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	s := &session{c: c, conn: conn, r: bufio.NewReader(conn)}
+	conn.SetDeadline(time.Now().Add(c.Timeout))
+
+	if err := writePacket(conn, connectPacket(c.ClientID, c.Username, c.Password, 60)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	p, err := readPacket(s.r)
+	if err == nil {
+		err = checkConnack(p)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if err := writePacket(conn, subscribePacket(1, c.Topic, c.QoS)); err != nil {
+		s.close()
+		return nil, err
+	}
+	for {
+		p, err := readPacket(s.r)
+		if err != nil {
+			s.close()
+			return nil, err
+		}
+		if p.kind() != packetSuback {
+			continue
+		}
+		if len(p.body) < 3 || p.body[2] == 0x80 {
+			s.close()
+			return nil, fmt.Errorf("subscription to %s refused", c.Topic)
+		}
+		return s, nil
+	}
+}
+
+// dial opens the transport connection for u.
+func (c Checker) dial(u *url.URL, tlsConfig *tls.Config) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: c.Timeout}
+	switch u.Scheme {
+	case "tcp", "mqtt":
+		return dialer.Dial("tcp", hostPort(u, "1883"))
+	case "ssl", "tls", "mqtts":
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = u.Hostname()
+		}
+		return tls.DialWithDialer(dialer, "tcp", hostPort(u, "8883"), tlsConfig)
+	case "ws", "wss":
+		origin := "http://" + u.Host
+		if u.Scheme == "wss" {
+			origin = "https://" + u.Host
+		}
+		config, err := websocket.NewConfig(u.String(), origin)
+		if err != nil {
+			return nil, err
+		}
+		config.Protocol = []string{"mqtt"}
+		config.TlsConfig = tlsConfig
+		config.Dialer = dialer
+		ws, err := websocket.DialConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		ws.PayloadType = websocket.BinaryFrame
+		return ws, nil
+	default:
+		return nil, fmt.Errorf("unsupported scheme '%s'", u.Scheme)
+	}
+}
+
+// roundTrip publishes payload to the probe topic and waits
+// until it is received back.
+func (s *session) roundTrip(id uint16, payload []byte) error {
+	s.conn.SetDeadline(time.Now().Add(s.c.Timeout))
+	if err := writePacket(s.conn, publishPacket(id, s.c.Topic, s.c.QoS, payload)); err != nil {
+		return err
+	}
+	for {
+		p, err := readPacket(s.r)
+		if err, ok := err.(net.Error); ok && err.Timeout() {
+			return fmt.Errorf("probe message not received within %s", s.c.Timeout)
+		}
+		if err != nil {
+			return err
+		}
+		if p.kind() != packetPublish {
+			continue
+		}
+		topic, pid, got, err := parsePublish(p)
+		if err != nil {
+			return err
+		}
+		if (p.header>>1)&0x03 > 0 {
+			if err := writePacket(s.conn, pubackPacket(pid)); err != nil {
+				return err
+			}
+		}
+		if topic == s.c.Topic && bytes.Equal(got, payload) {
+			return nil
+		}
+	}
+}
+
+// close disconnects from the broker.
+func (s *session) close() {
+	writePacket(s.conn, packet{header: packetDisconnect})
+	s.conn.Close()
+}
+
+// hostPort returns the host and port of u, with
+// defaultPort if u has none.
+func hostPort(u *url.URL, defaultPort string) string {
+	if u.Port() == "" {
+		return net.JoinHostPort(u.Hostname(), defaultPort)
+	}
+	return u.Host
+}
+
+// randomString returns a random hex string.
+func randomString() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,199 @@
+package mqtt
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// broker is a minimal in-process MQTT broker that routes
+// messages between its clients by exact topic match.
+type broker struct {
+	password string
+	drop     bool // don't deliver any messages
+
+	mu   sync.Mutex
+	subs map[string][]net.Conn
+}
+
+func newBroker(password string) *broker {
+	return &broker{password: password, subs: make(map[string][]net.Conn)}
+}
+
+func (b *broker) listen(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(conn)
+		}
+	}()
+	return l.Addr().String()
+}
+
+func (b *broker) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	p, err := readPacket(r)
+	if err != nil || p.kind() != packetConnect {
+		return
+	}
+	// skip protocol name, level, flags and keep alive
+	_, rest, _ := readString(p.body)
+	flags := rest[1]
+	_, rest, _ = readString(rest[4:]) // client id
+	var password string
+	if flags&0x80 != 0 {
+		_, rest, _ = readString(rest)
+	}
+	if flags&0x40 != 0 {
+		password, _, _ = readString(rest)
+	}
+	if password != b.password {
+		writePacket(conn, packet{header: packetConnack, body: []byte{0, 4}})
+		return
+	}
+	writePacket(conn, packet{header: packetConnack, body: []byte{0, 0}})
+
+	for {
+		p, err := readPacket(r)
+		if err != nil {
+			return
+		}
+		switch p.kind() {
+		case packetSubscribe:
+			topic, rest, _ := readString(p.body[2:])
+			b.mu.Lock()
+			b.subs[topic] = append(b.subs[topic], conn)
+			b.mu.Unlock()
+			writePacket(conn, packet{header: packetSuback, body: []byte{p.body[0], p.body[1], rest[0]}})
+		case packetPublish:
+			topic, id, payload, _ := parsePublish(p)
+			qos := (p.header >> 1) & 0x03
+			if qos > 0 {
+				writePacket(conn, pubackPacket(id))
+			}
+			if b.drop {
+				continue
+			}
+			b.mu.Lock()
+			for _, sub := range b.subs[topic] {
+				writePacket(sub, publishPacket(id, topic, qos, payload))
+			}
+			b.mu.Unlock()
+		case packetDisconnect:
+			return
+		}
+	}
+}
+
+func TestChecker(t *testing.T) {
+	b := newBroker("secret")
+	addr := b.listen(t)
+
+	for _, qos := range []byte{0, 1} {
+		c := Checker{Name: "Test", URL: "tcp://" + addr, Username: "probe", Password: "secret", QoS: qos, Attempts: 3}
+		result, err := c.Check()
+		if err != nil {
+			t.Fatalf("Didn't expect an error: %v", err)
+		}
+		if got, want := result.Endpoint, c.URL; got != want {
+			t.Errorf("Expected result.Endpoint='%s', got '%s'", want, got)
+		}
+		if !result.Healthy {
+			t.Errorf("qos %d: expected result.Healthy=true, got %+v", qos, result)
+		}
+		if got, want := len(result.Times), c.Attempts; got != want {
+			t.Errorf("Expected %d attempts, got %d", want, got)
+		}
+	}
+
+	// Bad credentials
+	c := Checker{Name: "Test", URL: "mqtt://" + addr, Password: "wrong"}
+	result, err := c.Check()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if !result.Down {
+		t.Errorf("Expected result.Down=true, got %+v", result)
+	}
+	if got, want := result.Times[0].Error, "connection refused: bad user name or password"; got != want {
+		t.Errorf("Expected error '%s', got '%s'", want, got)
+	}
+
+	// Slow delivery
+	c = Checker{Name: "Test", URL: "tcp://" + addr, Password: "secret", ThresholdRTT: time.Nanosecond}
+	if result, err = c.Check(); err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if !result.Degraded {
+		t.Errorf("Expected result.Degraded=true, got %+v", result)
+	}
+
+	// Configuration errors
+	for _, c := range []Checker{
+		{URL: "http://" + addr},
+		{URL: "tcp://" + addr, QoS: 2},
+	} {
+		result, err := c.Check()
+		if err == nil && !result.Down {
+			t.Errorf("%s: expected an error or result.Down=true", c.URL)
+		}
+	}
+}
+
+func TestCheckerTimeout(t *testing.T) {
+	b := newBroker("")
+	b.drop = true
+	addr := b.listen(t)
+
+	c := Checker{Name: "Test", URL: "tcp://" + addr, Timeout: 50 * time.Millisecond}
+	result, err := c.Check()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if !result.Down {
+		t.Errorf("Expected result.Down=true, got %+v", result)
+	}
+	if got := result.Times[0].Error; !strings.Contains(got, "not received within 50ms") {
+		t.Errorf("Expected a timeout error, got '%s'", got)
+	}
+}
+
+func TestCheckerWebSocket(t *testing.T) {
+	b := newBroker("")
+	srv := httptest.NewServer(websocket.Server{
+		Handshake: func(config *websocket.Config, r *http.Request) error {
+			config.Protocol = []string{"mqtt"}
+			return nil
+		},
+		Handler: func(ws *websocket.Conn) {
+			ws.PayloadType = websocket.BinaryFrame
+			b.serve(ws)
+		},
+	})
+	defer srv.Close()
+
+	c := Checker{Name: "Test", URL: "ws" + strings.TrimPrefix(srv.URL, "http") + "/mqtt", Attempts: 2}
+	result, err := c.Check()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if !result.Healthy {
+		t.Errorf("Expected result.Healthy=true, got %+v", result)
+	}
+}
@@ -0,0 +1,184 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MQTT 3.1.1 control packet types, shifted into the upper
+// four bits of the fixed header.
+const (
+	packetConnect     = 1 << 4
+	packetConnack     = 2 << 4
+	packetPublish     = 3 << 4
+	packetPuback      = 4 << 4
+	packetSubscribe   = 8 << 4
+	packetSuback      = 9 << 4
+	packetDisconnect  = 14 << 4
+	subscribeFlags    = 0x02
+	maxRemainingBytes = 4
+)
+
+// connackErrors describes the CONNACK return codes.
+var connackErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// packet is an MQTT control packet.
+type packet struct {
+	header byte
+	body   []byte
+}
+
+// kind returns the control packet type of p.
+func (p packet) kind() byte {
+	return p.header & 0xF0
+}
+
+// writePacket writes p to w.
+func writePacket(w io.Writer, p packet) error {
+	buf := []byte{p.header}
+	n := len(p.body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		buf = append(buf, b)
+		if n == 0 {
+			break
+		}
+	}
+	_, err := w.Write(append(buf, p.body...))
+	return err
+}
+
+// readPacket reads the next packet from r.
+func readPacket(r *bufio.Reader) (packet, error) {
+	var p packet
+	var err error
+	if p.header, err = r.ReadByte(); err != nil {
+		return p, err
+	}
+	var n, shift int
+	for i := 0; ; i++ {
+		if i == maxRemainingBytes {
+			return p, errors.New("malformed remaining length")
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return p, err
+		}
+		n |= int(b&0x7F) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	p.body = make([]byte, n)
+	_, err = io.ReadFull(r, p.body)
+	return p, err
+}
+
+// appendString appends s to b as a length-prefixed string.
+func appendString(b []byte, s string) []byte {
+	b = append(b, byte(len(s)>>8), byte(len(s)))
+	return append(b, s...)
+}
+
+// readString reads a length-prefixed string from the start
+// of b and returns it along with the rest of b.
+func readString(b []byte) (string, []byte, error) {
+	if len(b) < 2 {
+		return "", nil, errors.New("short packet")
+	}
+	n := int(binary.BigEndian.Uint16(b))
+	if len(b) < 2+n {
+		return "", nil, errors.New("short packet")
+	}
+	return string(b[2 : 2+n]), b[2+n:], nil
+}
+
+// connectPacket returns a CONNECT packet for a clean session.
+func connectPacket(clientID, username, password string, keepAlive uint16) packet {
+	flags := byte(0x02) // clean session
+	if username != "" {
+		flags |= 0x80
+	}
+	if password != "" {
+		flags |= 0x40
+	}
+	body := appendString(nil, "MQTT")
+	body = append(body, 4, flags, byte(keepAlive>>8), byte(keepAlive))
+	body = appendString(body, clientID)
+	if username != "" {
+		body = appendString(body, username)
+	}
+	if password != "" {
+		body = appendString(body, password)
+	}
+	return packet{header: packetConnect, body: body}
+}
+
+// checkConnack returns an error if p does not accept the
+// connection.
+func checkConnack(p packet) error {
+	if p.kind() != packetConnack || len(p.body) != 2 {
+		return fmt.Errorf("expected CONNACK, got packet type %d", p.kind()>>4)
+	}
+	if code := p.body[1]; code != 0 {
+		if msg, ok := connackErrors[code]; ok {
+			return fmt.Errorf("connection refused: %s", msg)
+		}
+		return fmt.Errorf("connection refused: code %d", code)
+	}
+	return nil
+}
+
+// subscribePacket returns a SUBSCRIBE packet for topic.
+func subscribePacket(id uint16, topic string, qos byte) packet {
+	body := []byte{byte(id >> 8), byte(id)}
+	body = appendString(body, topic)
+	body = append(body, qos)
+	return packet{header: packetSubscribe | subscribeFlags, body: body}
+}
+
+// publishPacket returns a PUBLISH packet. The packet
+// identifier is only included if qos is greater than 0.
+func publishPacket(id uint16, topic string, qos byte, payload []byte) packet {
+	body := appendString(nil, topic)
+	if qos > 0 {
+		body = append(body, byte(id>>8), byte(id))
+	}
+	return packet{header: packetPublish | qos<<1, body: append(body, payload...)}
+}
+
+// parsePublish returns the topic, packet identifier and
+// payload of a PUBLISH packet.
+func parsePublish(p packet) (topic string, id uint16, payload []byte, err error) {
+	topic, rest, err := readString(p.body)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	if (p.header>>1)&0x03 > 0 {
+		if len(rest) < 2 {
+			return "", 0, nil, errors.New("short packet")
+		}
+		id = binary.BigEndian.Uint16(rest)
+		rest = rest[2:]
+	}
+	return topic, id, rest, nil
+}
+
+// pubackPacket returns a PUBACK packet for id.
+func pubackPacket(id uint16) packet {
+	return packet{header: packetPuback, body: []byte{byte(id >> 8), byte(id)}}
+}
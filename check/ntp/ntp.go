@@ -0,0 +1,264 @@
+package ntp
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "ntp"
+
+// Checker implements a Checker for NTP servers. It
+// reports the server's stratum, the round trip delay and
+// the offset of the local clock relative to the server.
+type Checker struct {
+	// Name is the name of the endpoint.
+	Name string `json:"endpoint_name"`
+
+	// URL is the host or host:port of the NTP server.
+	// The port defaults to 123.
+	URL string `json:"endpoint_url"`
+
+	// Timeout is the maximum time to wait for a reply.
+	// Default is 1 second.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// ThresholdRTT is the maximum round trip delay to
+	// allow for a healthy endpoint. If non-zero and the
+	// delay is longer than ThresholdRTT, the endpoint
+	// will be considered unhealthy.
+	ThresholdRTT time.Duration `json:"threshold_rtt,omitempty"`
+
+	// OffsetThreshold is the largest offset of the local
+	// clock that is tolerated before declaring a degraded
+	// status. Default is 1 second.
+	OffsetThreshold time.Duration `json:"offset_threshold,omitempty"`
+
+	// OffsetDownThreshold is the largest offset of the
+	// local clock that is tolerated before declaring the
+	// endpoint down. If zero, the offset alone never
+	// makes the endpoint down.
+	OffsetDownThreshold time.Duration `json:"offset_down_threshold,omitempty"`
+
+	// Attempts is how many requests the client will
+	// make to the endpoint in a single check.
+	Attempts int `json:"attempts,omitempty"`
+
+	// Policy is how the status of the endpoint is
+	// concluded from the attempts.
+	types.Policy
+
+	// CheckerOptions are the options common to all checkers.
+	types.CheckerOptions
+}
+
+// New creates a new Checker instance based on json config
+func New(config json.RawMessage) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	return checker, err
+}
+
+// Type returns the checker package name
+func (Checker) Type() string {
+	return Type
+}
+
+// EndpointName returns the name of the endpoint
+func (c Checker) EndpointName() string {
+	return c.Name
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	if c.Attempts < 1 {
+		c.Attempts = 1
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 1 * time.Second
+	}
+	if c.OffsetThreshold == 0 {
+		c.OffsetThreshold = 1 * time.Second
+	}
+
+	result := types.NewResult()
+	result.Title = c.Name
+	result.Endpoint = c.URL
+
+	if err := c.Policy.Validate(); err != nil {
+		return result, err
+	}
+
+	addr := c.URL
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "123")
+	}
+
+	attempts, replies := c.doChecks(addr)
+	result.Times = attempts
+
+	return c.conclude(result, replies), nil
+}
+
+// reply holds the values of interest from an NTP reply.
+type reply struct {
+	leap    byte
+	stratum byte
+	offset  time.Duration
+	delay   time.Duration
+}
+
+// doChecks queries addr and returns each attempt along
+// with the reply to it, which is nil if the attempt failed.
+// The RTT of each attempt is the NTP round trip delay,
+// which excludes the server's processing time.
+func (c Checker) doChecks(addr string) (types.Attempts, []*reply) {
+	checks := make(types.Attempts, c.Attempts)
+	replies := make([]*reply, c.Attempts)
+	for i := 0; i < c.Attempts; i++ {
+		start := time.Now()
+		r, err := c.query(addr)
+		if err != nil {
+			checks[i].RTT = time.Since(start)
+			checks[i].Error = err.Error()
+			continue
+		}
+		checks[i].RTT = r.delay
+		replies[i] = r
+	}
+	return checks, replies
+}
+
+// query sends a single client request to addr.
+func (c Checker) query(addr string) (*reply, error) {
+	conn, err := net.DialTimeout("udp", addr, c.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(c.Timeout)); err != nil {
+		return nil, err
+	}
+
+	req := make([]byte, 48)
+	req[0] = 0x23 // LI = 0, VN = 4, Mode = 3 (client)
+	t1 := time.Now()
+	xmt := toNTPTime(t1)
+	binary.BigEndian.PutUint64(req[40:], xmt)
+	if _, err := conn.Write(req); err != nil {
+		return nil, err
+	}
+
+	resp := make([]byte, 48)
+	n, err := conn.Read(resp)
+	if err != nil {
+		return nil, err
+	}
+	t4 := time.Now()
+	if n < 48 {
+		return nil, fmt.Errorf("short reply (%d bytes)", n)
+	}
+	if mode := resp[0] & 0x07; mode != 4 {
+		return nil, fmt.Errorf("unexpected mode %d in reply", mode)
+	}
+	if binary.BigEndian.Uint64(resp[24:]) != xmt {
+		return nil, errors.New("reply does not match request")
+	}
+
+	t2 := fromNTPTime(binary.BigEndian.Uint64(resp[32:]))
+	t3 := fromNTPTime(binary.BigEndian.Uint64(resp[40:]))
+
+	delay := t4.Sub(t1) - t3.Sub(t2)
+	if delay < 0 {
+		delay = 0
+	}
+	return &reply{
+		leap:    resp[0] >> 6,
+		stratum: resp[1],
+		offset:  (t2.Sub(t1) + t3.Sub(t4)) / 2,
+		delay:   delay,
+	}, nil
+}
+
+// conclude takes the data in result from the attempts and
+// computes remaining values needed to fill out the result.
+// The reply with the lowest delay is the one used to judge
+// the server's synchronization and the local clock offset.
+func (c Checker) conclude(result types.Result, replies []*reply) types.Result {
+	result.ThresholdRTT = c.ThresholdRTT
+
+	// Check errors (down)
+	if c.Policy.Failed(result.Times) {
+		result.Down = true
+		return result
+	}
+
+	var best *reply
+	for _, r := range replies {
+		if r != nil && (best == nil || r.delay < best.delay) {
+			best = r
+		}
+	}
+	summary := fmt.Sprintf("stratum %d, offset %s, delay %s", best.stratum, best.offset, best.delay)
+
+	// Check server synchronization (down)
+	if best.leap == 3 || best.stratum == 0 || best.stratum >= 16 {
+		result.Notice = "server is unsynchronized; " + summary
+		result.Down = true
+		return result
+	}
+
+	// Check clock offset (down, degraded)
+	offset := best.offset
+	if offset < 0 {
+		offset = -offset
+	}
+	if c.OffsetDownThreshold > 0 && offset > c.OffsetDownThreshold {
+		result.Notice = fmt.Sprintf("clock offset exceeded down threshold (%s); %s", c.OffsetDownThreshold, summary)
+		result.Down = true
+		return result
+	}
+	if offset > c.OffsetThreshold {
+		result.Notice = fmt.Sprintf("clock offset exceeded threshold (%s); %s", c.OffsetThreshold, summary)
+		result.Degraded = true
+		return result
+	}
+
+	// Check round trip time (degraded)
+	if notice := c.Policy.Slow(result, c.ThresholdRTT); notice != "" {
+		result.Notice = notice + "; " + summary
+		result.Degraded = true
+		return result
+	}
+
+	result.Notice = summary
+	result.Healthy = true
+	return result
+}
+
+// ntpEpochOffset is the number of seconds between the NTP
+// epoch (1900) and the Unix epoch (1970).
+const ntpEpochOffset = 2208988800
+
+// toNTPTime converts t to the 64-bit NTP timestamp format.
+func toNTPTime(t time.Time) uint64 {
+	nsec := uint64(t.Sub(time.Unix(-ntpEpochOffset, 0)))
+	sec := nsec / 1e9
+	frac := (nsec - sec*1e9) << 32 / 1e9
+	return sec<<32 | frac
+}
+
+// fromNTPTime converts a 64-bit NTP timestamp to a time.Time.
+func fromNTPTime(ts uint64) time.Time {
+	sec := int64(ts >> 32)
+	nsec := int64((ts & 0xffffffff) * 1e9 >> 32)
+	return time.Unix(sec-ntpEpochOffset, nsec)
+}
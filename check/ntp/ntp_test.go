@@ -0,0 +1,163 @@
+package ntp
+
+import (
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeServer is an NTP server whose clock is off by offset
+// and which reports the given leap indicator and stratum.
+type fakeServer struct {
+	conn    net.PacketConn
+	offset  time.Duration
+	leap    byte
+	stratum byte
+}
+
+func newFakeServer(t *testing.T, offset time.Duration, leap, stratum byte) *fakeServer {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Couldn't start NTP test server with error: %v", err)
+	}
+	srv := &fakeServer{conn: conn, offset: offset, leap: leap, stratum: stratum}
+	go srv.serve()
+	return srv
+}
+
+func (s *fakeServer) serve() {
+	buf := make([]byte, 48)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if n < 48 {
+			continue
+		}
+		recv := time.Now().Add(s.offset)
+		resp := make([]byte, 48)
+		resp[0] = s.leap<<6 | 4<<3 | 4 // VN = 4, Mode = 4 (server)
+		resp[1] = s.stratum
+		copy(resp[24:32], buf[40:48]) // origin = client transmit
+		binary.BigEndian.PutUint64(resp[32:], toNTPTime(recv))
+		binary.BigEndian.PutUint64(resp[40:], toNTPTime(time.Now().Add(s.offset)))
+		s.conn.WriteTo(resp, addr)
+	}
+}
+
+func (s *fakeServer) Close() { s.conn.Close() }
+
+func TestChecker(t *testing.T) {
+	srv := newFakeServer(t, 0, 0, 2)
+	defer srv.Close()
+
+	endpt := srv.conn.LocalAddr().String()
+	testName := "TestNTP"
+	hc := Checker{Name: testName, URL: endpt, Attempts: 2}
+
+	result, err := hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Title, testName; got != want {
+		t.Errorf("Expected result.Title='%s', got '%s'", want, got)
+	}
+	if got, want := result.Endpoint, endpt; got != want {
+		t.Errorf("Expected result.Endpoint='%s', got '%s'", want, got)
+	}
+	if got, want := result.Healthy, true; got != want {
+		t.Errorf("Expected result.Healthy=%v, got %v (%s)", want, got, result.Notice)
+	}
+	if got, want := len(result.Times), hc.Attempts; got != want {
+		t.Errorf("Expected %d attempts, got %d", want, got)
+	}
+	if !strings.HasPrefix(result.Notice, "stratum 2, offset ") {
+		t.Errorf("Expected notice to report stratum and offset, got '%s'", result.Notice)
+	}
+
+	hc.ThresholdRTT = 1 * time.Nanosecond
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Degraded, true; got != want {
+		t.Errorf("Expected result.Degraded=%v, got %v", want, got)
+	}
+
+	// Try when the server is not even online
+	srv.Close()
+	hc.Timeout = 50 * time.Millisecond
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Down, true; got != want {
+		t.Errorf("Expected result.Down=%v, got %v", want, got)
+	}
+}
+
+func TestCheckerOffset(t *testing.T) {
+	srv := newFakeServer(t, -5*time.Second, 0, 1)
+	defer srv.Close()
+
+	hc := Checker{Name: "Skewed", URL: srv.conn.LocalAddr().String()}
+	result, err := hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Degraded, true; got != want {
+		t.Errorf("Expected result.Degraded=%v, got %v (%s)", want, got, result.Notice)
+	}
+
+	hc.OffsetThreshold = 10 * time.Second
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Healthy, true; got != want {
+		t.Errorf("Expected result.Healthy=%v, got %v (%s)", want, got, result.Notice)
+	}
+
+	hc.OffsetDownThreshold = 2 * time.Second
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Down, true; got != want {
+		t.Errorf("Expected result.Down=%v, got %v (%s)", want, got, result.Notice)
+	}
+}
+
+func TestCheckerUnsynchronized(t *testing.T) {
+	for _, test := range []struct {
+		leap, stratum byte
+	}{
+		{0, 16}, // unsynchronized stratum
+		{3, 2},  // alarm condition
+		{0, 0},  // kiss-o'-death
+	} {
+		srv := newFakeServer(t, 0, test.leap, test.stratum)
+		hc := Checker{Name: "Unsynchronized", URL: srv.conn.LocalAddr().String()}
+		result, err := hc.Check()
+		srv.Close()
+		if err != nil {
+			t.Errorf("Didn't expect an error: %v", err)
+		}
+		if got, want := result.Down, true; got != want {
+			t.Errorf("[leap %d, stratum %d] Expected result.Down=%v, got %v", test.leap, test.stratum, want, got)
+		}
+		if !strings.HasPrefix(result.Notice, "server is unsynchronized") {
+			t.Errorf("[leap %d, stratum %d] Unexpected notice '%s'", test.leap, test.stratum, result.Notice)
+		}
+	}
+}
+
+func TestNTPTime(t *testing.T) {
+	now := time.Unix(1600000000, 123456789)
+	if got := fromNTPTime(toNTPTime(now)); got.Sub(now) > time.Microsecond || now.Sub(got) > time.Microsecond {
+		t.Errorf("Expected NTP time round trip to yield %s, got %s", now, got)
+	}
+}
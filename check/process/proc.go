@@ -0,0 +1,146 @@
+package process
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// proc holds the information about a process that is
+// relevant to the check.
+type proc struct {
+	pid       int
+	name      string
+	cmdline   []string
+	rss       int64
+	openFiles int
+}
+
+// find returns the processes that match c. The RSS and
+// open files of the matches are only read if needed.
+func (c Checker) find(pattern *regexp.Regexp) ([]proc, error) {
+	pids, err := c.candidates()
+	if err != nil {
+		return nil, err
+	}
+
+	var procs []proc
+	for _, pid := range pids {
+		p, err := c.readProc(pid)
+		if err != nil {
+			// the process exited while we were looking at it
+			continue
+		}
+		if c.ProcessName != "" && p.name != c.ProcessName &&
+			(len(p.cmdline) == 0 || filepath.Base(p.cmdline[0]) != c.ProcessName) {
+			continue
+		}
+		if pattern != nil && !pattern.MatchString(strings.Join(p.cmdline, " ")) {
+			continue
+		}
+		if c.MaxRSS > 0 {
+			if p.rss, err = c.readRSS(pid); os.IsNotExist(err) {
+				// the process exited while we were looking at it
+				continue
+			} else if err != nil {
+				return nil, fmt.Errorf("reading RSS of pid %d: %v", pid, err)
+			}
+		}
+		if c.MaxOpenFiles > 0 {
+			fds, err := ioutil.ReadDir(filepath.Join(c.ProcDir, strconv.Itoa(pid), "fd"))
+			if os.IsNotExist(err) {
+				// the process exited while we were looking at it
+				continue
+			} else if err != nil {
+				return nil, fmt.Errorf("reading fds of pid %d: %v", pid, err)
+			}
+			p.openFiles = len(fds)
+		}
+		procs = append(procs, p)
+	}
+	return procs, nil
+}
+
+// candidates returns the PIDs of the processes that are
+// considered: the one in c.PIDFile, or else all of them.
+func (c Checker) candidates() ([]int, error) {
+	if c.PIDFile != "" {
+		b, err := ioutil.ReadFile(c.PIDFile)
+		if err != nil {
+			return nil, err
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(b)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid pid file %s: %v", c.PIDFile, err)
+		}
+		if _, err := os.Stat(filepath.Join(c.ProcDir, strconv.Itoa(pid))); err != nil {
+			return nil, nil
+		}
+		return []int{pid}, nil
+	}
+
+	entries, err := ioutil.ReadDir(c.ProcDir)
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	for _, entry := range entries {
+		if pid, err := strconv.Atoi(entry.Name()); err == nil && entry.IsDir() {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
+
+// readProc reads the name and command line of pid.
+func (c Checker) readProc(pid int) (proc, error) {
+	p := proc{pid: pid}
+	dir := filepath.Join(c.ProcDir, strconv.Itoa(pid))
+
+	comm, err := ioutil.ReadFile(filepath.Join(dir, "comm"))
+	if err != nil {
+		return p, err
+	}
+	p.name = strings.TrimSpace(string(comm))
+
+	cmdline, err := ioutil.ReadFile(filepath.Join(dir, "cmdline"))
+	if err != nil {
+		return p, err
+	}
+	for _, arg := range bytes.Split(bytes.TrimRight(cmdline, "\x00"), []byte{0}) {
+		if len(arg) > 0 {
+			p.cmdline = append(p.cmdline, string(arg))
+		}
+	}
+	return p, nil
+}
+
+// readRSS returns the resident set size of pid in bytes.
+func (c Checker) readRSS(pid int) (int64, error) {
+	f, err := os.Open(filepath.Join(c.ProcDir, strconv.Itoa(pid), "status"))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "VmRSS:" {
+			continue
+		}
+		kb, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return kb * 1024, nil
+	}
+	// kernel threads have no VmRSS
+	return 0, scanner.Err()
+}
@@ -0,0 +1,234 @@
+package process
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "process"
+
+// Checker implements a Checker for local processes. It
+// either matches processes in the proc filesystem and
+// asserts on their number and resource usage, or, if Unit
+// is set, queries systemd for the state of a unit.
+type Checker struct {
+	// Name is the name of the endpoint.
+	Name string `json:"endpoint_name"`
+
+	// ProcessName matches processes by their command name
+	// (as in /proc/<pid>/comm) or the base name of their
+	// executable.
+	ProcessName string `json:"process_name,omitempty"`
+
+	// CmdlinePattern matches processes whose command line,
+	// with arguments separated by spaces, matches this
+	// regular expression.
+	CmdlinePattern string `json:"cmdline_pattern,omitempty"`
+
+	// PIDFile matches the process whose PID is written
+	// in this file. If several of ProcessName,
+	// CmdlinePattern and PIDFile are set, a process must
+	// match all of them.
+	PIDFile string `json:"pid_file,omitempty"`
+
+	// MinCount is the minimum number of matching
+	// processes. Default is 1.
+	MinCount int `json:"min_count,omitempty"`
+
+	// MaxCount is the maximum number of matching
+	// processes. If zero, there is no maximum.
+	MaxCount int `json:"max_count,omitempty"`
+
+	// MaxRSS is the maximum resident set size, in
+	// bytes, of any matching process. If zero, memory
+	// usage is not checked.
+	MaxRSS int64 `json:"max_rss,omitempty"`
+
+	// MaxOpenFiles is the maximum number of open file
+	// descriptors of any matching process. If zero,
+	// open files are not checked.
+	MaxOpenFiles int `json:"max_open_files,omitempty"`
+
+	// Raise is a string that tells us if we should mark
+	// the endpoint as down when the RSS or open files
+	// limits are exceeded ("error" - the default), or if
+	// we should just mark it as degraded ("warn" or
+	// "warning"). Instance counts outside of the limits
+	// always mark the endpoint as down.
+	Raise string `json:"raise,omitempty"`
+
+	// ProcDir is where the proc filesystem is mounted.
+	// Default is "/proc".
+	ProcDir string `json:"proc_dir,omitempty"`
+
+	// Unit is the name of a systemd unit, such as
+	// "nginx.service". If set, its ActiveState is queried
+	// over D-Bus instead of matching processes: "active"
+	// is healthy, "failed" and "inactive" are down and
+	// transitional states are degraded.
+	Unit string `json:"systemd_unit,omitempty"`
+
+	// DBusAddress is the address of the D-Bus to query
+	// for Unit. Default is the system bus.
+	DBusAddress string `json:"dbus_address,omitempty"`
+
+	// CheckerOptions are the options common to all checkers.
+	types.CheckerOptions
+}
+
+// New creates a new Checker instance based on json config
+func New(config json.RawMessage) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	return checker, err
+}
+
+// Type returns the checker package name
+func (Checker) Type() string {
+	return Type
+}
+
+// EndpointName returns the name of the endpoint
+func (c Checker) EndpointName() string {
+	return c.Name
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	if c.ProcDir == "" {
+		c.ProcDir = "/proc"
+	}
+	if c.MinCount == 0 {
+		c.MinCount = 1
+	}
+
+	result := types.NewResult()
+	result.Title = c.Name
+
+	if c.Unit != "" {
+		result.Endpoint = c.Unit
+		start := time.Now()
+		state, err := c.unitState()
+		result.Times = types.Attempts{{RTT: time.Since(start)}}
+		if err != nil {
+			result.Times[0].Error = err.Error()
+		}
+		return c.concludeUnit(result, state), nil
+	}
+
+	result.Endpoint = c.describe()
+	if result.Endpoint == "" {
+		return result, fmt.Errorf("no process_name, cmdline_pattern, pid_file or systemd_unit configured")
+	}
+	var pattern *regexp.Regexp
+	if c.CmdlinePattern != "" {
+		var err error
+		pattern, err = regexp.Compile(c.CmdlinePattern)
+		if err != nil {
+			return result, fmt.Errorf("invalid cmdline_pattern: %v", err)
+		}
+	}
+
+	start := time.Now()
+	procs, err := c.find(pattern)
+	result.Times = types.Attempts{{RTT: time.Since(start)}}
+	if err != nil {
+		result.Times[0].Error = err.Error()
+	}
+
+	return c.conclude(result, procs), nil
+}
+
+// describe returns a description of the processes matched
+// by c, to be used as the endpoint of the result.
+func (c Checker) describe() string {
+	var parts []string
+	if c.ProcessName != "" {
+		parts = append(parts, "name="+c.ProcessName)
+	}
+	if c.CmdlinePattern != "" {
+		parts = append(parts, "cmdline=~"+c.CmdlinePattern)
+	}
+	if c.PIDFile != "" {
+		parts = append(parts, "pidfile="+c.PIDFile)
+	}
+	return strings.Join(parts, " ")
+}
+
+// conclude takes the matching processes and makes the
+// conclusion about the result's status.
+func (c Checker) conclude(result types.Result, procs []proc) types.Result {
+	// Check errors (down)
+	for i := range result.Times {
+		if result.Times[i].Error != "" {
+			result.Down = true
+			return result
+		}
+	}
+
+	// Check instance count (down)
+	if n := len(procs); n < c.MinCount || (c.MaxCount > 0 && n > c.MaxCount) {
+		want := fmt.Sprintf("at least %d", c.MinCount)
+		if c.MaxCount > 0 {
+			want = fmt.Sprintf("%d to %d", c.MinCount, c.MaxCount)
+		}
+		result.Notice = fmt.Sprintf("%d matching processes, want %s", n, want)
+		result.Down = true
+		return result
+	}
+
+	// Check resource limits (down, degraded)
+	var violations []string
+	for _, p := range procs {
+		if c.MaxRSS > 0 && p.rss > c.MaxRSS {
+			violations = append(violations, fmt.Sprintf("pid %d (%s): RSS %d bytes, want at most %d",
+				p.pid, p.name, p.rss, c.MaxRSS))
+		}
+		if c.MaxOpenFiles > 0 && p.openFiles > c.MaxOpenFiles {
+			violations = append(violations, fmt.Sprintf("pid %d (%s): %d open files, want at most %d",
+				p.pid, p.name, p.openFiles, c.MaxOpenFiles))
+		}
+	}
+	if len(violations) > 0 {
+		result.Notice = strings.Join(violations, "; ")
+		if c.Raise == "warn" || c.Raise == "warning" {
+			result.Degraded = true
+		} else {
+			result.Down = true
+		}
+		return result
+	}
+
+	result.Healthy = true
+	return result
+}
+
+// concludeUnit takes the ActiveState of the systemd unit
+// and makes the conclusion about the result's status.
+func (c Checker) concludeUnit(result types.Result, state string) types.Result {
+	// Check errors (down)
+	for i := range result.Times {
+		if result.Times[i].Error != "" {
+			result.Down = true
+			return result
+		}
+	}
+
+	result.Notice = "unit is " + state
+	switch state {
+	case "active":
+		result.Healthy = true
+	case "activating", "deactivating", "reloading":
+		result.Degraded = true
+	default:
+		result.Down = true
+	}
+	return result
+}
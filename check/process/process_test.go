@@ -0,0 +1,273 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeProc creates a proc filesystem in dir holding a
+// process with the given properties.
+func fakeProc(t *testing.T, dir string, pid int, comm, cmdline string, rssKB, fds int) {
+	pidDir := filepath.Join(dir, strconv.Itoa(pid))
+	if err := os.MkdirAll(filepath.Join(pidDir, "fd"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"comm":    comm + "\n",
+		"cmdline": strings.Replace(cmdline, " ", "\x00", -1) + "\x00",
+		"status":  fmt.Sprintf("Name:\t%s\nVmRSS:\t%d kB\nThreads:\t1\n", comm, rssKB),
+	}
+	for name, contents := range files {
+		if err := ioutil.WriteFile(filepath.Join(pidDir, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < fds; i++ {
+		if err := ioutil.WriteFile(filepath.Join(pidDir, "fd", strconv.Itoa(i)), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestChecker(t *testing.T) {
+	procDir, err := ioutil.TempDir("", "checkup")
+	if err != nil {
+		t.Fatalf("Cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(procDir)
+
+	fakeProc(t, procDir, 100, "nginx", "nginx: master process /usr/sbin/nginx", 2048, 10)
+	fakeProc(t, procDir, 101, "nginx", "nginx: worker process", 8192, 40)
+	fakeProc(t, procDir, 200, "python3", "/usr/bin/python3 /opt/app/worker.py --queue jobs", 102400, 5)
+
+	testName := "TestProcess"
+	hc := Checker{Name: testName, ProcDir: procDir, ProcessName: "nginx", MinCount: 2, MaxCount: 4}
+
+	result, err := hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Title, testName; got != want {
+		t.Errorf("Expected result.Title='%s', got '%s'", want, got)
+	}
+	if got, want := result.Endpoint, "name=nginx"; got != want {
+		t.Errorf("Expected result.Endpoint='%s', got '%s'", want, got)
+	}
+	if got, want := result.Healthy, true; got != want {
+		t.Errorf("Expected result.Healthy=%v, got %v (%s %v)", want, got, result.Notice, result.Times)
+	}
+
+	// Resource limits
+	hc.MaxRSS = 4 * 1024 * 1024
+	hc.MaxOpenFiles = 20
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Down, true; got != want {
+		t.Errorf("Expected result.Down=%v, got %v", want, got)
+	}
+	want := "pid 101 (nginx): RSS 8388608 bytes, want at most 4194304; pid 101 (nginx): 40 open files, want at most 20"
+	if result.Notice != want {
+		t.Errorf("Expected notice '%s', got '%s'", want, result.Notice)
+	}
+
+	hc.Raise = "warn"
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Degraded, true; got != want {
+		t.Errorf("Expected result.Degraded=%v, got %v", want, got)
+	}
+
+	// Instance counts
+	hc = Checker{Name: testName, ProcDir: procDir, CmdlinePattern: `worker\.py .*--queue jobs`}
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Healthy, true; got != want {
+		t.Errorf("Expected result.Healthy=%v, got %v (%s)", want, got, result.Notice)
+	}
+
+	hc.ProcessName = "python3"
+	hc.CmdlinePattern = "--queue mail"
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Notice, "0 matching processes, want at least 1"; got != want {
+		t.Errorf("Expected notice '%s', got '%s'", want, got)
+	}
+
+	hc = Checker{Name: testName, ProcDir: procDir, ProcessName: "nginx", MaxCount: 1}
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Notice, "2 matching processes, want 1 to 1"; got != want {
+		t.Errorf("Expected notice '%s', got '%s'", want, got)
+	}
+
+	// Configuration errors
+	hc = Checker{Name: testName, ProcDir: procDir}
+	if _, err = hc.Check(); err == nil {
+		t.Error("Expected an error with no matcher, didn't get one")
+	}
+	hc.CmdlinePattern = "("
+	if _, err = hc.Check(); err == nil {
+		t.Error("Expected an error for an invalid pattern, didn't get one")
+	}
+
+	// Processes that exit while they are inspected are skipped
+	fakeProc(t, procDir, 102, "nginx", "nginx: worker process", 4096, 1)
+	if err := os.RemoveAll(filepath.Join(procDir, "102", "fd")); err != nil {
+		t.Fatal(err)
+	}
+	hc = Checker{Name: testName, ProcDir: procDir, ProcessName: "nginx", MaxOpenFiles: 100}
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Healthy, true; got != want {
+		t.Errorf("Expected result.Healthy=%v, got %v (%s)", want, got, result.Notice)
+	}
+
+	// Processes that can't be inspected make the attempt fail
+	fakeProc(t, procDir, 103, "nginx", "nginx: worker process", 4096, 0)
+	fdDir := filepath.Join(procDir, "103", "fd")
+	if err := os.Remove(fdDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(fdDir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if !result.Down || len(result.Times) != 1 || !strings.HasPrefix(result.Times[0].Error, "reading fds of pid 103: ") {
+		t.Errorf("Expected result to be down with the error reading fds, got %+v", result)
+	}
+}
+
+func TestCheckerPIDFile(t *testing.T) {
+	procDir, err := ioutil.TempDir("", "checkup")
+	if err != nil {
+		t.Fatalf("Cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(procDir)
+
+	fakeProc(t, procDir, 300, "redis-server", "/usr/bin/redis-server *:6379", 1024, 3)
+	pidFile := filepath.Join(procDir, "redis.pid")
+
+	hc := Checker{Name: "Redis", ProcDir: procDir, PIDFile: pidFile}
+	for _, test := range []struct {
+		contents string
+		healthy  bool
+	}{
+		{"300\n", true},
+		{"301\n", false}, // stale pid file
+		{"", false},      // garbage
+	} {
+		if err := ioutil.WriteFile(pidFile, []byte(test.contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+		result, err := hc.Check()
+		if err != nil {
+			t.Errorf("Didn't expect an error: %v", err)
+		}
+		if got, want := result.Healthy, test.healthy; got != want {
+			t.Errorf("[%q] Expected result.Healthy=%v, got %v", test.contents, want, got)
+		}
+	}
+
+	// Against the real proc filesystem
+	if _, err := os.Stat("/proc/self"); err != nil {
+		t.Skip("no proc filesystem")
+	}
+	if err := ioutil.WriteFile(pidFile, []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hc = Checker{Name: "Self", PIDFile: pidFile, MaxRSS: 1 << 40, MaxOpenFiles: 1 << 20}
+	result, err := hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Healthy, true; got != want {
+		t.Errorf("Expected result.Healthy=%v, got %v (%s %v)", want, got, result.Notice, result.Times)
+	}
+}
+
+func TestCheckerSystemd(t *testing.T) {
+	fake := &systemdMock{states: map[string]string{
+		"nginx.service":  "active",
+		"backup.service": "failed",
+		"app.service":    "activating",
+	}}
+	newSystemd = func(address string) (systemd, error) {
+		if address == "unix:path=/nonexistent" {
+			return nil, errors.New("no such file or directory")
+		}
+		return fake, nil
+	}
+
+	for _, test := range []struct {
+		unit   string
+		status string
+	}{
+		{"nginx.service", "healthy"},
+		{"app.service", "degraded"},
+		{"backup.service", "down"},
+		{"missing.service", "down"},
+	} {
+		hc := Checker{Name: test.unit, Unit: test.unit}
+		result, err := hc.Check()
+		if err != nil {
+			t.Errorf("Didn't expect an error: %v", err)
+		}
+		if got, want := result.Endpoint, test.unit; got != want {
+			t.Errorf("Expected result.Endpoint='%s', got '%s'", want, got)
+		}
+		if got, want := string(result.Status()), test.status; got != want {
+			t.Errorf("[%s] Expected status '%s', got '%s' (%s)", test.unit, want, got, result.Notice)
+		}
+	}
+	if !fake.closed {
+		t.Error("Expected the D-Bus connection to be closed")
+	}
+
+	hc := Checker{Name: "Unreachable", Unit: "nginx.service", DBusAddress: "unix:path=/nonexistent"}
+	result, err := hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Down, true; got != want {
+		t.Errorf("Expected result.Down=%v, got %v", want, got)
+	}
+}
+
+// systemdMock mocks the systemd D-Bus API.
+type systemdMock struct {
+	states map[string]string
+	closed bool
+}
+
+func (s *systemdMock) ActiveState(unit string) (string, error) {
+	if state, ok := s.states[unit]; ok {
+		return state, nil
+	}
+	return "inactive", nil
+}
+
+func (s *systemdMock) Close() error {
+	s.closed = true
+	return nil
+}
@@ -0,0 +1,79 @@
+package process
+
+import (
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// unitState queries systemd for the ActiveState of c.Unit.
+func (c Checker) unitState() (string, error) {
+	sd, err := newSystemd(c.DBusAddress)
+	if err != nil {
+		return "", fmt.Errorf("connecting to systemd: %v", err)
+	}
+	defer sd.Close()
+	return sd.ActiveState(c.Unit)
+}
+
+// systemd is used for mocking the systemd D-Bus API.
+type systemd interface {
+	ActiveState(unit string) (string, error)
+	Close() error
+}
+
+// newSystemd connects to systemd over the D-Bus at address,
+// or the system bus if address is empty. It may be replaced
+// for mocking in tests.
+var newSystemd = func(address string) (systemd, error) {
+	var conn *dbus.Conn
+	var err error
+	if address == "" {
+		conn, err = dbus.SystemBusPrivate()
+	} else {
+		conn, err = dbus.Dial(address)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = conn.Auth(nil); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err = conn.Hello(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return dbusSystemd{conn}, nil
+}
+
+// dbusSystemd implements systemd using a D-Bus connection.
+type dbusSystemd struct {
+	conn *dbus.Conn
+}
+
+// ActiveState returns the ActiveState property of unit. Units
+// that do not exist are reported as "inactive" by systemd.
+func (sd dbusSystemd) ActiveState(unit string) (string, error) {
+	var path dbus.ObjectPath
+	manager := sd.conn.Object("org.freedesktop.systemd1", "/org/freedesktop/systemd1")
+	err := manager.Call("org.freedesktop.systemd1.Manager.LoadUnit", 0, unit).Store(&path)
+	if err != nil {
+		return "", err
+	}
+
+	v, err := sd.conn.Object("org.freedesktop.systemd1", path).GetProperty("org.freedesktop.systemd1.Unit.ActiveState")
+	if err != nil {
+		return "", err
+	}
+	state, ok := v.Value().(string)
+	if !ok {
+		return "", fmt.Errorf("unexpected ActiveState value %v", v)
+	}
+	return state, nil
+}
+
+// Close closes the D-Bus connection.
+func (sd dbusSystemd) Close() error {
+	return sd.conn.Close()
+}
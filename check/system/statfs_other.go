@@ -0,0 +1,12 @@
+// +build windows plan9
+
+package system
+
+import (
+	"errors"
+)
+
+// statfs is not supported on this platform.
+func statfs(path string) (diskUsage, error) {
+	return diskUsage{}, errors.New("disk checks are not supported on this platform")
+}
@@ -0,0 +1,21 @@
+// +build !windows,!plan9
+
+package system
+
+import (
+	"syscall"
+)
+
+// statfs returns the usage of the filesystem containing path.
+func statfs(path string) (diskUsage, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return diskUsage{}, err
+	}
+	return diskUsage{
+		blocks:      uint64(st.Blocks),
+		blocksAvail: uint64(st.Bavail),
+		inodes:      uint64(st.Files),
+		inodesFree:  uint64(st.Ffree),
+	}, nil
+}
@@ -0,0 +1,263 @@
+package system
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "system"
+
+// Checker implements a Checker for the resources of the
+// local system: disk space and inodes per mount, available
+// memory and load average.
+type Checker struct {
+	// Name is the name of the endpoint.
+	Name string `json:"endpoint_name"`
+
+	// Disks are the mounts to check.
+	Disks []Disk `json:"disks,omitempty"`
+
+	// MinMemAvailablePercent is the minimum percentage of
+	// memory that must be available. If zero, memory is
+	// not checked.
+	MinMemAvailablePercent float64 `json:"min_mem_available_percent,omitempty"`
+
+	// MaxLoad is the maximum 1-minute load average. If
+	// zero, the load average is not checked.
+	MaxLoad float64 `json:"max_load,omitempty"`
+
+	// LoadPerCPU divides the load average by the number
+	// of CPUs before comparing it against MaxLoad.
+	LoadPerCPU bool `json:"load_per_cpu,omitempty"`
+
+	// Raise is a string that tells us if we should mark
+	// the endpoint as down when a limit is exceeded
+	// ("error" - the default), or if we should just mark
+	// it as degraded ("warn" or "warning").
+	Raise string `json:"raise,omitempty"`
+
+	// ProcDir is where the proc filesystem is mounted.
+	// Default is "/proc".
+	ProcDir string `json:"proc_dir,omitempty"`
+
+	// CheckerOptions are the options common to all checkers.
+	types.CheckerOptions
+}
+
+// Disk holds the limits for a mounted filesystem.
+type Disk struct {
+	// Path is any path on the filesystem, usually the
+	// mount point.
+	Path string `json:"path"`
+
+	// MinFreePercent is the minimum percentage of space
+	// that must be available to unprivileged users.
+	MinFreePercent float64 `json:"min_free_percent,omitempty"`
+
+	// MaxInodesUsedPercent is the maximum percentage of
+	// inodes that may be in use. If zero, inodes are not
+	// checked.
+	MaxInodesUsedPercent float64 `json:"max_inodes_used_percent,omitempty"`
+}
+
+// New creates a new Checker instance based on json config
+func New(config json.RawMessage) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	return checker, err
+}
+
+// Type returns the checker package name
+func (Checker) Type() string {
+	return Type
+}
+
+// EndpointName returns the name of the endpoint
+func (c Checker) EndpointName() string {
+	return c.Name
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	if c.ProcDir == "" {
+		c.ProcDir = "/proc"
+	}
+
+	result := types.NewResult()
+	result.Title = c.Name
+	result.Endpoint, _ = os.Hostname()
+
+	start := time.Now()
+	violations, err := c.inspect()
+	result.Times = types.Attempts{{RTT: time.Since(start)}}
+	if err != nil {
+		result.Times[0].Error = err.Error()
+	}
+
+	return c.conclude(result, violations), nil
+}
+
+// inspect reads the current state of the system and returns
+// a description of each limit that is exceeded.
+func (c Checker) inspect() ([]string, error) {
+	var violations []string
+
+	for _, disk := range c.Disks {
+		usage, err := statfs(disk.Path)
+		if err != nil {
+			return nil, fmt.Errorf("statfs %s: %v", disk.Path, err)
+		}
+		if free := usage.freePercent(); free < disk.MinFreePercent {
+			violations = append(violations, fmt.Sprintf("%s: %.1f%% disk space free, want at least %.1f%%",
+				disk.Path, free, disk.MinFreePercent))
+		}
+		if disk.MaxInodesUsedPercent > 0 {
+			if used := usage.inodesUsedPercent(); used > disk.MaxInodesUsedPercent {
+				violations = append(violations, fmt.Sprintf("%s: %.1f%% inodes used, want at most %.1f%%",
+					disk.Path, used, disk.MaxInodesUsedPercent))
+			}
+		}
+	}
+
+	if c.MinMemAvailablePercent > 0 {
+		avail, err := c.memAvailablePercent()
+		if err != nil {
+			return nil, err
+		}
+		if avail < c.MinMemAvailablePercent {
+			violations = append(violations, fmt.Sprintf("%.1f%% memory available, want at least %.1f%%",
+				avail, c.MinMemAvailablePercent))
+		}
+	}
+
+	if c.MaxLoad > 0 {
+		load, err := c.loadAverage()
+		if err != nil {
+			return nil, err
+		}
+		desc := "load average"
+		if c.LoadPerCPU {
+			load /= float64(runtime.NumCPU())
+			desc = "load average per CPU"
+		}
+		if load > c.MaxLoad {
+			violations = append(violations, fmt.Sprintf("%s %.2f, want at most %.2f", desc, load, c.MaxLoad))
+		}
+	}
+
+	return violations, nil
+}
+
+// memAvailablePercent returns the percentage of memory
+// available according to /proc/meminfo.
+func (c Checker) memAvailablePercent() (float64, error) {
+	f, err := os.Open(filepath.Join(c.ProcDir, "meminfo"))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	values := make(map[string]float64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		v, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			continue
+		}
+		values[strings.TrimSuffix(fields[0], ":")] = v
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+
+	total, ok := values["MemTotal"]
+	if !ok || total == 0 {
+		return 0, fmt.Errorf("no MemTotal in meminfo")
+	}
+	avail, ok := values["MemAvailable"]
+	if !ok {
+		// kernels before 3.14 lack MemAvailable
+		avail = values["MemFree"] + values["Buffers"] + values["Cached"]
+	}
+	return 100 * avail / total, nil
+}
+
+// loadAverage returns the 1-minute load average according
+// to /proc/loadavg.
+func (c Checker) loadAverage() (float64, error) {
+	b, err := ioutil.ReadFile(filepath.Join(c.ProcDir, "loadavg"))
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(b))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("empty loadavg")
+	}
+	return strconv.ParseFloat(fields[0], 64)
+}
+
+// conclude takes the violations found and makes the
+// conclusion about the result's status.
+func (c Checker) conclude(result types.Result, violations []string) types.Result {
+	// Check errors (down)
+	for i := range result.Times {
+		if result.Times[i].Error != "" {
+			result.Down = true
+			return result
+		}
+	}
+
+	// Check limits (down, degraded)
+	if len(violations) > 0 {
+		result.Notice = strings.Join(violations, "; ")
+		if c.Raise == "warn" || c.Raise == "warning" {
+			result.Degraded = true
+		} else {
+			result.Down = true
+		}
+		return result
+	}
+
+	result.Healthy = true
+	return result
+}
+
+// diskUsage holds the capacity and usage of a filesystem.
+type diskUsage struct {
+	blocks, blocksAvail uint64
+	inodes, inodesFree  uint64
+}
+
+// freePercent returns the percentage of space available
+// to unprivileged users.
+func (u diskUsage) freePercent() float64 {
+	if u.blocks == 0 {
+		return 0
+	}
+	return 100 * float64(u.blocksAvail) / float64(u.blocks)
+}
+
+// inodesUsedPercent returns the percentage of inodes in use.
+// Filesystems without a fixed number of inodes report 0.
+func (u diskUsage) inodesUsedPercent() float64 {
+	if u.inodes == 0 {
+		return 0
+	}
+	return 100 * float64(u.inodes-u.inodesFree) / float64(u.inodes)
+}
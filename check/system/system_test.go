@@ -0,0 +1,105 @@
+package system
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestChecker(t *testing.T) {
+	procDir, err := ioutil.TempDir("", "checkup")
+	if err != nil {
+		t.Fatalf("Cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(procDir)
+
+	meminfo := "MemTotal:       16000000 kB\nMemFree:         1000000 kB\nMemAvailable:    4000000 kB\n"
+	if err := ioutil.WriteFile(filepath.Join(procDir, "meminfo"), []byte(meminfo), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(procDir, "loadavg"), []byte("3.50 2.00 1.00 2/300 12345\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	testName := "TestSystem"
+	hc := Checker{
+		Name:                   testName,
+		ProcDir:                procDir,
+		Disks:                  []Disk{{Path: procDir}},
+		MinMemAvailablePercent: 20,
+		MaxLoad:                4,
+	}
+
+	result, err := hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Title, testName; got != want {
+		t.Errorf("Expected result.Title='%s', got '%s'", want, got)
+	}
+	if got, want := result.Healthy, true; got != want {
+		t.Errorf("Expected result.Healthy=%v, got %v (%s %v)", want, got, result.Notice, result.Times)
+	}
+
+	// Exceed every limit
+	hc.Disks = []Disk{{Path: procDir, MinFreePercent: 101, MaxInodesUsedPercent: 0.0000001}}
+	hc.MinMemAvailablePercent = 30
+	hc.MaxLoad = 3
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Down, true; got != want {
+		t.Errorf("Expected result.Down=%v, got %v", want, got)
+	}
+	for _, want := range []string{
+		"disk space free, want at least 101.0%",
+		"25.0% memory available, want at least 30.0%",
+		"load average 3.50, want at most 3.00",
+	} {
+		if !strings.Contains(result.Notice, want) {
+			t.Errorf("Expected notice to contain '%s', got '%s'", want, result.Notice)
+		}
+	}
+
+	hc.Raise = "warn"
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Degraded, true; got != want {
+		t.Errorf("Expected result.Degraded=%v, got %v", want, got)
+	}
+
+	// Unreadable system information
+	hc.Disks = []Disk{{Path: filepath.Join(procDir, "nonexistent")}}
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Down, true; got != want {
+		t.Errorf("Expected result.Down=%v, got %v", want, got)
+	}
+}
+
+func TestMemAvailableFallback(t *testing.T) {
+	procDir, err := ioutil.TempDir("", "checkup")
+	if err != nil {
+		t.Fatalf("Cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(procDir)
+
+	meminfo := "MemTotal: 1000 kB\nMemFree: 100 kB\nBuffers: 50 kB\nCached: 100 kB\n"
+	if err := ioutil.WriteFile(filepath.Join(procDir, "meminfo"), []byte(meminfo), 0644); err != nil {
+		t.Fatal(err)
+	}
+	avail, err := Checker{ProcDir: procDir}.memAvailablePercent()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if got, want := avail, 25.0; got != want {
+		t.Errorf("Expected %v%% available, got %v%%", want, got)
+	}
+}
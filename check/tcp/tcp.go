@@ -4,7 +4,6 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"net"
 	"time"
@@ -50,6 +49,22 @@ type Checker struct {
 	// Attempts is how many requests the client will
 	// make to the endpoint in a single check.
 	Attempts int `json:"attempts,omitempty"`
+
+	// Retries is how many times to retry a failed attempt
+	// before counting it as failed.
+	Retries int `json:"retries,omitempty"`
+
+	// RetryBackoff is how long to wait before the first
+	// retry of an attempt. The wait doubles for each
+	// further retry.
+	RetryBackoff time.Duration `json:"retry_backoff,omitempty"`
+
+	// Policy is how the status of the endpoint is
+	// concluded from the attempts.
+	types.Policy
+
+	// CheckerOptions are the options common to all checkers.
+	types.CheckerOptions
 }
 
 // Check performs checks using c according to its configuration.
@@ -62,9 +77,13 @@ func (c Checker) Check() (types.Result, error) {
 	result := types.NewResult()
 	result.Title = c.Name
 	result.Endpoint = c.URL
+
+	if err := c.Policy.Validate(); err != nil {
+		return result, err
+	}
 	result.Times = c.doChecks()
 
-	return c.conclude(result), nil
+	return c.Policy.Conclude(result, c.ThresholdRTT), nil
 }
 
 // New creates a new Checker instance based on json config
@@ -79,11 +98,13 @@ func (Checker) Type() string {
 	return Type
 }
 
+// EndpointName returns the name of the endpoint
+func (c Checker) EndpointName() string {
+	return c.Name
+}
+
 // doChecks executes and returns each attempt.
 func (c Checker) doChecks() types.Attempts {
-	var err error
-	var conn net.Conn
-
 	timeout := c.Timeout
 	if timeout == 0 {
 		timeout = 1 * time.Second
@@ -91,72 +112,54 @@ func (c Checker) doChecks() types.Attempts {
 
 	checks := make(types.Attempts, c.Attempts)
 	for i := 0; i < c.Attempts; i++ {
-		start := time.Now()
+		checks[i] = types.Retry(c.Retries, c.RetryBackoff, func() types.Attempt {
+			return c.doCheck(timeout)
+		})
+	}
+	return checks
+}
 
-		if c.TLSEnabled {
-			// Dialer with timeout
-			dialer := &net.Dialer{
-				Timeout: timeout,
-			}
+// doCheck connects to the endpoint once.
+func (c Checker) doCheck(timeout time.Duration) types.Attempt {
+	var check types.Attempt
+	var err error
+	var conn net.Conn
 
-			// TLS config based on configuration
-			var tlsConfig tls.Config
-			tlsConfig.InsecureSkipVerify = c.TLSSkipVerify
-			if c.TLSCAFile != "" {
-				rootPEM, err := ioutil.ReadFile(c.TLSCAFile)
-				if err != nil || rootPEM == nil {
-					checks[i].Error = "failed to read root certificate"
-				}
-				pool := x509.NewCertPool()
-				ok := pool.AppendCertsFromPEM([]byte(rootPEM))
-				if !ok {
-					checks[i].Error = "failed to parse root certificate"
-				}
-				tlsConfig.RootCAs = pool
-			}
-			if conn, err = tls.DialWithDialer(dialer, "tcp", c.URL, &tlsConfig); err == nil {
-				conn.Close()
+	start := time.Now()
+
+	if c.TLSEnabled {
+		// Dialer with timeout
+		dialer := &net.Dialer{
+			Timeout: timeout,
+		}
+
+		// TLS config based on configuration
+		var tlsConfig tls.Config
+		tlsConfig.InsecureSkipVerify = c.TLSSkipVerify
+		if c.TLSCAFile != "" {
+			rootPEM, err := ioutil.ReadFile(c.TLSCAFile)
+			if err != nil || rootPEM == nil {
+				check.Error = "failed to read root certificate"
 			}
-		} else {
-			if conn, err = net.DialTimeout("tcp", c.URL, timeout); err == nil {
-				conn.Close()
+			pool := x509.NewCertPool()
+			ok := pool.AppendCertsFromPEM([]byte(rootPEM))
+			if !ok {
+				check.Error = "failed to parse root certificate"
 			}
+			tlsConfig.RootCAs = pool
 		}
-
-		checks[i].RTT = time.Since(start)
-		if err != nil {
-			checks[i].Error = err.Error()
-			continue
+		if conn, err = tls.DialWithDialer(dialer, "tcp", c.URL, &tlsConfig); err == nil {
+			conn.Close()
 		}
-	}
-	return checks
-}
-
-// conclude takes the data in result from the attempts and
-// computes remaining values needed to fill out the result.
-// It detects degraded (high-latency) responses and makes
-// the conclusion about the result's status.
-func (c Checker) conclude(result types.Result) types.Result {
-	result.ThresholdRTT = c.ThresholdRTT
-
-	// Check errors (down)
-	for i := range result.Times {
-		if result.Times[i].Error != "" {
-			result.Down = true
-			return result
+	} else {
+		if conn, err = net.DialTimeout("tcp", c.URL, timeout); err == nil {
+			conn.Close()
 		}
 	}
 
-	// Check round trip time (degraded)
-	if c.ThresholdRTT > 0 {
-		stats := result.ComputeStats()
-		if stats.Median > c.ThresholdRTT {
-			result.Notice = fmt.Sprintf("median round trip time exceeded threshold (%s)", c.ThresholdRTT)
-			result.Degraded = true
-			return result
-		}
+	check.RTT = time.Since(start)
+	if err != nil {
+		check.Error = err.Error()
 	}
-
-	result.Healthy = true
-	return result
+	return check
 }
@@ -46,6 +46,15 @@ type Checker struct {
 	// make to the endpoint in a single check.
 	Attempts int `json:"attempts,omitempty"`
 
+	// Retries is how many times to retry a failed attempt
+	// before counting it as failed.
+	Retries int `json:"retries,omitempty"`
+
+	// RetryBackoff is how long to wait before the first
+	// retry of an attempt. The wait doubles for each
+	// further retry.
+	RetryBackoff time.Duration `json:"retry_backoff,omitempty"`
+
 	// CertExpiryThreshold is how close to expiration
 	// the TLS certificate must be before declaring
 	// a degraded status. Default is 14 days.
@@ -60,6 +69,13 @@ type Checker struct {
 	// over values described from the JSON (exported)
 	// fields, where necessary.
 	tlsConfig *tls.Config
+
+	// Policy is how the status of the endpoint is
+	// concluded from the attempts.
+	types.Policy
+
+	// CheckerOptions are the options common to all checkers.
+	types.CheckerOptions
 }
 
 // New creates a new Checker instance based on json config
@@ -74,6 +90,11 @@ func (Checker) Type() string {
 	return Type
 }
 
+// EndpointName returns the name of the endpoint
+func (c Checker) EndpointName() string {
+	return c.Name
+}
+
 // Check performs checks using c according to its configuration.
 // An error is only returned if there is a configuration error.
 func (c Checker) Check() (types.Result, error) {
@@ -84,6 +105,14 @@ func (c Checker) Check() (types.Result, error) {
 		c.CertExpiryThreshold = 24 * time.Hour * 14
 	}
 
+	result := types.NewResult()
+	result.Title = c.Name
+	result.Endpoint = c.URL
+
+	if err := c.Policy.Validate(); err != nil {
+		return result, err
+	}
+
 	if len(c.TrustedRoots) > 0 {
 		if c.tlsConfig == nil {
 			c.tlsConfig = new(tls.Config)
@@ -104,9 +133,6 @@ func (c Checker) Check() (types.Result, error) {
 
 	attempts, conns := c.doChecks()
 
-	result := types.NewResult()
-	result.Title = c.Name
-	result.Endpoint = c.URL
 	result.Times = attempts
 	result.ThresholdRTT = c.ThresholdRTT
 
@@ -122,15 +148,18 @@ func (c Checker) doChecks() (types.Attempts, []*tls.Conn) {
 	checks := make(types.Attempts, c.Attempts)
 	conns := make([]*tls.Conn, c.Attempts)
 	for i := 0; i < c.Attempts; i++ {
-		dialer := &net.Dialer{Timeout: c.Timeout}
-		start := time.Now()
-		conn, err := tls.DialWithDialer(dialer, "tcp", c.URL, c.tlsConfig)
-		checks[i].RTT = time.Since(start)
-		conns[i] = conn
-		if err != nil {
-			checks[i].Error = err.Error()
-			continue
-		}
+		checks[i] = types.Retry(c.Retries, c.RetryBackoff, func() types.Attempt {
+			var check types.Attempt
+			dialer := &net.Dialer{Timeout: c.Timeout}
+			start := time.Now()
+			conn, err := tls.DialWithDialer(dialer, "tcp", c.URL, c.tlsConfig)
+			check.RTT = time.Since(start)
+			conns[i] = conn
+			if err != nil {
+				check.Error = err.Error()
+			}
+			return check
+		})
 	}
 	return checks, conns
 }
@@ -151,11 +180,9 @@ func (c Checker) conclude(conns []*tls.Conn, result types.Result) types.Result {
 	}()
 
 	// check errors (down)
-	for i := range result.Times {
-		if result.Times[i].Error != "" {
-			result.Down = true
-			return result
-		}
+	if c.Policy.Failed(result.Times) {
+		result.Down = true
+		return result
 	}
 
 	// check if certificates expired (down)
@@ -192,13 +219,10 @@ func (c Checker) conclude(conns []*tls.Conn, result types.Result) types.Result {
 	}
 
 	// check round trip time (degraded)
-	if c.ThresholdRTT > 0 {
-		stats := result.ComputeStats()
-		if stats.Median > c.ThresholdRTT {
-			result.Notice = fmt.Sprintf("median round trip time exceeded threshold (%s)", c.ThresholdRTT)
-			result.Degraded = true
-			return result
-		}
+	if notice := c.Policy.Slow(result, c.ThresholdRTT); notice != "" {
+		result.Notice = notice
+		result.Degraded = true
+		return result
 	}
 
 	result.Healthy = true
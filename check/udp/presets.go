@@ -0,0 +1,142 @@
+package udp
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ntpRequest returns an NTP v3 client mode request.
+func ntpRequest() []byte {
+	req := make([]byte, 48)
+	req[0] = 0x1b // LI = 0, VN = 3, Mode = 3 (client)
+	return req
+}
+
+// checkNTPReply returns an error if reply is not an NTP
+// server mode packet.
+func checkNTPReply(reply []byte) error {
+	if len(reply) < 48 {
+		return fmt.Errorf("ntp: short reply (%d bytes)", len(reply))
+	}
+	if mode := reply[0] & 0x07; mode != 4 {
+		return fmt.Errorf("ntp: unexpected mode %d in reply", mode)
+	}
+	return nil
+}
+
+// BER tags used by SNMPv1 messages.
+const (
+	berInteger     = 0x02
+	berOctetString = 0x04
+	berNull        = 0x05
+	berOID         = 0x06
+	berSequence    = 0x30
+	snmpGetPDU     = 0xa0
+	snmpRespPDU    = 0xa2
+)
+
+// sysDescr.0 (1.3.6.1.2.1.1.1.0), BER encoded.
+var oidSysDescr = []byte{0x2b, 0x06, 0x01, 0x02, 0x01, 0x01, 0x01, 0x00}
+
+// snmpGetRequest returns an SNMPv1 GetRequest for sysDescr.0.
+func snmpGetRequest(community string) []byte {
+	varbind := berTLV(berSequence, concat(
+		berTLV(berOID, oidSysDescr),
+		berTLV(berNull, nil),
+	))
+	pdu := berTLV(snmpGetPDU, concat(
+		berTLV(berInteger, []byte{0x01}), // request-id
+		berTLV(berInteger, []byte{0x00}), // error-status
+		berTLV(berInteger, []byte{0x00}), // error-index
+		berTLV(berSequence, varbind),
+	))
+	return berTLV(berSequence, concat(
+		berTLV(berInteger, []byte{0x00}), // version-1
+		berTLV(berOctetString, []byte(community)),
+		pdu,
+	))
+}
+
+// checkSNMPReply returns an error if reply is not an SNMP
+// GetResponse with a zero error status.
+func checkSNMPReply(reply []byte) error {
+	tag, msg, _, err := berNext(reply)
+	if err != nil || tag != berSequence {
+		return errors.New("snmp: reply is not an SNMP message")
+	}
+	// skip version and community
+	for i := 0; i < 2; i++ {
+		if _, _, msg, err = berNext(msg); err != nil {
+			return fmt.Errorf("snmp: %v", err)
+		}
+	}
+	tag, pdu, _, err := berNext(msg)
+	if err != nil {
+		return fmt.Errorf("snmp: %v", err)
+	}
+	if tag != snmpRespPDU {
+		return fmt.Errorf("snmp: unexpected PDU type 0x%x in reply", tag)
+	}
+	// skip request-id
+	if _, _, pdu, err = berNext(pdu); err != nil {
+		return fmt.Errorf("snmp: %v", err)
+	}
+	_, status, _, err := berNext(pdu)
+	if err != nil {
+		return fmt.Errorf("snmp: %v", err)
+	}
+	for _, b := range status {
+		if b != 0 {
+			return fmt.Errorf("snmp: reply has error status %d", status[len(status)-1])
+		}
+	}
+	return nil
+}
+
+// berTLV encodes value with the given tag.
+func berTLV(tag byte, value []byte) []byte {
+	var length []byte
+	switch n := len(value); {
+	case n < 0x80:
+		length = []byte{byte(n)}
+	case n <= 0xff:
+		length = []byte{0x81, byte(n)}
+	default:
+		length = []byte{0x82, byte(n >> 8), byte(n)}
+	}
+	return concat([]byte{tag}, length, value)
+}
+
+// berNext decodes the first TLV in b, returning its tag,
+// its value and the remaining bytes.
+func berNext(b []byte) (tag byte, value, rest []byte, err error) {
+	if len(b) < 2 {
+		return 0, nil, nil, errors.New("truncated BER element")
+	}
+	tag, b = b[0], b[1:]
+	n := int(b[0])
+	b = b[1:]
+	if n&0x80 != 0 {
+		octets := n & 0x7f
+		if octets == 0 || octets > 2 || len(b) < octets {
+			return 0, nil, nil, errors.New("invalid BER length")
+		}
+		n = 0
+		for _, o := range b[:octets] {
+			n = n<<8 | int(o)
+		}
+		b = b[octets:]
+	}
+	if len(b) < n {
+		return 0, nil, nil, errors.New("truncated BER element")
+	}
+	return tag, b[:n], b[n:], nil
+}
+
+func concat(parts ...[]byte) []byte {
+	var b []byte
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return b
+}
@@ -0,0 +1,283 @@
+package udp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "udp"
+
+// Presets for common UDP services. A preset provides the
+// request payload, a default port and validation of the
+// reply, so that no payload needs to be configured.
+const (
+	// PresetNTP sends an NTP v3 client request and expects
+	// a server mode reply.
+	PresetNTP = "ntp"
+
+	// PresetSNMP sends an SNMPv1 GetRequest for sysDescr.0
+	// and expects a GetResponse without error status.
+	PresetSNMP = "snmp"
+)
+
+// Checker implements a Checker for UDP endpoints that
+// reply to a request datagram.
+type Checker struct {
+	// Name is the name of the endpoint.
+	Name string `json:"endpoint_name"`
+
+	// URL is the host:port of the endpoint. The port may
+	// be omitted if a Preset is used.
+	URL string `json:"endpoint_url"`
+
+	// Preset selects a built-in request/response pair for
+	// a well-known protocol, either "ntp" or "snmp". If set,
+	// Payload and PayloadHex are ignored.
+	Preset string `json:"preset,omitempty"`
+
+	// Community is the SNMP community used by the "snmp"
+	// preset. Default is "public".
+	Community string `json:"community,omitempty"`
+
+	// Payload is the text payload to send.
+	Payload string `json:"payload,omitempty"`
+
+	// PayloadHex is the payload to send, hex encoded.
+	// Whitespace is ignored. It takes precedence over
+	// Payload if both are set.
+	PayloadHex string `json:"payload_hex,omitempty"`
+
+	// MustContain is a string that the reply must
+	// contain in order to be considered up.
+	MustContain string `json:"must_contain,omitempty"`
+
+	// MustContainHex is like MustContain, but the
+	// expected bytes are hex encoded.
+	MustContainHex string `json:"must_contain_hex,omitempty"`
+
+	// NoResponse is for services that never reply, such
+	// as syslog collectors. The check then only fails if
+	// the datagram is actively refused by the remote host.
+	NoResponse bool `json:"no_response,omitempty"`
+
+	// Timeout is the maximum time to wait for a reply.
+	// Default is 1 second.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// ThresholdRTT is the maximum round trip time to
+	// allow for a healthy endpoint. If non-zero and a
+	// request takes longer than ThresholdRTT, the
+	// endpoint will be considered unhealthy. Note that
+	// this duration includes any in-between network
+	// latency.
+	ThresholdRTT time.Duration `json:"threshold_rtt,omitempty"`
+
+	// Attempts is how many requests the client will
+	// make to the endpoint in a single check.
+	Attempts int `json:"attempts,omitempty"`
+
+	// AttemptSpacing spaces out each attempt in a check
+	// by this duration to avoid hitting a remote too
+	// quickly in succession. By default, no waiting
+	// occurs between attempts.
+	AttemptSpacing time.Duration `json:"attempt_spacing,omitempty"`
+
+	// Policy is how the status of the endpoint is
+	// concluded from the attempts.
+	types.Policy
+
+	// CheckerOptions are the options common to all checkers.
+	types.CheckerOptions
+}
+
+// New creates a new Checker instance based on json config
+func New(config json.RawMessage) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	return checker, err
+}
+
+// Type returns the checker package name
+func (Checker) Type() string {
+	return Type
+}
+
+// EndpointName returns the name of the endpoint
+func (c Checker) EndpointName() string {
+	return c.Name
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	if c.Attempts < 1 {
+		c.Attempts = 1
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 1 * time.Second
+	}
+
+	result := types.NewResult()
+	result.Title = c.Name
+	result.Endpoint = c.URL
+
+	if err := c.Policy.Validate(); err != nil {
+		return result, err
+	}
+
+	addr, err := c.address()
+	if err != nil {
+		return result, err
+	}
+	payload, err := c.payload()
+	if err != nil {
+		return result, err
+	}
+	mustContain, err := c.mustContain()
+	if err != nil {
+		return result, err
+	}
+
+	result.Times = c.doChecks(addr, payload, mustContain)
+
+	return c.Policy.Conclude(result, c.ThresholdRTT), nil
+}
+
+// address returns the host:port to send datagrams to,
+// adding the preset's default port if none is given.
+func (c Checker) address() (string, error) {
+	if _, _, err := net.SplitHostPort(c.URL); err == nil {
+		return c.URL, nil
+	}
+	switch c.Preset {
+	case PresetNTP:
+		return net.JoinHostPort(c.URL, "123"), nil
+	case PresetSNMP:
+		return net.JoinHostPort(c.URL, "161"), nil
+	}
+	return "", fmt.Errorf("endpoint_url must be host:port, got '%s'", c.URL)
+}
+
+// payload returns the datagram to send.
+func (c Checker) payload() ([]byte, error) {
+	switch c.Preset {
+	case "":
+	case PresetNTP:
+		return ntpRequest(), nil
+	case PresetSNMP:
+		community := c.Community
+		if community == "" {
+			community = "public"
+		}
+		return snmpGetRequest(community), nil
+	default:
+		return nil, fmt.Errorf("unknown preset '%s'", c.Preset)
+	}
+
+	if c.PayloadHex != "" {
+		b, err := decodeHex(c.PayloadHex)
+		if err != nil {
+			return nil, fmt.Errorf("decoding payload_hex: %v", err)
+		}
+		return b, nil
+	}
+	if c.Payload == "" {
+		return nil, fmt.Errorf("no payload configured")
+	}
+	return []byte(c.Payload), nil
+}
+
+// mustContain returns the bytes that a reply must contain,
+// or nil if there are none.
+func (c Checker) mustContain() ([]byte, error) {
+	if c.MustContainHex != "" {
+		b, err := decodeHex(c.MustContainHex)
+		if err != nil {
+			return nil, fmt.Errorf("decoding must_contain_hex: %v", err)
+		}
+		return b, nil
+	}
+	if c.MustContain != "" {
+		return []byte(c.MustContain), nil
+	}
+	return nil, nil
+}
+
+// doChecks sends payload to addr and returns each attempt.
+func (c Checker) doChecks(addr string, payload, mustContain []byte) types.Attempts {
+	checks := make(types.Attempts, c.Attempts)
+	for i := 0; i < c.Attempts; i++ {
+		start := time.Now()
+		reply, err := c.exchange(addr, payload)
+		checks[i].RTT = time.Since(start)
+		if err == nil && !c.NoResponse {
+			err = c.checkDown(reply, mustContain)
+		}
+		if err != nil {
+			checks[i].Error = err.Error()
+		}
+		if c.AttemptSpacing > 0 {
+			time.Sleep(c.AttemptSpacing)
+		}
+	}
+	return checks
+}
+
+// exchange sends payload to addr and waits for a reply.
+func (c Checker) exchange(addr string, payload []byte) ([]byte, error) {
+	conn, err := net.DialTimeout("udp", addr, c.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(c.Timeout)); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(payload); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 65535)
+	n, err := conn.Read(buf)
+	if err != nil {
+		if nerr, ok := err.(net.Error); ok && nerr.Timeout() && c.NoResponse {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+// checkDown checks whether the endpoint is down based on reply
+// and the configuration of c. It returns a non-nil error if down.
+// Note that it does not check for degraded response.
+func (c Checker) checkDown(reply, mustContain []byte) error {
+	switch c.Preset {
+	case PresetNTP:
+		if err := checkNTPReply(reply); err != nil {
+			return err
+		}
+	case PresetSNMP:
+		if err := checkSNMPReply(reply); err != nil {
+			return err
+		}
+	}
+	if mustContain != nil && !bytes.Contains(reply, mustContain) {
+		return fmt.Errorf("reply does not contain '%s'", mustContain)
+	}
+	return nil
+}
+
+// decodeHex decodes s, ignoring any whitespace in it.
+func decodeHex(s string) ([]byte, error) {
+	return hex.DecodeString(strings.Join(strings.Fields(s), ""))
+}
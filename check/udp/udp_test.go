@@ -0,0 +1,208 @@
+package udp
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+// serve answers every datagram received on a local UDP socket
+// with the result of reply, or not at all if reply returns nil.
+func serve(t *testing.T, reply func([]byte) []byte) net.PacketConn {
+	srv, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Couldn't start UDP test server with error: %v", err)
+	}
+	go func() {
+		buf := make([]byte, 65535)
+		for {
+			n, addr, err := srv.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if resp := reply(buf[:n]); resp != nil {
+				srv.WriteTo(resp, addr)
+			}
+		}
+	}()
+	return srv
+}
+
+func TestChecker(t *testing.T) {
+	srv := serve(t, func(req []byte) []byte {
+		return append([]byte("echo: "), req...)
+	})
+	defer srv.Close()
+
+	endpt := srv.LocalAddr().String()
+	testName := "TestUDP"
+	hc := Checker{Name: testName, URL: endpt, Payload: "ping", Attempts: 2}
+
+	// Try an up server
+	result, err := hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Title, testName; got != want {
+		t.Errorf("Expected result.Title='%s', got '%s'", want, got)
+	}
+	if got, want := result.Endpoint, endpt; got != want {
+		t.Errorf("Expected result.Endpoint='%s', got '%s'", want, got)
+	}
+	if got, want := result.Healthy, true; got != want {
+		t.Errorf("Expected result.Healthy=%v, got %v", want, got)
+	}
+	if got, want := len(result.Times), hc.Attempts; got != want {
+		t.Errorf("Expected %d attempts, got %d", want, got)
+	}
+
+	// Assertions on the reply
+	hc.MustContain = "echo: ping"
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Healthy, true; got != want {
+		t.Errorf("Expected result.Healthy=%v, got %v", want, got)
+	}
+
+	hc.MustContain = ""
+	hc.PayloadHex = "de ad be ef"
+	hc.MustContainHex = "deadbeef"
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Healthy, true; got != want {
+		t.Errorf("Expected result.Healthy=%v, got %v", want, got)
+	}
+
+	hc.MustContainHex = "cafe"
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Down, true; got != want {
+		t.Errorf("Expected result.Down=%v, got %v", want, got)
+	}
+
+	hc.MustContainHex = ""
+	hc.ThresholdRTT = 1 * time.Nanosecond
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Degraded, true; got != want {
+		t.Errorf("Expected result.Degraded=%v, got %v", want, got)
+	}
+
+	// Configuration errors
+	hc.PayloadHex = "xyz"
+	if _, err = hc.Check(); err == nil {
+		t.Error("Expected an error for invalid payload_hex, didn't get one")
+	}
+	hc.PayloadHex = ""
+	hc.Payload = ""
+	if _, err = hc.Check(); err == nil {
+		t.Error("Expected an error with no payload, didn't get one")
+	}
+}
+
+func TestCheckerNoReply(t *testing.T) {
+	srv := serve(t, func([]byte) []byte { return nil })
+	defer srv.Close()
+
+	hc := Checker{Name: "Silent", URL: srv.LocalAddr().String(), Payload: "ping", Timeout: 50 * time.Millisecond}
+	result, err := hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Down, true; got != want {
+		t.Errorf("Expected result.Down=%v, got %v", want, got)
+	}
+
+	// Services like syslog never reply
+	hc.NoResponse = true
+	result, err = hc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Healthy, true; got != want {
+		t.Errorf("Expected result.Healthy=%v, got %v", want, got)
+	}
+}
+
+func TestCheckerPresets(t *testing.T) {
+	srv := serve(t, func(req []byte) []byte {
+		if len(req) == 48 && req[0] == 0x1b {
+			resp := make([]byte, 48)
+			resp[0] = 0x1c // LI = 0, VN = 3, Mode = 4 (server)
+			return resp
+		}
+		if bytes.Equal(req, snmpGetRequest("public")) {
+			return snmpResponse(0)
+		}
+		return []byte("?")
+	})
+	defer srv.Close()
+
+	endpt := srv.LocalAddr().String()
+	for _, preset := range []string{PresetNTP, PresetSNMP} {
+		hc := Checker{Name: preset, URL: endpt, Preset: preset}
+		result, err := hc.Check()
+		if err != nil {
+			t.Errorf("[%s] Didn't expect an error: %v", preset, err)
+		}
+		if got, want := result.Healthy, true; got != want {
+			t.Errorf("[%s] Expected result.Healthy=%v, got %v (%v)", preset, want, got, result.Times)
+		}
+	}
+
+	hc := Checker{Name: "unknown", URL: endpt, Preset: "gopher"}
+	if _, err := hc.Check(); err == nil {
+		t.Error("Expected an error for an unknown preset, didn't get one")
+	}
+}
+
+func TestPresetReplies(t *testing.T) {
+	if err := checkNTPReply(make([]byte, 12)); err == nil {
+		t.Error("Expected an error for a short NTP reply")
+	}
+	client := ntpRequest()
+	if err := checkNTPReply(client); err == nil {
+		t.Error("Expected an error for a client mode NTP reply")
+	}
+
+	if err := checkSNMPReply(snmpResponse(0)); err != nil {
+		t.Errorf("Expected valid SNMP reply, got: %v", err)
+	}
+	if err := checkSNMPReply(snmpResponse(2)); err == nil {
+		t.Error("Expected an error for an SNMP reply with noSuchName status")
+	}
+	if err := checkSNMPReply(snmpGetRequest("public")); err == nil {
+		t.Error("Expected an error for a GetRequest PDU in reply")
+	}
+	if err := checkSNMPReply([]byte("garbage")); err == nil {
+		t.Error("Expected an error for a non-SNMP reply")
+	}
+}
+
+// snmpResponse builds a GetResponse with the given error status.
+func snmpResponse(status byte) []byte {
+	varbind := berTLV(berSequence, concat(
+		berTLV(berOID, oidSysDescr),
+		berTLV(berOctetString, []byte("checkup test agent")),
+	))
+	pdu := berTLV(snmpRespPDU, concat(
+		berTLV(berInteger, []byte{0x01}),
+		berTLV(berInteger, []byte{status}),
+		berTLV(berInteger, []byte{0x00}),
+		berTLV(berSequence, varbind),
+	))
+	return berTLV(berSequence, concat(
+		berTLV(berInteger, []byte{0x00}),
+		berTLV(berOctetString, []byte("public")),
+		pdu,
+	))
+}
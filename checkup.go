@@ -43,6 +43,13 @@ type Checkup struct {
 	// completed. Notifier may evaluate and choose to
 	// send a notification of potential problems.
 	Notifiers []Notifier `json:"notifiers,omitempty"`
+
+	// Tracker keeps the state of endpoints across rounds
+	// of checks, which is needed to honor the failure and
+	// recovery thresholds of checkers. If nil, these
+	// thresholds are ignored. c.CheckAndStoreEvery()
+	// sets it if needed.
+	Tracker *Tracker `json:"-"`
 }
 
 // Check performs the health checks. An error is only
@@ -57,22 +64,53 @@ func (c Checkup) Check() ([]types.Result, error) {
 			c.ConcurrentChecks)
 	}
 
-	results := make([]types.Result, len(c.Checkers))
+	deps, err := c.dependencies()
+	if err != nil {
+		return nil, err
+	}
+
+	checkerResults := make([][]types.Result, len(c.Checkers))
 	errs := make(types.Errors, len(c.Checkers))
 	throttle := make(chan struct{}, c.ConcurrentChecks)
+	done := make([]chan struct{}, len(c.Checkers))
 	wg := sync.WaitGroup{}
 
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
 	for i, checker := range c.Checkers {
-		throttle <- struct{}{}
 		wg.Add(1)
 		go func(i int, checker Checker) {
-			results[i], errs[i] = checker.Check()
+			// dependencies are evaluated first
+			for _, j := range deps[i] {
+				<-done[j]
+			}
+			throttle <- struct{}{}
+			if mc, ok := checker.(MultiChecker); ok {
+				checkerResults[i], errs[i] = mc.CheckAll()
+			} else {
+				checkerResults[i] = make([]types.Result, 1)
+				checkerResults[i][0], errs[i] = checker.Check()
+			}
 			<-throttle
+			if len(checkerResults[i]) > 0 {
+				checkerOptions(checker).Annotate(&checkerResults[i][0])
+			}
+			if c.Tracker != nil && errs[i] == nil && len(checkerResults[i]) > 0 {
+				c.Tracker.Track(&checkerResults[i][0], checkerOptions(checker))
+			}
+			suppress(checkerResults[i], deps[i], checkerResults)
+			close(done[i])
 			wg.Done()
 		}(i, checker)
 	}
 	wg.Wait()
 
+	var results []types.Result
+	for _, r := range checkerResults {
+		results = append(results, r...)
+	}
+
 	if !c.Timestamp.IsZero() {
 		for i := range results {
 			results[i].Timestamp = c.Timestamp.UTC().UnixNano()
@@ -93,6 +131,136 @@ func (c Checkup) Check() ([]types.Result, error) {
 	return results, nil
 }
 
+// WithTags returns a copy of c that only performs the checks
+// of the checkers with any of tags, along with the checkers
+// they depend on.
+func (c Checkup) WithTags(tags ...string) Checkup {
+	names := checkerNames(c.Checkers)
+	keep := make(map[string]bool)
+	var visit func(name string)
+	visit = func(name string) {
+		if keep[name] {
+			return
+		}
+		keep[name] = true
+		for i, checker := range c.Checkers {
+			if names[i] == name {
+				for _, dep := range checkerOptions(checker).DependsOn {
+					visit(dep)
+				}
+			}
+		}
+	}
+	for i, checker := range c.Checkers {
+		if checkerOptions(checker).HasTag(tags...) {
+			visit(names[i])
+		}
+	}
+
+	var checkers []Checker
+	for i, checker := range c.Checkers {
+		if keep[names[i]] {
+			checkers = append(checkers, checker)
+		}
+	}
+	c.Checkers = checkers
+	return c
+}
+
+// dependencies returns, for each of c.Checkers, the indices
+// of the checkers it depends on. An error is returned if a
+// dependency does not exist or if dependencies form a cycle.
+func (c Checkup) dependencies() ([][]int, error) {
+	names := checkerNames(c.Checkers)
+	index := make(map[string]int)
+	for i, name := range names {
+		index[name] = i
+	}
+
+	deps := make([][]int, len(c.Checkers))
+	for i, checker := range c.Checkers {
+		for _, name := range checkerOptions(checker).DependsOn {
+			j, ok := index[name]
+			if !ok {
+				return nil, fmt.Errorf("%s: unknown dependency '%s'", names[i], name)
+			}
+			deps[i] = append(deps[i], j)
+		}
+	}
+
+	// make sure there are no cycles, which would block forever
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(c.Checkers))
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case visiting:
+			return fmt.Errorf("%s: dependency cycle", names[i])
+		case visited:
+			return nil
+		}
+		state[i] = visiting
+		for _, j := range deps[i] {
+			if err := visit(j); err != nil {
+				return err
+			}
+		}
+		state[i] = visited
+		return nil
+	}
+	for i := range c.Checkers {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+
+	return deps, nil
+}
+
+// suppress marks the first of results as unknown instead of
+// down if any of the checkers at indices deps, whose results
+// are in all, is down or suppressed itself.
+func suppress(results []types.Result, deps []int, all [][]types.Result) {
+	if len(results) == 0 || !results[0].Down {
+		return
+	}
+	for _, j := range deps {
+		if len(all[j]) == 0 {
+			continue
+		}
+		if dep := all[j][0]; dep.Down || dep.Suppressed {
+			results[0].Down = false
+			results[0].Suppressed = true
+			results[0].Notice = fmt.Sprintf("dependency %s is down", dep.Title)
+			return
+		}
+	}
+}
+
+// checkerOptions returns the options common to all checkers
+// of checker.
+func checkerOptions(checker Checker) types.CheckerOptions {
+	if o, ok := checker.(interface{ Options() types.CheckerOptions }); ok {
+		return o.Options()
+	}
+	return types.CheckerOptions{}
+}
+
+// checkerNames returns the endpoint names of checkers.
+func checkerNames(checkers []Checker) []string {
+	names := make([]string, len(checkers))
+	for i, checker := range checkers {
+		if n, ok := checker.(interface{ EndpointName() string }); ok {
+			names[i] = n.EndpointName()
+		}
+	}
+	return names
+}
+
 // CheckAndStore performs health checks and immediately
 // stores the results to the configured storage if there
 // were no errors. Checks are not performed if c.Storage
@@ -126,6 +294,9 @@ func (c Checkup) CheckAndStore() error {
 // would not be wise to set an interval lower than the time it takes
 // to perform the checks.
 func (c Checkup) CheckAndStoreEvery(interval time.Duration) *time.Ticker {
+	if c.Tracker == nil {
+		c.Tracker = NewTracker()
+	}
 	ticker := time.NewTicker(interval)
 	go func() {
 		for range ticker.C {
@@ -221,8 +392,8 @@ func (c *Checkup) UnmarshalJSON(b []byte) error {
 	// hence the conversion. We also know that the
 	// interface types will ultimately cause an error,
 	// but we can ignore it because we handle it below.
-	type checkup2 *Checkup
-	json.Unmarshal(b, checkup2(c))
+	type checkup2 Checkup
+	json.Unmarshal(b, (*checkup2)(c))
 
 	// clean the slate
 	c.Checkers = []Checker{}
@@ -4,10 +4,16 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/sourcegraph/checkup/storage/fs"
 	"github.com/sourcegraph/checkup/types"
 )
 
@@ -106,6 +112,28 @@ func TestComputeStats(t *testing.T) {
 	if got, want := s.Max, 7*time.Second; got != want {
 		t.Errorf("Expected Max=%v, got %v", want, got)
 	}
+	if got, want := s.P90, 7*time.Second; got != want {
+		t.Errorf("Expected P90=%v, got %v", want, got)
+	}
+	if got, want := s.StdDev, 1414213562*time.Nanosecond; got != want {
+		t.Errorf("Expected StdDev=%v, got %v", want, got)
+	}
+
+	s = types.Result{Times: []types.Attempt{
+		{RTT: 1}, {RTT: 2}, {RTT: 3}, {RTT: 4}, {RTT: 5},
+		{RTT: 6}, {RTT: 7}, {RTT: 8}, {RTT: 9}, {RTT: 10},
+		{RTT: 11}, {RTT: 12}, {RTT: 13}, {RTT: 14}, {RTT: 15},
+		{RTT: 16}, {RTT: 17}, {RTT: 18}, {RTT: 19}, {RTT: 20},
+	}}.ComputeStats()
+	if got, want := s.P90, time.Duration(18); got != want {
+		t.Errorf("Expected P90=%v, got %v", want, got)
+	}
+	if got, want := s.P95, time.Duration(19); got != want {
+		t.Errorf("Expected P95=%v, got %v", want, got)
+	}
+	if got, want := s.P99, time.Duration(20); got != want {
+		t.Errorf("Expected P99=%v, got %v", want, got)
+	}
 }
 
 func TestResultStatus(t *testing.T) {
@@ -195,6 +223,293 @@ func TestJSON(t *testing.T) {
 	}
 }
 
+func TestGroupChecker(t *testing.T) {
+	jsonBytes := []byte(`{"checkers":[{"type":"group","endpoint_name":"Sources","checkers":[{"type":"file","endpoint_name":"checkup.go","path":"checkup.go"},{"type":"file","endpoint_name":"check.go","path":"check.go"}],"emit_children":true}],"timestamp":"0001-01-01T00:00:00Z"}`)
+
+	var c Checkup
+	err := json.Unmarshal(jsonBytes, &c)
+	if err != nil {
+		t.Fatalf("Error unmarshaling: %v", err)
+	}
+
+	results, err := c.Check()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if got, want := len(results), 3; got != want {
+		t.Fatalf("Expected %d results, got %d", want, got)
+	}
+	for i, title := range []string{"Sources", "checkup.go", "check.go"} {
+		if results[i].Title != title || !results[i].Healthy {
+			t.Errorf("Expected healthy result for %s, got %+v", title, results[i])
+		}
+	}
+
+	result, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("Error marshaling: %v", err)
+	}
+	if !bytes.Equal(result, jsonBytes) {
+		t.Errorf("\nGot:  %s\nWant: %s", string(result), string(jsonBytes))
+	}
+}
+
+func TestMultiStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkup")
+	if err != nil {
+		t.Fatalf("Cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	dirA, dirB := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	for _, d := range []string{dirA, dirB} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	jsonBytes := []byte(fmt.Sprintf(`{"storage":{"type":"multi","storages":[{"type":"fs","dir":%q,"url":""},{"type":"fs","dir":%q,"url":""}],"primary":1},"checkers":[{"type":"file","endpoint_name":"checkup.go","path":"checkup.go"}],"timestamp":"0001-01-01T00:00:00Z"}`, dirA, dirB))
+
+	var c Checkup
+	if err := json.Unmarshal(jsonBytes, &c); err != nil {
+		t.Fatalf("Error unmarshaling: %v", err)
+	}
+	if err := c.CheckAndStore(); err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	for _, d := range []string{dirA, dirB} {
+		if _, err := os.Stat(filepath.Join(d, "index.json")); err != nil {
+			t.Errorf("Expected results to be stored in %s: %v", d, err)
+		}
+	}
+
+	index, err := c.Storage.(StorageReader).GetIndex()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if len(index) != 1 {
+		t.Errorf("Expected index of primary storage to have 1 check, got %v", index)
+	}
+
+	result, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("Error marshaling: %v", err)
+	}
+	if !bytes.Equal(result, jsonBytes) {
+		t.Errorf("\nGot:  %s\nWant: %s", string(result), string(jsonBytes))
+	}
+}
+
+func TestMigration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkup")
+	if err != nil {
+		t.Fatalf("Cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	from, to := fs.Storage{Dir: filepath.Join(dir, "from")}, fs.Storage{Dir: filepath.Join(dir, "to")}
+	for _, d := range []string{from.Dir, to.Dir} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, ts := range []int64{300, 100, 200} {
+		name := fmt.Sprintf(fs.FilenameFormatString, ts)
+		if err := from.StoreAs(name, ts, []types.Result{{Title: name}}); err != nil {
+			t.Fatalf("Didn't expect an error: %v", err)
+		}
+	}
+
+	var progress []string
+	m := Migration{
+		From:   from,
+		To:     to,
+		DryRun: true,
+		Progress: func(done, total int, name string, skipped bool) {
+			progress = append(progress, fmt.Sprintf("%d/%d %s %t", done, total, name, skipped))
+		},
+	}
+	if err := m.Run(); err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if index, _ := to.GetIndex(); len(index) != 0 {
+		t.Errorf("Expected nothing to be copied in a dry run, got %v", index)
+	}
+
+	// Copy a single check file, then resume
+	if err := to.StoreAs("100-check.json", 100, []types.Result{{Title: "100-check.json"}}); err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	progress = nil
+	m.DryRun, m.Resume = false, true
+	if err := m.Run(); err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	expected := []string{"1/3 100-check.json true", "2/3 200-check.json false", "3/3 300-check.json false"}
+	if strings.Join(progress, ", ") != strings.Join(expected, ", ") {
+		t.Errorf("Expected progress %v, got %v", expected, progress)
+	}
+
+	index, err := to.GetIndex()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	for _, ts := range []int64{100, 200, 300} {
+		name := fmt.Sprintf(fs.FilenameFormatString, ts)
+		if index[name] != ts {
+			t.Errorf("Expected %s to have timestamp %d, got %d", name, ts, index[name])
+		}
+		results, err := to.Fetch(name)
+		if err != nil || len(results) != 1 || results[0].Title != name {
+			t.Errorf("Expected results of %s to be copied, got %v (%v)", name, results, err)
+		}
+	}
+}
+
+func TestDependsOn(t *testing.T) {
+	jsonBytes := []byte(`{"checkers":[` +
+		`{"type":"file","endpoint_name":"C","path":"missing","depends_on":["B"]},` +
+		`{"type":"file","endpoint_name":"B","path":"missing","depends_on":["A"]},` +
+		`{"type":"file","endpoint_name":"A","path":"missing"},` +
+		`{"type":"file","endpoint_name":"D","path":"checkup.go","depends_on":["A"]}]}`)
+
+	var c Checkup
+	err := json.Unmarshal(jsonBytes, &c)
+	if err != nil {
+		t.Fatalf("Error unmarshaling: %v", err)
+	}
+
+	results, err := c.Check()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	for i, test := range []struct {
+		title  string
+		status types.StatusText
+		notice string
+	}{
+		{"C", types.StatusUnknown, "dependency B is down"},
+		{"B", types.StatusUnknown, "dependency A is down"},
+		{"A", types.StatusDown, ""},
+		{"D", types.StatusHealthy, ""},
+	} {
+		if got := results[i].Title; got != test.title {
+			t.Errorf("Test %d: expected title %s, got %s", i, test.title, got)
+		}
+		if got := results[i].Status(); got != test.status {
+			t.Errorf("Test %d: expected status %s, got %s", i, test.status, got)
+		}
+		if got := results[i].Suppressed; got != (test.notice != "") {
+			t.Errorf("Test %d: expected suppressed to be %v, got %v", i, !got, got)
+		}
+		if test.notice != "" && results[i].Notice != test.notice {
+			t.Errorf("Test %d: expected notice '%s', got '%s'", i, test.notice, results[i].Notice)
+		}
+	}
+
+	for i, config := range []string{
+		`{"checkers":[{"type":"file","endpoint_name":"A","path":"checkup.go","depends_on":["B"]}]}`,
+		`{"checkers":[{"type":"file","endpoint_name":"A","path":"checkup.go","depends_on":["B"]},` +
+			`{"type":"file","endpoint_name":"B","path":"checkup.go","depends_on":["A"]}]}`,
+	} {
+		var c Checkup
+		if err := json.Unmarshal([]byte(config), &c); err != nil {
+			t.Fatalf("Test %d: error unmarshaling: %v", i, err)
+		}
+		if _, err := c.Check(); err == nil {
+			t.Errorf("Test %d: expected an error", i)
+		}
+	}
+}
+
+func TestTracker(t *testing.T) {
+	tracker := NewTracker()
+	o := types.CheckerOptions{FailureThreshold: 2, RecoveryThreshold: 2}
+	for i, test := range []struct {
+		down     bool
+		want     types.StatusText
+		failures int
+	}{
+		{false, types.StatusHealthy, 0},
+		{true, types.StatusDegraded, 1},
+		{false, types.StatusHealthy, 0},
+		{true, types.StatusDegraded, 1},
+		{true, types.StatusDown, 2},
+		{true, types.StatusDown, 3},
+		{false, types.StatusDown, 0},
+		{true, types.StatusDown, 1},
+		{false, types.StatusDown, 0},
+		{false, types.StatusHealthy, 0},
+	} {
+		result := types.Result{Title: "Test", Healthy: !test.down, Down: test.down}
+		tracker.Track(&result, o)
+		if got := result.Status(); got != test.want {
+			t.Errorf("Round %d: expected status %s, got %s", i, test.want, got)
+		}
+		if got := result.ConsecutiveFailures; got != test.failures {
+			t.Errorf("Round %d: expected %d consecutive failures, got %d", i, test.failures, got)
+		}
+	}
+}
+
+func TestTags(t *testing.T) {
+	jsonBytes := []byte(`{"checkers":[` +
+		`{"type":"file","endpoint_name":"A","path":"checkup.go","tags":["db"]},` +
+		`{"type":"file","endpoint_name":"B","path":"checkup.go","depends_on":["A"],` +
+		`"tags":["payments","web"],"group":"Payments","owner":"team-payments","labels":{"tier":"1"}},` +
+		`{"type":"file","endpoint_name":"C","path":"checkup.go","tags":["web"]}]}`)
+
+	var c Checkup
+	err := json.Unmarshal(jsonBytes, &c)
+	if err != nil {
+		t.Fatalf("Error unmarshaling: %v", err)
+	}
+
+	results, err := c.WithTags("payments").Check()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if got, want := len(results), 2; got != want {
+		t.Fatalf("Expected %d results, got %d", want, got)
+	}
+	if results[0].Title != "A" || results[1].Title != "B" {
+		t.Errorf("Expected results of B and its dependency A, got %+v", results)
+	}
+	r := results[1]
+	if got, want := r.Group, "Payments"; got != want {
+		t.Errorf("Expected group '%s', got '%s'", want, got)
+	}
+	if got, want := r.Owner, "team-payments"; got != want {
+		t.Errorf("Expected owner '%s', got '%s'", want, got)
+	}
+	if got, want := r.Labels["tier"], "1"; got != want {
+		t.Errorf("Expected label '%s', got '%s'", want, got)
+	}
+	if got, want := len(r.Tags), 2; got != want {
+		t.Errorf("Expected %d tags, got %d", want, got)
+	}
+	if !r.OwnedBy(nil) || !r.OwnedBy([]string{"team-payments"}) || r.OwnedBy([]string{"team-web"}) {
+		t.Errorf("Unexpected ownership of %+v", r)
+	}
+
+	if got, want := len(c.WithTags("web").Checkers), 3; got != want {
+		t.Errorf("Expected %d checkers, got %d", want, got)
+	}
+	if got, want := len(c.WithTags("nope").Checkers), 0; got != want {
+		t.Errorf("Expected %d checkers, got %d", want, got)
+	}
+
+	// Checkers are named whatever the JSON key of their name
+	jsonBytes = []byte(`{"checkers":[` +
+		`{"type":"exec","name":"E","command":"true"},` +
+		`{"type":"file","endpoint_name":"F","path":"checkup.go","depends_on":["E"],"tags":["db"]}]}`)
+	c = Checkup{}
+	if err := json.Unmarshal(jsonBytes, &c); err != nil {
+		t.Fatalf("Error unmarshaling: %v", err)
+	}
+	if got, want := len(c.WithTags("db").Checkers), 2; got != want {
+		t.Errorf("Expected %d checkers, got %d", want, got)
+	}
+}
+
 var errTest = errors.New("i'm an error")
 
 type fake struct {
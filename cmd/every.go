@@ -72,5 +72,5 @@ Examples:
 
 func init() {
 	RootCmd.AddCommand(everyCmd)
-
+	everyCmd.Flags().StringSliceVar(&tags, "tag", nil, "Only perform checks with any of these tags")
 }
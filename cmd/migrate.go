@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sourcegraph/checkup"
+)
+
+var migrateFrom string
+var migrateTo string
+var migrateResume bool
+var migrateDryRun bool
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Copy check files from a storage to another",
+	Long: `The migrate subcommand copies all check files from the
+storage of a config file to the storage of another,
+oldest first, preserving their names and timestamps.
+For example, to move the history kept in GitHub storage
+into SQL storage.
+
+Use --resume to skip the check files that are already
+in the destination, so that an interrupted migration
+can be resumed, and --dry-run to list the check files
+that would be copied without copying them.
+
+Examples:
+
+  $ checkup migrate --from github.json --to sql.json
+  $ checkup migrate --from github.json --to sql.json --resume`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 0 || migrateFrom == "" || migrateTo == "" {
+			fmt.Println(cmd.Long)
+			os.Exit(1)
+		}
+
+		from := loadCheckupFile(migrateFrom).Storage
+		reader, ok := from.(checkup.StorageReader)
+		if !ok {
+			log.Fatalf("%s: storage can't be read", migrateFrom)
+		}
+		to := loadCheckupFile(migrateTo).Storage
+		writer, ok := to.(checkup.StorageWriter)
+		if !ok {
+			log.Fatalf("%s: storage can't store check files", migrateTo)
+		}
+
+		var copied, skipped int
+		m := checkup.Migration{
+			From:   reader,
+			To:     writer,
+			Resume: migrateResume,
+			DryRun: migrateDryRun,
+			Progress: func(done, total int, name string, skip bool) {
+				action := "copied"
+				switch {
+				case skip:
+					action = "skipped"
+					skipped++
+				case migrateDryRun:
+					action = "would copy"
+					copied++
+				default:
+					copied++
+				}
+				fmt.Printf("[%d/%d] %s %s\n", done, total, action, name)
+			},
+		}
+		if err := m.Run(); err != nil {
+			log.Fatal(err)
+		}
+		if migrateDryRun {
+			fmt.Printf("%d check files would be copied, %d skipped\n", copied, skipped)
+			return
+		}
+		fmt.Printf("%d check files copied, %d skipped\n", copied, skipped)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(migrateCmd)
+	migrateCmd.Flags().StringVar(&migrateFrom, "from", "", "JSON config file of the source storage")
+	migrateCmd.Flags().StringVar(&migrateTo, "to", "", "JSON config file of the destination storage")
+	migrateCmd.Flags().BoolVar(&migrateResume, "resume", false, "Skip check files already in the destination")
+	migrateCmd.Flags().BoolVar(&migrateDryRun, "dry-run", false, "List check files without copying them")
+}
@@ -14,6 +14,7 @@ import (
 var configFile string
 var storeResults bool
 var printLogs bool
+var tags []string
 
 // RootCmd represents the base command when called without any subcommands
 var RootCmd = &cobra.Command{
@@ -29,7 +30,9 @@ You can specify a different file location using the
 
 Running checkup without any arguments will invoke
 a single checkup and print results to stdout. To
-store the results of the check, use --store.`,
+store the results of the check, use --store. To
+only perform the checks of checkers with a tag,
+use --tag.`,
 
 	Run: func(cmd *cobra.Command, args []string) {
 		if printLogs {
@@ -72,7 +75,17 @@ store the results of the check, use --store.`,
 }
 
 func loadCheckup() checkup.Checkup {
-	configBytes, err := ioutil.ReadFile(configFile)
+	c := loadCheckupFile(configFile)
+
+	if len(tags) > 0 {
+		c = c.WithTags(tags...)
+	}
+
+	return c
+}
+
+func loadCheckupFile(file string) checkup.Checkup {
+	configBytes, err := ioutil.ReadFile(file)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -97,6 +110,7 @@ func Execute() {
 
 func init() {
 	RootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "checkup.json", "JSON config file")
+	RootCmd.Flags().StringSliceVar(&tags, "tag", nil, "Only perform checks with any of these tags")
 	RootCmd.Flags().BoolVar(&storeResults, "store", false, "Store results")
 	RootCmd.Flags().BoolVar(&printLogs, "v", false, "Enable logging to standard output")
 }
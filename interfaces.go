@@ -1,15 +1,27 @@
 package checkup
 
 import (
+	"time"
+
 	"github.com/sourcegraph/checkup/types"
 )
 
-// Checker can create a types.Result.
+// Checker can create a types.Result. Checkers that others
+// can depend on or select by tag also have the methods
+// EndpointName() string and Options() types.CheckerOptions.
 type Checker interface {
 	Type() string
 	Check() (types.Result, error)
 }
 
+// MultiChecker is a Checker that can create more than
+// one types.Result per check, such as a group of checkers
+// that also reports the results of its members.
+type MultiChecker interface {
+	Checker
+	CheckAll() ([]types.Result, error)
+}
+
 // Storage can store results.
 type Storage interface {
 	Type() string
@@ -25,6 +37,34 @@ type StorageReader interface {
 	GetIndex() (map[string]int64, error)
 }
 
+// StorageWriter can store results as a check file with a
+// given name and timestamp, rather than a new check file
+// named after the current time. It is used to copy check
+// files between storages.
+type StorageWriter interface {
+	StoreAs(name string, timestamp int64, results []types.Result) error
+}
+
+// StorageQuerier is a StorageReader that can also query
+// individual results across check files.
+type StorageQuerier interface {
+	StorageReader
+	// QueryResults returns the results that match q, ordered
+	// by their timestamp.
+	QueryResults(q types.ResultQuery) ([]types.Result, error)
+}
+
+// SummaryReader is a StorageReader that can also read the
+// summaries that it keeps of the check files deleted by
+// Maintain, such as the long-term uptime of each endpoint.
+type SummaryReader interface {
+	StorageReader
+	// Summaries returns the summaries of period, which is
+	// types.PeriodHour or types.PeriodDay, that start at or
+	// after from and before to. Zero times are unbounded.
+	Summaries(period string, from, to time.Time) ([]types.Summary, error)
+}
+
 // Maintainer can maintain a store of results by
 // deleting old check files that are no longer
 // needed or performing other required tasks.
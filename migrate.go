@@ -0,0 +1,79 @@
+package checkup
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Migration copies the check files of a storage to another,
+// preserving their names and timestamps.
+type Migration struct {
+	// From is the storage to copy check files from.
+	From StorageReader
+
+	// To is the storage to copy check files to.
+	To StorageWriter
+
+	// Resume skips the check files that are already in the
+	// index of To, which must then be a StorageReader, so
+	// that an interrupted migration can be resumed.
+	Resume bool
+
+	// DryRun lists the check files that would be copied
+	// without fetching or storing them.
+	DryRun bool
+
+	// Progress, if set, is called after each check file is
+	// copied or skipped, with the number of check files
+	// processed so far and the total number of them.
+	Progress func(done, total int, name string, skipped bool)
+}
+
+// Run copies the check files of m.From to m.To, oldest
+// first. It stops at the first error.
+func (m Migration) Run() error {
+	index, err := m.From.GetIndex()
+	if err != nil {
+		return fmt.Errorf("reading source index: %v", err)
+	}
+
+	var existing map[string]int64
+	if m.Resume {
+		r, ok := m.To.(StorageReader)
+		if !ok {
+			return fmt.Errorf("can't resume: destination storage can't be read")
+		}
+		existing, err = r.GetIndex()
+		if err != nil {
+			return fmt.Errorf("reading destination index: %v", err)
+		}
+	}
+
+	names := make([]string, 0, len(index))
+	for name := range index {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if index[names[i]] != index[names[j]] {
+			return index[names[i]] < index[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	for i, name := range names {
+		_, skip := existing[name]
+		if !skip && !m.DryRun {
+			results, err := m.From.Fetch(name)
+			if err != nil {
+				return fmt.Errorf("fetching %s: %v", name, err)
+			}
+			if err := m.To.StoreAs(name, index[name], results); err != nil {
+				return fmt.Errorf("storing %s: %v", name, err)
+			}
+		}
+		if m.Progress != nil {
+			m.Progress(i+1, len(names), name, skip)
+		}
+	}
+	return nil
+}
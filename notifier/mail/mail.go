@@ -31,6 +31,11 @@ type Notifier struct {
 		Username string `json:"username,omitempty"`
 		Password string `json:"password,omitempty"`
 	} `json:"smtp"`
+
+	// Owners limits notifications to the results of
+	// endpoints with one of these owners. If empty, all
+	// results are notified about.
+	Owners []string `json:"owners,omitempty"`
 }
 
 // New creates a new Notifier instance based on json config
@@ -56,7 +61,7 @@ func (Notifier) Type() string {
 func (m Notifier) Notify(results []types.Result) error {
 	issues := []types.Result{}
 	for _, result := range results {
-		if !result.Healthy {
+		if !result.Healthy && !result.Suppressed && result.OwnedBy(m.Owners) {
 			issues = append(issues, result)
 		}
 	}
@@ -18,6 +18,11 @@ type Notifier struct {
 	Username string `json:"username"`
 	Channel  string `json:"channel"`
 	Webhook  string `json:"webhook"`
+
+	// Owners limits notifications to the results of
+	// endpoints with one of these owners. If empty, all
+	// results are notified about.
+	Owners []string `json:"owners,omitempty"`
 }
 
 // New creates a new Notifier instance based on json config
@@ -36,7 +41,7 @@ func (Notifier) Type() string {
 func (s Notifier) Notify(results []types.Result) error {
 	errs := make(types.Errors, 0)
 	for _, result := range results {
-		if !result.Healthy {
+		if !result.Healthy && !result.Suppressed && result.OwnedBy(s.Owners) {
 			if err := s.Send(result); err != nil {
 				errs = append(errs, err)
 			}
@@ -51,6 +56,9 @@ func (s Notifier) Send(result types.Result) error {
 	attach := slack.Attachment{}
 	attach.AddField(slack.Field{Title: result.Title, Value: result.Endpoint})
 	attach.AddField(slack.Field{Title: "Status", Value: strings.ToUpper(fmt.Sprint(result.Status()))})
+	if result.Owner != "" {
+		attach.AddField(slack.Field{Title: "Owner", Value: result.Owner})
+	}
 	attach.Color = &color
 	payload := slack.Payload{
 		Text:        result.Title,
@@ -125,6 +125,9 @@ checkup.orderedResults = [];
 // Stores the charts (keyed by endpoint) and all their data/info/elements
 checkup.charts = {};
 
+// Stores the elements that group charts, keyed by group
+checkup.groupElems = {};
+
 // ID counter for the charts, always incremented
 checkup.chartCounter = 0;
 
@@ -148,10 +151,11 @@ checkup.lastResultTs = null;
 // check file name).
 checkup.lastCheckTs = null;
 
-checkup.makeChart = function(title) {
+checkup.makeChart = function(title, group) {
 	var chart = {
 		id: "chart"+(checkup.chartCounter++),
 		title: title,
+		group: group,
 		results: [],
 		series: {
 			min: [],
@@ -170,14 +174,30 @@ checkup.makeChart = function(title) {
 	return chart;
 }
 
-// getJSON downloads the file at url and executes callback
-// with the parsed JSON and the url as arguments.
+// getJSON gets the JSON document at url and executes callback
+// with the parsed JSON and the url as arguments. Check files compressed
+// with gzip are decompressed by the browser if they are served with
+// a Content-Encoding header (as S3 storage does); otherwise they
+// are decompressed here, if the browser supports it.
 checkup.getJSON = function(url, callback) {
 	var request = new XMLHttpRequest();
 	request.open('GET', url, true);
+	request.responseType = 'arraybuffer';
 	request.onload = function() {
 		if (request.status >= 200 && request.status < 400) {
-			var json = JSON.parse(request.responseText);
+			var bytes = new Uint8Array(request.response);
+			if (bytes.length > 1 && bytes[0] == 0x1f && bytes[1] == 0x8b) {
+				if (typeof DecompressionStream === 'undefined') {
+					console.error("GET "+url+": can't decompress gzip in this browser");
+					return;
+				}
+				var stream = new Blob([bytes]).stream().pipeThrough(new DecompressionStream('gzip'));
+				new Response(stream).text().then(function(text) {
+					callback(JSON.parse(text), url);
+				});
+				return;
+			}
+			var json = JSON.parse(new TextDecoder().decode(bytes));
 			callback(json, url);
 		} else {
 			console.error("GET "+url+":", request);
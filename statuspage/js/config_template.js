@@ -16,6 +16,9 @@ checkup.config = {
 		"SecretAccessKey": "<not-so-secret key here>",
 		"Region": "<bucket region name here if you specified one>",
 		"BucketName": "<bucket name here>",
+		// Optional: URL of an S3-compatible service and key prefix
+		// "Endpoint": "https://minio.example.com",
+		// "Prefix": "checks/",
 
 		// Local file system (Caddy recommended: https://caddyserver.com)
 		"url": "http://127.0.0.1:2015/"
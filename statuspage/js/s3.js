@@ -18,7 +18,7 @@ requestUri:"/{Bucket}?versioning"},input:{type:"structure",required:["Bucket","V
 var checkup = checkup || {};
 
 checkup.storage = (function() {
-	var bucket, bucketName, region;
+	var bucket, bucketName, region, endpoint, prefix = "";
 
 	// getCheckFileList gets the list of check files within
 	// the given timeframe (as a unit of nanoseconds) to
@@ -29,6 +29,7 @@ checkup.storage = (function() {
 
 		function getObjectsAfter(marker) {
 			bucket.listObjects({
+				Prefix: prefix,
 				Marker: marker
 			}, function(err, data) {
 				allObjects = allObjects.concat(data.Contents);
@@ -46,19 +47,27 @@ checkup.storage = (function() {
 			});
 		}
 
-		getObjectsAfter("" + (time.Now() - timeframe))
+		getObjectsAfter(prefix + (time.Now() - timeframe))
 	};
 
 	// setup prepares this storage unit to operate.
 	this.setup = function(cfg) {
 		AWS.config.update({accessKeyId: cfg.AccessKeyID, secretAccessKey: cfg.SecretAccessKey, region: cfg.Region})
-		bucket = new AWS.S3({
+		var opts = {
 			params: {
 				Bucket: cfg.BucketName,
 			}
-		});
+		};
+		if (cfg.Endpoint) {
+			// S3-compatible services
+			opts.endpoint = cfg.Endpoint;
+			opts.s3ForcePathStyle = true;
+		}
+		bucket = new AWS.S3(opts);
 		bucketName = cfg.BucketName;
 		region = cfg.Region;
+		endpoint = cfg.Endpoint;
+		prefix = cfg.Prefix || "";
 	};
 
 	// getChecksWithin gets all the checks within timeframe as a unit
@@ -71,7 +80,9 @@ checkup.storage = (function() {
 			} else {
 				for (var i = 0; i < list.length; i++) {
 					var url;
-					if (region && region !== "" && region !== "us-east-1") {
+					if (endpoint) {
+						url = endpoint.replace(/\/$/, "")+"/"+bucketName+"/"+list[i];
+					} else if (region && region !== "" && region !== "us-east-1") {
 						url = "https://s3-"+region+".amazonaws.com/"+bucketName+"/"+list[i];
 					} else {
 						url = "https://s3.amazonaws.com/"+bucketName+"/"+list[i];
@@ -85,7 +96,7 @@ checkup.storage = (function() {
 							if (checksLoaded >= list.length && (typeof doneCallback === 'function'))
 								doneCallback(checksLoaded, resultsLoaded);
 						};
-					}(list[i]));
+					}(list[i].substr(prefix.length)));
 				}
 			}
 		});
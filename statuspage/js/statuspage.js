@@ -69,7 +69,7 @@ function processNewCheckFile(json, filename) {
 		else
 			checkup.results[result.endpoint].push(result);
 
-		var chart = checkup.charts[result.endpoint] || checkup.makeChart(result.title);
+		var chart = checkup.charts[result.endpoint] || checkup.makeChart(result.title, result.group);
 		chart.results.push(result);
 
 		var ts = checkup.unixNanoToD3Timestamp(result.timestamp);
@@ -413,8 +413,25 @@ function renderChart(chart) {
 	el3.className = "chart";
 	el.appendChild(el3);
 
-	// Inject elements into DOM
-	document.getElementById('chart-grid').appendChild(el);
+	// Inject elements into DOM, grouping charts by the group
+	// of their endpoint, if any
+	var parent = document.getElementById('chart-grid');
+	if (chart.group) {
+		var groupElem = checkup.groupElems[chart.group];
+		if (!groupElem) {
+			groupElem = document.createElement('div');
+			groupElem.className = "chart-group";
+			groupElem.setAttribute("data-group", chart.group);
+			var groupTitle = document.createElement('div');
+			groupTitle.className = "chart-group-title";
+			groupTitle.appendChild(document.createTextNode(chart.group));
+			groupElem.appendChild(groupTitle);
+			parent.appendChild(groupElem);
+			checkup.groupElems[chart.group] = groupElem;
+		}
+		parent = groupElem;
+	}
+	parent.appendChild(el);
 
 	// Save it with the chart and use D3 to set up its svg element.
 	chart.elem = el3;
@@ -4,8 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/sourcegraph/checkup/storage/bolt"
 	"github.com/sourcegraph/checkup/storage/fs"
 	"github.com/sourcegraph/checkup/storage/github"
+	"github.com/sourcegraph/checkup/storage/influxdb"
+	"github.com/sourcegraph/checkup/storage/multi"
+	"github.com/sourcegraph/checkup/storage/prometheus"
 	"github.com/sourcegraph/checkup/storage/s3"
 	"github.com/sourcegraph/checkup/storage/sql"
 )
@@ -18,6 +22,16 @@ func storageDecode(typeName string, config json.RawMessage) (Storage, error) {
 		return github.New(config)
 	case fs.Type:
 		return fs.New(config)
+	case bolt.Type:
+		return bolt.New(config)
+	case influxdb.Type:
+		return influxdb.New(config)
+	case multi.Type:
+		return multi.New(config, func(typeName string, config json.RawMessage) (multi.Member, error) {
+			return storageDecode(typeName, config)
+		})
+	case prometheus.Type:
+		return prometheus.New(config)
 	case sql.Type:
 		return sql.New(config)
 	default:
@@ -0,0 +1,325 @@
+package bolt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"github.com/sourcegraph/checkup/storage/fs"
+	"github.com/sourcegraph/checkup/storage/rollup"
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Storage is a way to store checkup results in an embedded
+// bbolt database file.
+type Storage struct {
+	// File is the path to the database file, which is
+	// created if it does not exist.
+	File string `json:"file"`
+
+	// Timeout is how long to wait for the lock on File,
+	// which is held by a single process at a time while
+	// results are read or written. Default is 10 seconds.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// Check files older than CheckExpiry will be
+	// deleted on calls to Maintain(). If this is
+	// the zero value, no old check files will be
+	// deleted.
+	CheckExpiry time.Duration `json:"check_expiry,omitempty"`
+
+	// Rollup, if set, makes Maintain() summarize checks
+	// per endpoint, hour and day before deleting them.
+	Rollup *types.Rollup `json:"rollup,omitempty"`
+}
+
+// New creates a new Storage instance based on json config
+func New(config json.RawMessage) (Storage, error) {
+	var storage Storage
+	err := json.Unmarshal(config, &storage)
+	return storage, err
+}
+
+// Type returns the storage driver package name
+func (Storage) Type() string {
+	return Type
+}
+
+// open opens the database file and makes sure its buckets
+// exist. The database is only kept open for the duration
+// of each call, so that other processes can use the file
+// in between.
+func (b Storage) open() (*bbolt.DB, error) {
+	if b.File == "" {
+		return nil, errors.New("missing database file")
+	}
+	timeout := b.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	db, err := bbolt.Open(b.File, 0644, &bbolt.Options{Timeout: timeout})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		for _, bucket := range [][]byte{checksBucket, namesBucket, summariesBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// key returns the key of the check with given name and
+// timestamp in checksBucket.
+func key(name string, timestamp int64) []byte {
+	k := make([]byte, 8, 8+len(name))
+	binary.BigEndian.PutUint64(k, uint64(timestamp))
+	return append(k, name...)
+}
+
+// timestamp returns the timestamp encoded in key k.
+func timestamp(k []byte) int64 {
+	return int64(binary.BigEndian.Uint64(k[:8]))
+}
+
+// Store stores results in the database.
+func (b Storage) Store(results []types.Result) error {
+	ts := types.Timestamp()
+	return b.StoreAs(fmt.Sprintf(fs.FilenameFormatString, ts), ts, results)
+}
+
+// StoreAs stores results as the check with the given name
+// and timestamp, replacing any check with the same name.
+func (b Storage) StoreAs(name string, timestamp int64, results []types.Result) error {
+	db, err := b.open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	contents, err := json.Marshal(results)
+	if err != nil {
+		return err
+	}
+	k := key(name, timestamp)
+
+	return db.Update(func(tx *bbolt.Tx) error {
+		checks, names := tx.Bucket(checksBucket), tx.Bucket(namesBucket)
+		if old := names.Get([]byte(name)); old != nil {
+			if err := checks.Delete(old); err != nil {
+				return err
+			}
+		}
+		if err := checks.Put(k, contents); err != nil {
+			return err
+		}
+		return names.Put([]byte(name), k)
+	})
+}
+
+// GetIndex returns the list of check results for the database.
+func (b Storage) GetIndex() (map[string]int64, error) {
+	db, err := b.open()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	index := make(map[string]int64)
+	err = db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(checksBucket).ForEach(func(k, _ []byte) error {
+			index[string(k[8:])] = timestamp(k)
+			return nil
+		})
+	})
+	return index, err
+}
+
+// Fetch fetches results of the check with given name.
+func (b Storage) Fetch(name string) ([]types.Result, error) {
+	db, err := b.open()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var results []types.Result
+	err = db.View(func(tx *bbolt.Tx) error {
+		k := tx.Bucket(namesBucket).Get([]byte(name))
+		if k == nil {
+			return fmt.Errorf("check '%s' not found", name)
+		}
+		return json.Unmarshal(tx.Bucket(checksBucket).Get(k), &results)
+	})
+	return results, err
+}
+
+// QueryResults returns the results that match q, ordered by
+// the timestamp of their check. Only the checks between
+// q.From and q.To are read.
+func (b Storage) QueryResults(q types.ResultQuery) ([]types.Result, error) {
+	db, err := b.open()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var from, to []byte
+	if !q.From.IsZero() {
+		from = key("", q.From.UnixNano())
+	}
+	if !q.To.IsZero() {
+		to = key("", q.To.UnixNano())
+	}
+
+	var matches []types.Result
+	err = db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(checksBucket).Cursor()
+		k, v := c.First()
+		if from != nil {
+			k, v = c.Seek(from)
+		}
+		for ; k != nil && (to == nil || bytes.Compare(k, to) < 0); k, v = c.Next() {
+			var results []types.Result
+			if err := json.Unmarshal(v, &results); err != nil {
+				return err
+			}
+			for _, result := range results {
+				if !matchResult(q, result) {
+					continue
+				}
+				matches = append(matches, result)
+				if q.Limit > 0 && len(matches) == q.Limit {
+					return nil
+				}
+			}
+		}
+		return nil
+	})
+	return matches, err
+}
+
+// matchResult returns whether result matches q, regardless
+// of the time range of q.
+func matchResult(q types.ResultQuery, result types.Result) bool {
+	return (q.Title == "" || q.Title == result.Title) &&
+		(q.Endpoint == "" || q.Endpoint == result.Endpoint) &&
+		(q.Status == "" || q.Status == result.Status())
+}
+
+// Maintain deletes checks that are older than b.CheckExpiry.
+// If b.Rollup is set, they are summarized before being deleted,
+// and expired summaries are deleted.
+func (b Storage) Maintain() error {
+	if b.CheckExpiry == 0 {
+		return nil
+	}
+
+	// The summaries are read and stored before the database
+	// is opened here, as it can only be opened once at a time.
+	cutoff := time.Now().Add(-1 * b.CheckExpiry)
+	if b.Rollup != nil {
+		index, err := b.GetIndex()
+		if err != nil {
+			return err
+		}
+		cutoff = rollup.Cutoff(b.CheckExpiry)
+		if err := rollup.Roll(index, cutoff, b.Fetch, b.Summaries, b.storeSummaries); err != nil {
+			return err
+		}
+	}
+
+	db, err := b.open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	expired := key("", cutoff.UnixNano())
+	return db.Update(func(tx *bbolt.Tx) error {
+		names := tx.Bucket(namesBucket)
+		c := tx.Bucket(checksBucket).Cursor()
+		for k, _ := c.First(); k != nil && bytes.Compare(k, expired) < 0; k, _ = c.First() {
+			if err := names.Delete(k[8:]); err != nil {
+				return err
+			}
+			if err := c.Delete(); err != nil {
+				return err
+			}
+		}
+		if b.Rollup == nil {
+			return nil
+		}
+		summaries := tx.Bucket(summariesBucket)
+		var expiredSummaries [][]byte
+		summaries.ForEach(func(k, _ []byte) error {
+			if rollup.Expired(*b.Rollup, string(k[8:]), time.Unix(0, timestamp(k)).UTC()) {
+				expiredSummaries = append(expiredSummaries, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		for _, k := range expiredSummaries {
+			if err := summaries.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// storeSummaries replaces the summaries of period for the
+// UTC day that starts at day.
+func (b Storage) storeSummaries(period string, day time.Time, summaries []types.Summary) error {
+	db, err := b.open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	contents, err := json.Marshal(summaries)
+	if err != nil {
+		return err
+	}
+	return db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(summariesBucket).Put(key(period, day.UnixNano()), contents)
+	})
+}
+
+// Summaries returns the summaries of period that start at
+// or after from and before to, which are unbounded if zero.
+func (b Storage) Summaries(period string, from, to time.Time) ([]types.Summary, error) {
+	db, err := b.open()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var summaries []types.Summary
+	err = db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(summariesBucket).ForEach(func(k, v []byte) error {
+			day := time.Unix(0, timestamp(k)).UTC()
+			if string(k[8:]) != period || !rollup.InRange(day, from, to) {
+				return nil
+			}
+			var s []types.Summary
+			if err := json.Unmarshal(v, &s); err != nil {
+				return err
+			}
+			summaries = append(summaries, rollup.Filter(s, from, to)...)
+			return nil
+		})
+	})
+	return summaries, err
+}
@@ -0,0 +1,225 @@
+package bolt
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/checkup/storage/fs"
+	"github.com/sourcegraph/checkup/types"
+)
+
+func TestStorage(t *testing.T) {
+	results := []types.Result{{Title: "Testing"}}
+
+	dir, err := ioutil.TempDir("", "checkup")
+	if err != nil {
+		t.Fatalf("Cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	specimen := Storage{
+		File: filepath.Join(dir, "checkup.db"),
+	}
+
+	if err := specimen.Store(results); err != nil {
+		t.Fatalf("Expected no error from Store(), got: %v", err)
+	}
+
+	index, err := specimen.GetIndex()
+	if err != nil {
+		t.Fatalf("Cannot read index: %v", err)
+	}
+	if len(index) != 1 {
+		t.Fatalf("Expected length of index to be 1, but got %v", len(index))
+	}
+
+	var (
+		name string
+		nsec int64
+	)
+	for name, nsec = range index {
+	}
+
+	// Make sure index has timestamp of check
+	ts := time.Unix(0, nsec)
+	if time.Since(ts) > 1*time.Second {
+		t.Errorf("Timestamp of check is %s but expected something very recent", ts)
+	}
+
+	// Make sure stored data are correct
+	fetched, err := specimen.Fetch(name)
+	if err != nil {
+		t.Fatalf("Could not fetch data, got: %v", err)
+	}
+	if len(fetched) != 1 || fetched[0].Title != results[0].Title {
+		t.Fatalf("Expected fetched results to be %+v, got %+v", results, fetched)
+	}
+	if _, err := specimen.Fetch("missing-check.json"); err == nil {
+		t.Errorf("Expected an error fetching a missing check")
+	}
+
+	// Make sure the check is not deleted after maintain with CheckExpiry == 0
+	if err := specimen.Maintain(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := specimen.Fetch(name); err != nil {
+		t.Fatalf("Expected the check to be present, got: %v", err)
+	}
+
+	// Make sure the check is not deleted after maintain with CheckExpiry == 1 day
+	specimen.CheckExpiry = 24 * time.Hour
+	if err := specimen.Maintain(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := specimen.Fetch(name); err != nil {
+		t.Fatalf("Expected the check to be present, got: %v", err)
+	}
+
+	// Make sure the check is deleted after maintain with CheckExpiry > 0
+	specimen.CheckExpiry = 1 * time.Nanosecond
+	if err := specimen.Maintain(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := specimen.Fetch(name); err == nil {
+		t.Fatalf("Expected not to be able to fetch the deleted check")
+	}
+	index, err = specimen.GetIndex()
+	if err != nil {
+		t.Fatalf("Cannot read index: %v", err)
+	}
+	if len(index) != 0 {
+		t.Errorf("Expected index to be empty after maintain, got %v", index)
+	}
+}
+
+func TestStorageQuery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkup")
+	if err != nil {
+		t.Fatalf("Cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	specimen := Storage{
+		File: filepath.Join(dir, "checkup.db"),
+	}
+
+	var middle time.Time
+	for i, results := range [][]types.Result{
+		{{Title: "A", Healthy: true}, {Title: "B", Down: true}},
+		{{Title: "A", Degraded: true}, {Title: "B", Healthy: true}},
+	} {
+		if i == 1 {
+			middle = time.Now()
+		}
+		if err := specimen.Store(results); err != nil {
+			t.Fatalf("Expected no error from Store(), got: %v", err)
+		}
+	}
+
+	for i, test := range []struct {
+		query  types.ResultQuery
+		expect []string
+	}{
+		{types.ResultQuery{}, []string{"A", "B", "A", "B"}},
+		{types.ResultQuery{Title: "B"}, []string{"B", "B"}},
+		{types.ResultQuery{Status: types.StatusDown}, []string{"B"}},
+		{types.ResultQuery{From: middle}, []string{"A", "B"}},
+		{types.ResultQuery{To: middle, Status: types.StatusHealthy}, []string{"A"}},
+		{types.ResultQuery{Limit: 3}, []string{"A", "B", "A"}},
+	} {
+		results, err := specimen.QueryResults(test.query)
+		if err != nil {
+			t.Fatalf("Test %d: expected no error, got %v", i, err)
+		}
+		if len(results) != len(test.expect) {
+			t.Fatalf("Test %d: expected %d results, got %d", i, len(test.expect), len(results))
+		}
+		for j, result := range results {
+			if result.Title != test.expect[j] {
+				t.Errorf("Test %d: expected result %d to be %s, got %s", i, j, test.expect[j], result.Title)
+			}
+		}
+	}
+}
+
+func TestStorageRollup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkup")
+	if err != nil {
+		t.Fatalf("Cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	specimen := Storage{
+		File:        filepath.Join(dir, "checkup.db"),
+		CheckExpiry: 24 * time.Hour,
+		Rollup:      &types.Rollup{HourlyExpiry: 30 * 24 * time.Hour},
+	}
+
+	store := func(i int, ts time.Time) {
+		results := []types.Result{{Title: "Testing", Timestamp: ts.UnixNano(), Healthy: i%2 == 0, Down: i%2 == 1}}
+		if err := specimen.StoreAs(fmt.Sprintf(fs.FilenameFormatString, ts.UnixNano()), ts.UnixNano(), results); err != nil {
+			t.Fatalf("Expected no error from StoreAs(), got: %v", err)
+		}
+	}
+	today := types.PeriodStart(types.PeriodDay, time.Now())
+	for i, ts := range []time.Time{
+		today.AddDate(0, 0, -3).Add(time.Hour),
+		today.AddDate(0, 0, -3).Add(2 * time.Hour),
+		today.AddDate(0, 0, -60),
+		today,
+	} {
+		store(i, ts)
+	}
+
+	if err := specimen.Maintain(); err != nil {
+		t.Fatalf("Expected no error from Maintain(), got: %v", err)
+	}
+
+	index, err := specimen.GetIndex()
+	if err != nil {
+		t.Fatalf("Cannot read index: %v", err)
+	}
+	if len(index) != 1 {
+		t.Errorf("Expected only the recent check to be kept, got %v", index)
+	}
+
+	// Hourly summaries expire, daily summaries are kept
+	hourly, err := specimen.Summaries(types.PeriodHour, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Expected no error from Summaries(), got: %v", err)
+	}
+	if len(hourly) != 2 || hourly[0].Uptime != 100 || hourly[1].Uptime != 0 {
+		t.Fatalf("Expected 2 hourly summaries, got %+v", hourly)
+	}
+	daily, err := specimen.Summaries(types.PeriodDay, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Expected no error from Summaries(), got: %v", err)
+	}
+	if len(daily) != 2 || daily[1].Results != 2 || daily[1].Uptime != 50 {
+		t.Errorf("Expected 2 daily summaries, got %+v", daily)
+	}
+	hourly, err = specimen.Summaries(types.PeriodHour, today.AddDate(0, 0, -3).Add(90*time.Minute), time.Time{})
+	if err != nil {
+		t.Fatalf("Expected no error from Summaries(), got: %v", err)
+	}
+	if len(hourly) != 1 {
+		t.Errorf("Expected 1 hourly summary in range, got %+v", hourly)
+	}
+
+	// Checks added to a rolled up day are added to its summaries
+	store(0, today.AddDate(0, 0, -3).Add(3*time.Hour))
+	if err := specimen.Maintain(); err != nil {
+		t.Fatalf("Expected no error from Maintain(), got: %v", err)
+	}
+	daily, err = specimen.Summaries(types.PeriodDay, today.AddDate(0, 0, -3), today)
+	if err != nil {
+		t.Fatalf("Expected no error from Summaries(), got: %v", err)
+	}
+	if len(daily) != 1 || daily[0].Results != 3 || daily[0].Healthy != 2 {
+		t.Errorf("Expected daily summary of the 3 checks of the day, got %+v", daily)
+	}
+}
@@ -0,0 +1,20 @@
+package bolt
+
+// Type should match the package name
+const Type = "bolt"
+
+var (
+	// checksBucket holds the results of each check, keyed by
+	// the big-endian timestamp of the check followed by its
+	// name, so that keys sort by time.
+	checksBucket = []byte("checks")
+
+	// namesBucket maps the name of each check to its key in
+	// checksBucket.
+	namesBucket = []byte("names")
+
+	// summariesBucket holds the summaries of each period for
+	// each UTC day, keyed by the big-endian start of the day
+	// followed by the period.
+	summariesBucket = []byte("summaries")
+)
@@ -0,0 +1,214 @@
+// Package codec compresses and encrypts the contents of
+// check files for the storages that write them as files.
+package codec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression algorithms.
+const (
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+var (
+	// encryptedMagic starts the contents of encrypted check
+	// files, followed by the nonce and the ciphertext.
+	encryptedMagic = []byte("checkup:aes-gcm:")
+
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// Codec compresses and encrypts the contents of check
+// files. The zero value leaves them as they are. Storages
+// embed it, so its fields are part of their configuration.
+type Codec struct {
+	// Compression is the algorithm used to compress check
+	// files: "gzip", "zstd" or none if empty.
+	Compression string `json:"compression,omitempty"`
+
+	// Encryption, if set, encrypts check files with
+	// AES-GCM, after compressing them.
+	Encryption *Encryption `json:"encryption,omitempty"`
+}
+
+// Encryption holds the source of the encryption key. The key
+// must be 16, 24 or 32 bytes long, for AES-128, AES-192 or
+// AES-256, encoded in hex or base64.
+type Encryption struct {
+	// KeyFile is the path to a file holding the key.
+	KeyFile string `json:"key_file,omitempty"`
+
+	// KeyEnv is the name of an environment variable holding
+	// the key. It is used if KeyFile is empty.
+	KeyEnv string `json:"key_env,omitempty"`
+}
+
+// Encode compresses and encrypts data according to c.
+func (c Codec) Encode(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	switch c.Compression {
+	case "":
+		buf.Write(data)
+	case CompressionGzip:
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	case CompressionZstd:
+		w, err := zstd.NewWriter(&buf)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown compression '%s'", c.Compression)
+	}
+
+	if c.Encryption == nil {
+		return buf.Bytes(), nil
+	}
+	aead, err := c.Encryption.aead()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	out := append(append([]byte{}, encryptedMagic...), nonce...)
+	return aead.Seal(out, nonce, buf.Bytes(), nil), nil
+}
+
+// Decode decrypts and decompresses data, detecting its
+// format regardless of the configuration of c, so that
+// check files written with other settings can still be
+// read. Decrypting requires c.Encryption, though.
+func (c Codec) Decode(data []byte) ([]byte, error) {
+	if bytes.HasPrefix(data, encryptedMagic) {
+		if c.Encryption == nil {
+			return nil, errors.New("check file is encrypted but no encryption key is configured")
+		}
+		aead, err := c.Encryption.aead()
+		if err != nil {
+			return nil, err
+		}
+		data = data[len(encryptedMagic):]
+		if len(data) < aead.NonceSize() {
+			return nil, errors.New("encrypted check file is truncated")
+		}
+		data, err = aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case bytes.HasPrefix(data, gzipMagic):
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return ioutil.ReadAll(r)
+	case bytes.HasPrefix(data, zstdMagic):
+		r, err := zstd.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return ioutil.ReadAll(r)
+	}
+	return data, nil
+}
+
+// ContentEncoding returns the value of the Content-Encoding
+// header that lets browsers decode check files written by c,
+// or an empty string if they can't.
+func (c Codec) ContentEncoding() string {
+	if c.Compression == CompressionGzip && c.Encryption == nil {
+		return "gzip"
+	}
+	return ""
+}
+
+// ContentType returns the media type of check files written
+// by c: JSON, possibly with a Content-Encoding, or else
+// opaque bytes.
+func (c Codec) ContentType() string {
+	if c.Compression != "" && c.ContentEncoding() == "" || c.Encryption != nil {
+		return "application/octet-stream"
+	}
+	return "application/json"
+}
+
+// aead returns the AES-GCM cipher with the key of e.
+func (e Encryption) aead() (cipher.AEAD, error) {
+	key, err := e.key()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// key reads and decodes the key of e.
+func (e Encryption) key() ([]byte, error) {
+	var encoded string
+	switch {
+	case e.KeyFile != "":
+		b, err := ioutil.ReadFile(e.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		encoded = string(b)
+	case e.KeyEnv != "":
+		encoded = os.Getenv(e.KeyEnv)
+		if encoded == "" {
+			return nil, fmt.Errorf("environment variable %s is not set", e.KeyEnv)
+		}
+	default:
+		return nil, errors.New("missing encryption key_file or key_env")
+	}
+
+	encoded = strings.TrimSpace(encoded)
+	key, err := hex.DecodeString(encoded)
+	if err != nil {
+		key, err = base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, errors.New("encryption key must be encoded in hex or base64")
+		}
+	}
+	switch len(key) {
+	case 16, 24, 32:
+		return key, nil
+	}
+	return nil, fmt.Errorf("encryption key must be 16, 24 or 32 bytes long, got %d", len(key))
+}
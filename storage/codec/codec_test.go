@@ -0,0 +1,101 @@
+package codec
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCodec(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkup")
+	if err != nil {
+		t.Fatalf("Cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	keyFile := filepath.Join(dir, "key")
+	err = ioutil.WriteFile(keyFile, []byte("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("CHECKUP_TEST_KEY", "AAECAwQFBgcICQoLDA0ODw==")
+	defer os.Unsetenv("CHECKUP_TEST_KEY")
+
+	data := []byte(`[{"title":"Testing"}]` + "\n")
+	for i, c := range []Codec{
+		{},
+		{Compression: CompressionGzip},
+		{Compression: CompressionZstd},
+		{Encryption: &Encryption{KeyFile: keyFile}},
+		{Compression: CompressionGzip, Encryption: &Encryption{KeyEnv: "CHECKUP_TEST_KEY"}},
+		{Compression: CompressionZstd, Encryption: &Encryption{KeyFile: keyFile}},
+	} {
+		encoded, err := c.Encode(data)
+		if err != nil {
+			t.Fatalf("Test %d: expected no error from Encode(), got %v", i, err)
+		}
+		if (c.Compression != "" || c.Encryption != nil) && bytes.Equal(encoded, data) {
+			t.Errorf("Test %d: expected data to be encoded", i)
+		}
+		if c.Encryption != nil && bytes.Contains(encoded, []byte("Testing")) {
+			t.Errorf("Test %d: expected data to be encrypted", i)
+		}
+
+		// The format is detected regardless of the compression
+		decoded, err := Codec{Encryption: c.Encryption}.Decode(encoded)
+		if err != nil {
+			t.Fatalf("Test %d: expected no error from Decode(), got %v", i, err)
+		}
+		if !bytes.Equal(decoded, data) {
+			t.Errorf("Test %d: expected %q, got %q", i, data, decoded)
+		}
+
+		if c.Encryption != nil {
+			if _, err := (Codec{}).Decode(encoded); err == nil {
+				t.Errorf("Test %d: expected an error decoding without a key", i)
+			}
+			other := &Encryption{KeyEnv: "CHECKUP_TEST_KEY"}
+			if c.Encryption.KeyEnv != "" {
+				other = &Encryption{KeyFile: keyFile}
+			}
+			if _, err := (Codec{Encryption: other}).Decode(encoded); err == nil {
+				t.Errorf("Test %d: expected an error decoding with the wrong key", i)
+			}
+		}
+	}
+
+	if got := (Codec{Compression: CompressionGzip}).ContentEncoding(); got != "gzip" {
+		t.Errorf("Expected gzip content encoding, got %q", got)
+	}
+	if got := (Codec{Compression: CompressionGzip, Encryption: &Encryption{KeyFile: keyFile}}).ContentEncoding(); got != "" {
+		t.Errorf("Expected no content encoding for encrypted data, got %q", got)
+	}
+
+	for i, test := range []struct {
+		codec Codec
+		want  string
+	}{
+		{Codec{}, "application/json"},
+		{Codec{Compression: CompressionGzip}, "application/json"},
+		{Codec{Compression: CompressionZstd}, "application/octet-stream"},
+		{Codec{Encryption: &Encryption{KeyFile: keyFile}}, "application/octet-stream"},
+		{Codec{Compression: CompressionGzip, Encryption: &Encryption{KeyFile: keyFile}}, "application/octet-stream"},
+	} {
+		if got := test.codec.ContentType(); got != test.want {
+			t.Errorf("Test %d: expected content type %q, got %q", i, test.want, got)
+		}
+	}
+
+	for i, c := range []Codec{
+		{Compression: "lzma"},
+		{Encryption: &Encryption{}},
+		{Encryption: &Encryption{KeyEnv: "CHECKUP_TEST_MISSING_KEY"}},
+		{Encryption: &Encryption{KeyFile: filepath.Join(dir, "missing")}},
+	} {
+		if _, err := c.Encode(data); err == nil {
+			t.Errorf("Test %d: expected an error from Encode()", i)
+		}
+	}
+}
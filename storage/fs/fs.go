@@ -1,12 +1,17 @@
 package fs
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"time"
 
+	"github.com/sourcegraph/checkup/storage/codec"
+	"github.com/sourcegraph/checkup/storage/rollup"
 	"github.com/sourcegraph/checkup/types"
 )
 
@@ -25,6 +30,13 @@ type Storage struct {
 	// the zero value, no old check files will be
 	// deleted.
 	CheckExpiry time.Duration `json:"check_expiry,omitempty"`
+
+	// Rollup, if set, makes Maintain() summarize check files
+	// per endpoint, hour and day before deleting them.
+	Rollup *types.Rollup `json:"rollup,omitempty"`
+
+	// Codec compresses and encrypts check files.
+	codec.Codec
 }
 
 // New creates a new Storage instance based on json config
@@ -60,45 +72,107 @@ func (fs Storage) readIndex() (map[string]int64, error) {
 }
 
 func (fs Storage) writeIndex(index map[string]int64) error {
-	f, err := os.Create(filepath.Join(fs.Dir, IndexName))
+	return writeFileAtomic(filepath.Join(fs.Dir, IndexName), func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(index)
+	})
+}
+
+// lockIndex acquires an exclusive lock on the index, so that
+// processes sharing fs.Dir don't lose each other's updates.
+// It blocks until the lock is available and returns a
+// function that releases it.
+func (fs Storage) lockIndex() (func(), error) {
+	f, err := os.OpenFile(filepath.Join(fs.Dir, LockName), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// writeFileAtomic writes the file at path with write, such
+// that readers see either the previous or the complete new
+// contents, even if the process crashes midway. It writes to
+// a temporary file in the same directory and renames it.
+func writeFileAtomic(path string, write func(w io.Writer) error) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
 	if err != nil {
 		return err
 	}
-	defer f.Close()
+	defer os.Remove(f.Name()) // no-op after the rename
 
-	return json.NewEncoder(f).Encode(index)
+	err = write(f)
+	if err == nil {
+		err = f.Sync()
+	}
+	if err == nil {
+		// temporary files are only readable by their owner
+		err = f.Chmod(0644)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
 }
 
 // Fetch fetches results from filesystem for the specified index.
 func (fs Storage) Fetch(name string) ([]types.Result, error) {
-	f, err := os.Open(filepath.Join(fs.Dir, name))
+	contents, err := ioutil.ReadFile(filepath.Join(fs.Dir, name))
 	if err != nil {
 		return nil, err
 	}
-	var results []types.Result
-	err = json.NewDecoder(f).Decode(&results)
-	f.Close()
+	contents, err = fs.Decode(contents)
 	if err != nil {
 		return nil, err
 	}
+	var results []types.Result
+	if err := json.Unmarshal(contents, &results); err != nil {
+		return nil, err
+	}
 
 	return results, nil
 }
 
 // Store stores results on filesystem according to the configuration in fs.
 func (fs Storage) Store(results []types.Result) error {
+	return fs.StoreAs(*GenerateFilename(), time.Now().UnixNano(), results)
+}
+
+// StoreAs stores results in the check file with the given
+// name and adds it to the index with the given timestamp.
+func (fs Storage) StoreAs(name string, timestamp int64, results []types.Result) error {
 	// Write results to a new file
-	name := *GenerateFilename()
-	f, err := os.Create(filepath.Join(fs.Dir, name))
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(results); err != nil {
+		return err
+	}
+	contents, err := fs.Encode(buf.Bytes())
 	if err != nil {
 		return err
 	}
-	err = json.NewEncoder(f).Encode(results)
-	f.Close()
+	err = writeFileAtomic(filepath.Join(fs.Dir, name), func(w io.Writer) error {
+		_, err := w.Write(contents)
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
+	unlock, err := fs.lockIndex()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Read current index file
 	index, err := fs.readIndex()
 	if err != nil {
@@ -106,39 +180,98 @@ func (fs Storage) Store(results []types.Result) error {
 	}
 
 	// Add new file to index
-	index[name] = time.Now().UnixNano()
+	index[name] = timestamp
 
 	// Write new index
 	return fs.writeIndex(index)
 }
 
+// RebuildIndex reconstructs the index from the check files
+// present in fs.Dir, such as after it was lost or check files
+// were copied in. The timestamps are taken from the names of
+// the check files.
+func (fs Storage) RebuildIndex() error {
+	unlock, err := fs.lockIndex()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	files, err := ioutil.ReadDir(fs.Dir)
+	if err != nil {
+		return err
+	}
+
+	index := map[string]int64{}
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		var nsec int64
+		if _, err := fmt.Sscanf(f.Name(), FilenameFormatString, &nsec); err != nil {
+			continue
+		}
+		if f.Name() != fmt.Sprintf(FilenameFormatString, nsec) {
+			continue
+		}
+		index[f.Name()] = nsec
+	}
+
+	return fs.writeIndex(index)
+}
+
 // Maintain deletes check files that are older than fs.CheckExpiry.
+// If fs.Rollup is set, they are summarized before being deleted,
+// and expired summaries are deleted.
 func (fs Storage) Maintain() error {
 	if fs.CheckExpiry == 0 {
 		return nil
 	}
 
-	files, err := ioutil.ReadDir(fs.Dir)
+	unlock, err := fs.lockIndex()
 	if err != nil {
 		return err
 	}
+	defer unlock()
 
 	index, err := fs.readIndex()
 	if err != nil {
 		return err
 	}
 
+	cutoff := time.Now().Add(-fs.CheckExpiry)
+	if fs.Rollup != nil {
+		cutoff = rollup.Cutoff(fs.CheckExpiry)
+		if err := rollup.Roll(index, cutoff, fs.Fetch, fs.Summaries, fs.storeSummaries); err != nil {
+			return err
+		}
+	}
+
+	files, err := ioutil.ReadDir(fs.Dir)
+	if err != nil {
+		return err
+	}
+
 	for _, f := range files {
 		if f.Name() == IndexName {
 			continue
 		}
 
+		if period, day, ok := rollup.ParseFilename(f.Name()); ok {
+			if fs.Rollup != nil && rollup.Expired(*fs.Rollup, period, day) {
+				if err := os.Remove(filepath.Join(fs.Dir, f.Name())); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
 		nsec, ok := index[f.Name()]
 		if !ok {
 			continue
 		}
 
-		if time.Since(time.Unix(0, nsec)) > fs.CheckExpiry {
+		if nsec < cutoff.UnixNano() {
 			if err := os.Remove(filepath.Join(fs.Dir, f.Name())); err != nil {
 				return err
 			}
@@ -148,3 +281,51 @@ func (fs Storage) Maintain() error {
 
 	return fs.writeIndex(index)
 }
+
+// storeSummaries writes the summaries of period for the
+// UTC day that starts at day to their summary file.
+func (fs Storage) storeSummaries(period string, day time.Time, summaries []types.Summary) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(summaries); err != nil {
+		return err
+	}
+	contents, err := fs.Encode(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(fs.Dir, rollup.Filename(period, day)), func(w io.Writer) error {
+		_, err := w.Write(contents)
+		return err
+	})
+}
+
+// Summaries returns the summaries of period that start at
+// or after from and before to, which are unbounded if zero.
+func (fs Storage) Summaries(period string, from, to time.Time) ([]types.Summary, error) {
+	files, err := ioutil.ReadDir(fs.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var summaries []types.Summary
+	for _, f := range files {
+		p, day, ok := rollup.ParseFilename(f.Name())
+		if !ok || p != period || !rollup.InRange(day, from, to) {
+			continue
+		}
+		contents, err := ioutil.ReadFile(filepath.Join(fs.Dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		contents, err = fs.Decode(contents)
+		if err != nil {
+			return nil, err
+		}
+		var s []types.Summary
+		if err := json.Unmarshal(contents, &s); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, rollup.Filter(s, from, to)...)
+	}
+	return summaries, nil
+}
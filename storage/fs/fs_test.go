@@ -2,12 +2,16 @@ package fs
 
 import (
 	"bytes"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/sourcegraph/checkup/storage/codec"
 	"github.com/sourcegraph/checkup/types"
 )
 
@@ -106,3 +110,212 @@ func TestStorage(t *testing.T) {
 		t.Fatalf("Expected checkfile to be deleted, but Stat() returned error: %v", err)
 	}
 }
+
+func TestStorageConcurrent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkup")
+	if err != nil {
+		t.Fatalf("Cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	specimen := Storage{
+		Dir: dir,
+	}
+
+	const n = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- specimen.Store([]types.Result{{Title: "Testing"}})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Expected no error from Store(), got: %v", err)
+		}
+	}
+
+	index, err := specimen.GetIndex()
+	if err != nil {
+		t.Fatalf("Cannot read index: %v", err)
+	}
+	if len(index) != n {
+		t.Fatalf("Expected length of index to be %d, but got %d", n, len(index))
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Cannot read directory: %v", err)
+	}
+	for _, f := range files {
+		if strings.HasPrefix(f.Name(), ".") {
+			t.Errorf("Expected no temporary files to be left, found %s", f.Name())
+		}
+	}
+
+	// Rebuild the index from the check files
+	if err := os.Remove(filepath.Join(dir, IndexName)); err != nil {
+		t.Fatalf("Cannot remove index: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "notes.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatalf("Cannot write file: %v", err)
+	}
+	if err := specimen.RebuildIndex(); err != nil {
+		t.Fatalf("Expected no error from RebuildIndex(), got: %v", err)
+	}
+	rebuilt, err := specimen.GetIndex()
+	if err != nil {
+		t.Fatalf("Cannot read index: %v", err)
+	}
+	if len(rebuilt) != n {
+		t.Fatalf("Expected length of rebuilt index to be %d, but got %d", n, len(rebuilt))
+	}
+	for name := range index {
+		nsec, ok := rebuilt[name]
+		if !ok {
+			t.Errorf("Expected %s in rebuilt index", name)
+		}
+		if time.Since(time.Unix(0, nsec)) > 1*time.Minute {
+			t.Errorf("Expected recent timestamp for %s, got %s", name, time.Unix(0, nsec))
+		}
+	}
+}
+
+func TestStorageCodec(t *testing.T) {
+	results := []types.Result{{Title: "Testing"}}
+
+	dir, err := ioutil.TempDir("", "checkup")
+	if err != nil {
+		t.Fatalf("Cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Setenv("CHECKUP_TEST_KEY", "000102030405060708090a0b0c0d0e0f")
+	defer os.Unsetenv("CHECKUP_TEST_KEY")
+
+	plain := Storage{Dir: dir}
+	if err := plain.StoreAs("1-check.json", 1, results); err != nil {
+		t.Fatalf("Expected no error from StoreAs(), got: %v", err)
+	}
+
+	specimen := Storage{Dir: dir}
+	specimen.Compression = codec.CompressionZstd
+	specimen.Encryption = &codec.Encryption{KeyEnv: "CHECKUP_TEST_KEY"}
+	if err := specimen.StoreAs("2-check.json", 2, results); err != nil {
+		t.Fatalf("Expected no error from StoreAs(), got: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "2-check.json"))
+	if err != nil {
+		t.Fatalf("Expected no error reading check file, got: %v", err)
+	}
+	if bytes.Contains(b, []byte("Testing")) {
+		t.Errorf("Expected check file to be encrypted, got %q", b)
+	}
+
+	// Both check files can be read, whatever their format
+	for _, name := range []string{"1-check.json", "2-check.json"} {
+		fetched, err := specimen.Fetch(name)
+		if err != nil {
+			t.Fatalf("Expected no error fetching %s, got: %v", name, err)
+		}
+		if len(fetched) != 1 || fetched[0].Title != "Testing" {
+			t.Errorf("Expected stored results in %s, got %+v", name, fetched)
+		}
+	}
+	if _, err := plain.Fetch("2-check.json"); err == nil {
+		t.Errorf("Expected an error fetching an encrypted check file without a key")
+	}
+}
+
+func TestStorageRollup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkup")
+	if err != nil {
+		t.Fatalf("Cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	specimen := Storage{
+		Dir:         dir,
+		CheckExpiry: 24 * time.Hour,
+		Rollup:      &types.Rollup{HourlyExpiry: 30 * 24 * time.Hour},
+	}
+
+	today := types.PeriodStart(types.PeriodDay, time.Now())
+	for i, ts := range []time.Time{
+		today.AddDate(0, 0, -3).Add(time.Hour),
+		today.AddDate(0, 0, -3).Add(2 * time.Hour),
+		today.AddDate(0, 0, -60),
+		today,
+	} {
+		results := []types.Result{{Title: "Testing", Timestamp: ts.UnixNano(), Healthy: i%2 == 0, Down: i%2 == 1}}
+		if err := specimen.StoreAs(fmt.Sprintf(FilenameFormatString, ts.UnixNano()), ts.UnixNano(), results); err != nil {
+			t.Fatalf("Expected no error from StoreAs(), got: %v", err)
+		}
+	}
+
+	if err := specimen.Maintain(); err != nil {
+		t.Fatalf("Expected no error from Maintain(), got: %v", err)
+	}
+
+	index, err := specimen.GetIndex()
+	if err != nil {
+		t.Fatalf("Cannot read index: %v", err)
+	}
+	if len(index) != 1 {
+		t.Errorf("Expected only the recent check file to be kept, got %v", index)
+	}
+
+	hourly, err := specimen.Summaries(types.PeriodHour, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Expected no error from Summaries(), got: %v", err)
+	}
+	if len(hourly) != 2 {
+		t.Fatalf("Expected 2 hourly summaries, got %+v", hourly)
+	}
+	if hourly[0].Uptime != 100 || hourly[1].Uptime != 0 {
+		t.Errorf("Expected uptime of each hour, got %+v", hourly)
+	}
+
+	// Hourly summaries expire, daily summaries are kept
+	daily, err := specimen.Summaries(types.PeriodDay, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Expected no error from Summaries(), got: %v", err)
+	}
+	if len(daily) != 2 || daily[1].Results != 2 || daily[1].Uptime != 50 {
+		t.Errorf("Expected 2 daily summaries, got %+v", daily)
+	}
+	if err := specimen.Maintain(); err != nil {
+		t.Fatalf("Expected no error from Maintain(), got: %v", err)
+	}
+	hourly, err = specimen.Summaries(types.PeriodHour, today.AddDate(0, 0, -3).Add(90*time.Minute), time.Time{})
+	if err != nil {
+		t.Fatalf("Expected no error from Summaries(), got: %v", err)
+	}
+	if len(hourly) != 1 {
+		t.Errorf("Expected 1 hourly summary in range, got %+v", hourly)
+	}
+
+	// Check files copied into a rolled up day are added to
+	// its summaries
+	ts := today.AddDate(0, 0, -3).Add(3 * time.Hour)
+	results := []types.Result{{Title: "Testing", Timestamp: ts.UnixNano(), Healthy: true}}
+	if err := specimen.StoreAs(fmt.Sprintf(FilenameFormatString, ts.UnixNano()), ts.UnixNano(), results); err != nil {
+		t.Fatalf("Expected no error from StoreAs(), got: %v", err)
+	}
+	if err := specimen.Maintain(); err != nil {
+		t.Fatalf("Expected no error from Maintain(), got: %v", err)
+	}
+	daily, err = specimen.Summaries(types.PeriodDay, today.AddDate(0, 0, -3), today)
+	if err != nil {
+		t.Fatalf("Expected no error from Summaries(), got: %v", err)
+	}
+	if len(daily) != 1 || daily[0].Results != 3 || daily[0].Healthy != 2 {
+		t.Errorf("Expected daily summary of the 3 check files of the day, got %+v", daily)
+	}
+}
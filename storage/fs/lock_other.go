@@ -0,0 +1,19 @@
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package fs
+
+import (
+	"os"
+)
+
+// lockFile does nothing, as file locks are not supported
+// on this platform.
+func lockFile(f *os.File) error {
+	return nil
+}
+
+// unlockFile does nothing, as file locks are not supported
+// on this platform.
+func unlockFile(f *os.File) error {
+	return nil
+}
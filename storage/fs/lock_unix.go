@@ -0,0 +1,19 @@
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package fs
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile acquires an exclusive lock on f, blocking until
+// it is available.
+func lockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+}
+
+// unlockFile releases the lock on f.
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
@@ -8,6 +8,10 @@ import (
 
 const IndexName = "index.json"
 
+// LockName is the name of the file that is locked while
+// the index is updated.
+const LockName = "index.lock"
+
 // FilenameFormatString is the format string used
 // by GenerateFilename to create a filename.
 const FilenameFormatString = "%d-check.json"
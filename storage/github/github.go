@@ -13,7 +13,9 @@ import (
 	"github.com/google/go-github/github"
 	"golang.org/x/oauth2"
 
+	"github.com/sourcegraph/checkup/storage/codec"
 	"github.com/sourcegraph/checkup/storage/fs"
+	"github.com/sourcegraph/checkup/storage/rollup"
 	"github.com/sourcegraph/checkup/types"
 )
 
@@ -56,6 +58,13 @@ type Storage struct {
 	// deleted.
 	CheckExpiry time.Duration `json:"check_expiry,omitempty"`
 
+	// Rollup, if set, makes Maintain() summarize check files
+	// per endpoint, hour and day before deleting them.
+	Rollup *types.Rollup `json:"rollup,omitempty"`
+
+	// Codec compresses and encrypts check files.
+	codec.Codec
+
 	client *github.Client `json:"-"`
 }
 
@@ -238,13 +247,24 @@ func (gh *Storage) writeIndex(index map[string]int64, sha string) error {
 
 // Store stores results in the Git repo & updates the index.
 func (gh *Storage) Store(results []types.Result) error {
+	return gh.StoreAs(*fs.GenerateFilename(), time.Now().UnixNano(), results)
+}
+
+// StoreAs stores results in the check file with the given
+// name and adds it to the index with the given timestamp.
+func (gh *Storage) StoreAs(name string, timestamp int64, results []types.Result) error {
 	// Write results to a new file
-	name := *fs.GenerateFilename()
 	contents, err := json.Marshal(results)
 	if err != nil {
 		return err
 	}
-	err = gh.writeFile(name, "", contents)
+	contents, err = gh.Encode(contents)
+	if err != nil {
+		return err
+	}
+	if err := gh.writeFile(name, "", contents); err != nil {
+		return err
+	}
 
 	// Read current index file
 	index, indexSHA, err := gh.readIndex()
@@ -253,7 +273,7 @@ func (gh *Storage) Store(results []types.Result) error {
 	}
 
 	// Add new file to index
-	index[name] = time.Now().UnixNano()
+	index[name] = timestamp
 
 	// Write new index
 	return gh.writeIndex(index, indexSHA)
@@ -265,6 +285,10 @@ func (gh *Storage) Fetch(name string) ([]types.Result, error) {
 	if err != nil {
 		return nil, err
 	}
+	contents, err = gh.Decode(contents)
+	if err != nil {
+		return nil, err
+	}
 	var r []types.Result
 	err = json.Unmarshal(contents, &r)
 	return r, err
@@ -276,31 +300,51 @@ func (gh *Storage) GetIndex() (map[string]int64, error) {
 	return m, e
 }
 
+// treeEntries returns the entries of the Git tree of the
+// configured branch, recursively.
+func (gh *Storage) treeEntries() ([]github.TreeEntry, error) {
+	if err := gh.ensureClient(); err != nil {
+		return nil, err
+	}
+
+	ref, _, err := gh.client.Git.GetRef(context.Background(), gh.RepositoryOwner, gh.RepositoryName, "heads/"+gh.Branch)
+	if err != nil {
+		return nil, err
+	}
+	tree, _, err := gh.client.Git.GetTree(context.Background(), gh.RepositoryOwner, gh.RepositoryName, *ref.Object.SHA, true)
+	if err != nil {
+		return nil, err
+	}
+	return tree.Entries, nil
+}
+
 // Maintain deletes check files that are older than gh.CheckExpiry.
+// If gh.Rollup is set, they are summarized before being deleted,
+// and expired summaries are deleted.
 func (gh *Storage) Maintain() error {
 	if gh.CheckExpiry == 0 {
 		return nil
 	}
 
-	if err := gh.ensureClient(); err != nil {
-		return err
-	}
-
 	index, indexSHA, err := gh.readIndex()
 	if err != nil {
 		return err
 	}
 
-	ref, _, err := gh.client.Git.GetRef(context.Background(), gh.RepositoryOwner, gh.RepositoryName, "heads/"+gh.Branch)
-	if err != nil {
-		return err
+	cutoff := time.Now().Add(-gh.CheckExpiry)
+	if gh.Rollup != nil {
+		cutoff = rollup.Cutoff(gh.CheckExpiry)
+		if err := rollup.Roll(index, cutoff, gh.Fetch, gh.Summaries, gh.storeSummaries); err != nil {
+			return err
+		}
 	}
-	tree, _, err := gh.client.Git.GetTree(context.Background(), gh.RepositoryOwner, gh.RepositoryName, *ref.Object.SHA, true)
+
+	entries, err := gh.treeEntries()
 	if err != nil {
 		return err
 	}
 
-	for _, treeEntry := range tree.Entries {
+	for _, treeEntry := range entries {
 		fileName := treeEntry.GetPath()
 
 		if fileName == filepath.Join(gh.Dir, fs.IndexName) {
@@ -311,20 +355,86 @@ func (gh *Storage) Maintain() error {
 			continue
 		}
 
+		if period, day, ok := rollup.ParseFilename(filepath.Base(fileName)); ok {
+			if gh.Rollup != nil && rollup.Expired(*gh.Rollup, period, day) {
+				log.Printf("github: maintain: deleting %s", fileName)
+				if err = gh.deleteFile(fileName, treeEntry.GetSHA()); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
 		nsec, ok := index[filepath.Base(fileName)]
 		if !ok {
 			log.Printf("github: maintain: skipping %s because it's not in the index", fileName)
 			continue
 		}
 
-		if time.Since(time.Unix(0, nsec)) > gh.CheckExpiry {
+		if nsec < cutoff.UnixNano() {
 			log.Printf("github: maintain: deleting %s", fileName)
 			if err = gh.deleteFile(fileName, treeEntry.GetSHA()); err != nil {
 				return err
 			}
-			delete(index, fileName)
+			delete(index, filepath.Base(fileName))
 		}
 	}
 
 	return gh.writeIndex(index, indexSHA)
 }
+
+// storeSummaries writes the summaries of period for the
+// UTC day that starts at day to their summary file.
+func (gh *Storage) storeSummaries(period string, day time.Time, summaries []types.Summary) error {
+	contents, err := json.Marshal(summaries)
+	if err != nil {
+		return err
+	}
+	contents, err = gh.Encode(contents)
+	if err != nil {
+		return err
+	}
+
+	// Overwriting an existing file requires its SHA
+	name := rollup.Filename(period, day)
+	_, sha, err := gh.readFile(name)
+	if err != nil && err != errFileNotFound {
+		return err
+	}
+	return gh.writeFile(name, sha, contents)
+}
+
+// Summaries returns the summaries of period that start at
+// or after from and before to, which are unbounded if zero.
+func (gh *Storage) Summaries(period string, from, to time.Time) ([]types.Summary, error) {
+	entries, err := gh.treeEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	var summaries []types.Summary
+	for _, treeEntry := range entries {
+		fileName := treeEntry.GetPath()
+		if filepath.Dir(fileName) != filepath.Clean(gh.Dir) {
+			continue
+		}
+		p, day, ok := rollup.ParseFilename(filepath.Base(fileName))
+		if !ok || p != period || !rollup.InRange(day, from, to) {
+			continue
+		}
+		contents, _, err := gh.readFile(fileName)
+		if err != nil {
+			return nil, err
+		}
+		contents, err = gh.Decode(contents)
+		if err != nil {
+			return nil, err
+		}
+		var s []types.Summary
+		if err := json.Unmarshal(contents, &s); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, rollup.Filter(s, from, to)...)
+	}
+	return summaries, nil
+}
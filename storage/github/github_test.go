@@ -10,13 +10,17 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/go-github/github"
 
+	"github.com/sourcegraph/checkup/storage/codec"
+	"github.com/sourcegraph/checkup/storage/fs"
 	"github.com/sourcegraph/checkup/types"
 )
 
@@ -437,3 +441,227 @@ func TestGitHubWithSubdir(t *testing.T) {
 		}
 	})
 }
+
+// fakeRepo is the state of a Git repository served by
+// withFakeRepo, keyed by the path of each file.
+type fakeRepo struct {
+	sync.Mutex
+	files map[string][]byte
+}
+
+// withFakeRepo calls f with a client of a minimal GitHub API
+// that serves the files of repo, so that what is stored can
+// be read back unchanged.
+func withFakeRepo(t *testing.T, f func(client *github.Client, repo *fakeRepo)) {
+	repo := &fakeRepo{files: map[string][]byte{}}
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	url, _ := url.Parse(server.URL + "/")
+	client.BaseURL = url
+	client.UploadURL = url
+
+	mux.HandleFunc("/repos/o/r/git/refs/heads/b", func(w http.ResponseWriter, r *http.Request) {
+		mustWriteJSON(w, github.Reference{
+			Ref:    github.String("refs/heads/b"),
+			Object: &github.GitObject{Type: github.String("commit"), SHA: github.String("head")},
+		})
+	})
+
+	mux.HandleFunc("/repos/o/r/git/trees/head", func(w http.ResponseWriter, r *http.Request) {
+		repo.Lock()
+		defer repo.Unlock()
+		var entries []github.TreeEntry
+		for path, contents := range repo.files {
+			entries = append(entries, github.TreeEntry{
+				Type: github.String("blob"),
+				SHA:  github.String(sha(contents)),
+				Path: github.String(path),
+			})
+		}
+		mustWriteJSON(w, github.Tree{SHA: github.String("head"), Entries: entries})
+	})
+
+	mux.HandleFunc("/repos/o/r/contents/", func(w http.ResponseWriter, r *http.Request) {
+		repo.Lock()
+		defer repo.Unlock()
+		path := strings.TrimPrefix(r.URL.Path, "/repos/o/r/contents/")
+		contents, exists := repo.files[path]
+
+		if r.Method == "GET" {
+			if !exists {
+				http.Error(w, path+" does not exist", 404)
+				return
+			}
+			mustWriteJSON(w, &github.RepositoryContent{
+				Type:     github.String("file"),
+				Encoding: github.String("base64"),
+				Path:     github.String(path),
+				Content:  github.String(base64Encoded(contents)),
+				SHA:      github.String(sha(contents)),
+			})
+			return
+		}
+
+		var opts github.RepositoryContentFileOptions
+		if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+			t.Errorf("Expected body to decode fine, but got %+v", err)
+		}
+		if exists && opts.GetSHA() != sha(contents) || !exists && opts.GetSHA() != "" {
+			http.Error(w, "wrong SHA for "+path, 409)
+			return
+		}
+		switch r.Method {
+		case "PUT":
+			repo.files[path] = opts.Content
+		case "DELETE":
+			delete(repo.files, path)
+		default:
+			http.Error(w, r.Method+" is not handled", 405)
+			return
+		}
+		mustWriteJSON(w, github.RepositoryContentResponse{Commit: github.Commit{SHA: github.String("head")}})
+	})
+
+	f(client, repo)
+}
+
+func TestGitHubStoreAs(t *testing.T) {
+	specimen := &Storage{
+		RepositoryOwner: "o",
+		RepositoryName:  "r",
+		Branch:          "b",
+		Dir:             "subdir",
+	}
+
+	withFakeRepo(t, func(client *github.Client, repo *fakeRepo) {
+		specimen.client = client
+
+		for i, name := range []string{"1-check.json", "2-check.json"} {
+			if err := specimen.StoreAs(name, int64(i+1), results); err != nil {
+				t.Fatalf("Expected no error from StoreAs(), got: %v", err)
+			}
+		}
+
+		index, err := specimen.GetIndex()
+		if err != nil {
+			t.Fatalf("Expected no error from GetIndex(), got: %v", err)
+		}
+		if len(index) != 2 || index["1-check.json"] != 1 || index["2-check.json"] != 2 {
+			t.Errorf("Expected index with the given timestamps, got %v", index)
+		}
+		if b := repo.files["subdir/2-check.json"]; !bytes.Equal(b, resultsBytes) {
+			t.Errorf("Contents of file are wrong\nExpected %s\nGot %s", resultsBytes, b)
+		}
+
+		fetched, err := specimen.Fetch("2-check.json")
+		if err != nil {
+			t.Fatalf("Expected no error from Fetch(), got: %v", err)
+		}
+		if len(fetched) != 1 || fetched[0].Title != "Testing" {
+			t.Errorf("Expected stored results, got %+v", fetched)
+		}
+	})
+}
+
+func TestGitHubCodec(t *testing.T) {
+	os.Setenv("CHECKUP_TEST_KEY", "000102030405060708090a0b0c0d0e0f")
+	defer os.Unsetenv("CHECKUP_TEST_KEY")
+
+	specimen := &Storage{
+		RepositoryOwner: "o",
+		RepositoryName:  "r",
+		Branch:          "b",
+	}
+	specimen.Compression = codec.CompressionGzip
+	specimen.Encryption = &codec.Encryption{KeyEnv: "CHECKUP_TEST_KEY"}
+
+	withFakeRepo(t, func(client *github.Client, repo *fakeRepo) {
+		specimen.client = client
+
+		if err := specimen.StoreAs("1-check.json", 1, results); err != nil {
+			t.Fatalf("Expected no error from StoreAs(), got: %v", err)
+		}
+		if b := repo.files["1-check.json"]; bytes.Contains(b, []byte("Testing")) {
+			t.Errorf("Expected check file to be encrypted, got %q", b)
+		}
+
+		fetched, err := specimen.Fetch("1-check.json")
+		if err != nil {
+			t.Fatalf("Expected no error from Fetch(), got: %v", err)
+		}
+		if len(fetched) != 1 || fetched[0].Title != "Testing" {
+			t.Errorf("Expected stored results, got %+v", fetched)
+		}
+
+		plain := &Storage{client: client, RepositoryOwner: "o", RepositoryName: "r", Branch: "b"}
+		if _, err := plain.Fetch("1-check.json"); err == nil {
+			t.Error("Expected an error fetching an encrypted check file without a key")
+		}
+	})
+}
+
+func TestGitHubRollup(t *testing.T) {
+	specimen := &Storage{
+		RepositoryOwner: "o",
+		RepositoryName:  "r",
+		Branch:          "b",
+		Dir:             "subdir",
+		CheckExpiry:     24 * time.Hour,
+		Rollup:          &types.Rollup{HourlyExpiry: 30 * 24 * time.Hour},
+	}
+
+	withFakeRepo(t, func(client *github.Client, repo *fakeRepo) {
+		specimen.client = client
+
+		today := types.PeriodStart(types.PeriodDay, time.Now())
+		for i, ts := range []time.Time{
+			today.AddDate(0, 0, -3).Add(time.Hour),
+			today.AddDate(0, 0, -3).Add(2 * time.Hour),
+			today.AddDate(0, 0, -60),
+			today,
+		} {
+			results := []types.Result{{Title: "Testing", Timestamp: ts.UnixNano(), Healthy: i%2 == 0, Down: i%2 == 1}}
+			if err := specimen.StoreAs(fmt.Sprintf(fs.FilenameFormatString, ts.UnixNano()), ts.UnixNano(), results); err != nil {
+				t.Fatalf("Expected no error from StoreAs(), got: %v", err)
+			}
+		}
+
+		// Maintaining twice must update the existing summary files
+		for i := 0; i < 2; i++ {
+			if err := specimen.Maintain(); err != nil {
+				t.Fatalf("Expected no error from Maintain(), got: %v", err)
+			}
+		}
+
+		index, err := specimen.GetIndex()
+		if err != nil {
+			t.Fatalf("Expected no error from GetIndex(), got: %v", err)
+		}
+		if len(index) != 1 {
+			t.Errorf("Expected only the recent check file to be kept, got %v", index)
+		}
+		if got, want := len(repo.files), 5; got != want {
+			t.Errorf("Expected %d files left in the repository, got %d", want, got)
+		}
+
+		hourly, err := specimen.Summaries(types.PeriodHour, time.Time{}, time.Time{})
+		if err != nil {
+			t.Fatalf("Expected no error from Summaries(), got: %v", err)
+		}
+		if len(hourly) != 2 || hourly[0].Uptime != 100 || hourly[1].Uptime != 0 {
+			t.Errorf("Expected 2 hourly summaries of the recent day, got %+v", hourly)
+		}
+
+		daily, err := specimen.Summaries(types.PeriodDay, today.AddDate(0, 0, -7), time.Time{})
+		if err != nil {
+			t.Fatalf("Expected no error from Summaries(), got: %v", err)
+		}
+		if len(daily) != 1 || daily[0].Results != 2 || daily[0].Uptime != 50 {
+			t.Errorf("Expected daily summary of both check files of the day, got %+v", daily)
+		}
+	})
+}
@@ -0,0 +1,255 @@
+package influxdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Storage is a way to write checkup results as points to
+// InfluxDB, using the HTTP line protocol. It only writes;
+// the results can't be read back by the status page.
+type Storage struct {
+	// URL is the base URL of the InfluxDB server, for
+	// example "http://localhost:8086" (required).
+	URL string `json:"url"`
+
+	// Database, RetentionPolicy, Username and Password are
+	// used to write to InfluxDB 1.x. Database is required.
+	Database        string `json:"database,omitempty"`
+	RetentionPolicy string `json:"retention_policy,omitempty"`
+	Username        string `json:"username,omitempty"`
+	Password        string `json:"password,omitempty"`
+
+	// Org, Bucket and Token are used to write to InfluxDB
+	// 2.x. If Bucket is set, InfluxDB 2.x is assumed and Org
+	// is required.
+	Org    string `json:"org,omitempty"`
+	Bucket string `json:"bucket,omitempty"`
+	Token  string `json:"token,omitempty"`
+
+	// Measurement is the name of the measurement of results.
+	// Default is DefaultMeasurement.
+	Measurement string `json:"measurement,omitempty"`
+
+	// Timeout is the maximum time to wait for a write.
+	// Default is 10 seconds.
+	Timeout time.Duration `json:"timeout,omitempty"`
+}
+
+// New creates a new Storage instance based on json config
+func New(config json.RawMessage) (Storage, error) {
+	var storage Storage
+	err := json.Unmarshal(config, &storage)
+	return storage, err
+}
+
+// Type returns the storage driver package name
+func (Storage) Type() string {
+	return Type
+}
+
+// Store writes results to InfluxDB: one point per result in
+// the results measurement, with the status, threshold and
+// statistics of the result, and one point per attempt in the
+// attempts measurement. Points are tagged with the title,
+// endpoint, group, owner and labels of their result.
+func (i Storage) Store(results []types.Result) error {
+	req, err := i.request(lines(i.measurement(), results))
+	if err != nil {
+		return err
+	}
+	timeout := i.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("influxdb: write failed with status %s: %s",
+			resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func (i Storage) measurement() string {
+	if i.Measurement == "" {
+		return DefaultMeasurement
+	}
+	return i.Measurement
+}
+
+// request returns the request that writes body to the
+// configured version of InfluxDB.
+func (i Storage) request(body []byte) (*http.Request, error) {
+	if i.URL == "" {
+		return nil, fmt.Errorf("influxdb: missing URL")
+	}
+	query := url.Values{"precision": {"ns"}}
+	path := "/write"
+	if i.Bucket != "" {
+		if i.Org == "" {
+			return nil, fmt.Errorf("influxdb: missing org for bucket %s", i.Bucket)
+		}
+		path = "/api/v2/write"
+		query.Set("org", i.Org)
+		query.Set("bucket", i.Bucket)
+	} else {
+		if i.Database == "" {
+			return nil, fmt.Errorf("influxdb: missing database or bucket")
+		}
+		query.Set("db", i.Database)
+		if i.RetentionPolicy != "" {
+			query.Set("rp", i.RetentionPolicy)
+		}
+	}
+
+	req, err := http.NewRequest("POST", strings.TrimRight(i.URL, "/")+path+"?"+query.Encode(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if i.Token != "" {
+		req.Header.Set("Authorization", "Token "+i.Token)
+	} else if i.Username != "" {
+		req.SetBasicAuth(i.Username, i.Password)
+	}
+	return req, nil
+}
+
+// lines returns results in the line protocol, using
+// measurement as the name of the measurement of results
+// and measurement+"_attempt" for their attempts.
+func lines(measurement string, results []types.Result) []byte {
+	var buf bytes.Buffer
+	for _, result := range results {
+		tags := resultTags(result)
+		var stats types.Stats
+		if len(result.Times) > 0 {
+			stats = result.ComputeStats()
+		}
+		var failures int
+		for _, attempt := range result.Times {
+			if attempt.Error != "" {
+				failures++
+			}
+		}
+
+		writeLine(&buf, measurement, tags, []field{
+			{"status", quote(string(result.Status()))},
+			{"healthy", strconv.FormatBool(result.Healthy)},
+			{"degraded", strconv.FormatBool(result.Degraded)},
+			{"down", strconv.FormatBool(result.Down)},
+			{"suppressed", strconv.FormatBool(result.Suppressed)},
+			{"threshold", integer(int64(result.ThresholdRTT))},
+			{"attempts", integer(int64(len(result.Times)))},
+			{"failures", integer(int64(failures))},
+			{"total", integer(int64(stats.Total))},
+			{"mean", integer(int64(stats.Mean))},
+			{"median", integer(int64(stats.Median))},
+			{"min", integer(int64(stats.Min))},
+			{"max", integer(int64(stats.Max))},
+			{"p90", integer(int64(stats.P90))},
+			{"p95", integer(int64(stats.P95))},
+			{"p99", integer(int64(stats.P99))},
+			{"stddev", integer(int64(stats.StdDev))},
+		}, result.Timestamp)
+
+		for n, attempt := range result.Times {
+			fields := []field{{"rtt", integer(int64(attempt.RTT))}}
+			if attempt.Error != "" {
+				fields = append(fields, field{"error", quote(attempt.Error)})
+			}
+			attemptTags := append(tags[:len(tags):len(tags)], tag{"attempt", strconv.Itoa(n)})
+			sortTags(attemptTags)
+			writeLine(&buf, measurement+"_attempt", attemptTags, fields, result.Timestamp)
+		}
+	}
+	return buf.Bytes()
+}
+
+type tag struct{ key, value string }
+
+type field struct{ key, value string }
+
+// resultTags returns the tags of the points of result,
+// sorted by key as recommended by InfluxDB. Labels don't
+// override the other tags.
+func resultTags(result types.Result) []tag {
+	values := make(map[string]string)
+	for k, v := range result.Labels {
+		values[k] = v
+	}
+	values["title"] = result.Title
+	values["endpoint"] = result.Endpoint
+	values["group"] = result.Group
+	values["owner"] = result.Owner
+
+	var tags []tag
+	for k, v := range values {
+		// empty tag values are not allowed
+		if k != "" && v != "" {
+			tags = append(tags, tag{k, v})
+		}
+	}
+	sortTags(tags)
+	return tags
+}
+
+func sortTags(tags []tag) {
+	sort.Slice(tags, func(i, j int) bool { return tags[i].key < tags[j].key })
+}
+
+// writeLine writes a point in the line protocol to buf.
+func writeLine(buf *bytes.Buffer, measurement string, tags []tag, fields []field, timestamp int64) {
+	buf.WriteString(measurementEscaper.Replace(measurement))
+	for _, t := range tags {
+		buf.WriteByte(',')
+		buf.WriteString(tagEscaper.Replace(t.key))
+		buf.WriteByte('=')
+		buf.WriteString(tagEscaper.Replace(t.value))
+	}
+	for n, f := range fields {
+		if n == 0 {
+			buf.WriteByte(' ')
+		} else {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(tagEscaper.Replace(f.key))
+		buf.WriteByte('=')
+		buf.WriteString(f.value)
+	}
+	buf.WriteByte(' ')
+	buf.WriteString(strconv.FormatInt(timestamp, 10))
+	buf.WriteByte('\n')
+}
+
+var (
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "\n", `\n`)
+	tagEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", `\n`)
+	stringEscaper      = strings.NewReplacer(`"`, `\"`, `\`, `\\`)
+)
+
+// integer returns the field value of the integer n.
+func integer(n int64) string {
+	return strconv.FormatInt(n, 10) + "i"
+}
+
+// quote returns the field value of the string s.
+func quote(s string) string {
+	return `"` + stringEscaper.Replace(s) + `"`
+}
@@ -0,0 +1,92 @@
+package influxdb
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+func TestLines(t *testing.T) {
+	results := []types.Result{{
+		Title:        "Example, Inc",
+		Endpoint:     "http://example.com",
+		Timestamp:    1500000000000000000,
+		ThresholdRTT: 50 * time.Millisecond,
+		Times: types.Attempts{
+			{RTT: 10 * time.Millisecond},
+			{RTT: 30 * time.Millisecond, Error: `said "no"`},
+		},
+		Degraded: true,
+		Labels:   map[string]string{"env": "prod", "title": "ignored"},
+	}}
+
+	expected := `checkup,endpoint=http://example.com,env=prod,title=Example\,\ Inc ` +
+		`status="degraded",healthy=false,degraded=true,down=false,suppressed=false,threshold=50000000i,` +
+		`attempts=2i,failures=1i,total=40000000i,mean=20000000i,median=20000000i,min=10000000i,` +
+		`max=30000000i,p90=30000000i,p95=30000000i,p99=30000000i,stddev=10000000i 1500000000000000000
+checkup_attempt,attempt=0,endpoint=http://example.com,env=prod,title=Example\,\ Inc rtt=10000000i 1500000000000000000
+checkup_attempt,attempt=1,endpoint=http://example.com,env=prod,title=Example\,\ Inc rtt=30000000i,error="said \"no\"" 1500000000000000000
+`
+	if got := string(lines("checkup", results)); got != expected {
+		t.Errorf("Expected lines:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestStorage(t *testing.T) {
+	var (
+		path, query, auth, body string
+		status                  = http.StatusNoContent
+	)
+	standIn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, query, auth = r.URL.Path, r.URL.RawQuery, r.Header.Get("Authorization")
+		b, _ := ioutil.ReadAll(r.Body)
+		body = string(b)
+		w.WriteHeader(status)
+	}))
+	defer standIn.Close()
+
+	results := []types.Result{{Title: "Testing", Endpoint: "http://example.com", Healthy: true}}
+
+	for i, test := range []struct {
+		storage Storage
+		path    string
+		query   string
+		auth    string
+	}{
+		{
+			Storage{URL: standIn.URL, Database: "checkup", RetentionPolicy: "week", Username: "user", Password: "pass"},
+			"/write", "db=checkup&precision=ns&rp=week", "Basic dXNlcjpwYXNz",
+		},
+		{
+			Storage{URL: standIn.URL + "/", Org: "acme", Bucket: "checkup", Token: "secret"},
+			"/api/v2/write", "bucket=checkup&org=acme&precision=ns", "Token secret",
+		},
+	} {
+		if err := test.storage.Store(results); err != nil {
+			t.Fatalf("Test %d: expected no error from Store(), got %v", i, err)
+		}
+		if path != test.path || query != test.query || auth != test.auth {
+			t.Errorf("Test %d: expected request to %s?%s with auth %q, got %s?%s with auth %q",
+				i, test.path, test.query, test.auth, path, query, auth)
+		}
+		if !strings.HasPrefix(body, "checkup,endpoint=http://example.com,title=Testing ") {
+			t.Errorf("Test %d: unexpected body %q", i, body)
+		}
+	}
+
+	status = http.StatusBadRequest
+	if err := (Storage{URL: standIn.URL, Database: "checkup"}).Store(results); err == nil {
+		t.Errorf("Expected an error when the write fails")
+	}
+	if err := (Storage{URL: standIn.URL}).Store(results); err == nil {
+		t.Errorf("Expected an error without database or bucket")
+	}
+	if err := (Storage{URL: standIn.URL, Bucket: "checkup"}).Store(results); err == nil {
+		t.Errorf("Expected an error without org")
+	}
+}
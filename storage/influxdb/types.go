@@ -0,0 +1,9 @@
+package influxdb
+
+// Type should match the package name
+const Type = "influxdb"
+
+// DefaultMeasurement is the default name of the measurement
+// of results. Attempts are written to the measurement with
+// the suffix "_attempt".
+const DefaultMeasurement = "checkup"
@@ -0,0 +1,226 @@
+package multi
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Member is a storage in a multi storage. It has the same
+// methods as checkup.Storage.
+type Member interface {
+	Type() string
+	Store([]types.Result) error
+}
+
+// DecodeFunc decodes the configuration of a member with
+// the given type name.
+type DecodeFunc func(typeName string, config json.RawMessage) (Member, error)
+
+// Storage is a way to store checkup results in several
+// storages at once, for example in S3 for the status page
+// and in SQL for analytics.
+type Storage struct {
+	// Storages are the configurations of the members, in
+	// the same format as the storage of a checkup.
+	Storages []json.RawMessage `json:"storages"`
+
+	// Policy is how the failure of some members to store
+	// or maintain results is handled:
+	//
+	//   - "fail_any" (the default): an error is returned
+	//     if any member fails
+	//   - "best_effort": failures are logged, and an error
+	//     is only returned if all members fail
+	Policy string `json:"policy,omitempty"`
+
+	// Primary is the index in Storages of the member that
+	// results are read from. Default is the first one.
+	Primary int `json:"primary,omitempty"`
+
+	// members are the decoded Storages.
+	members []Member
+}
+
+// New creates a new Storage instance based on json config,
+// using decode to decode the configurations of the members.
+func New(config json.RawMessage, decode DecodeFunc) (Storage, error) {
+	var storage Storage
+	err := json.Unmarshal(config, &storage)
+	if err != nil {
+		return storage, err
+	}
+
+	switch storage.Policy {
+	case "", PolicyFailAny, PolicyBestEffort:
+	default:
+		return storage, fmt.Errorf("multi: unknown policy '%s'", storage.Policy)
+	}
+	if len(storage.Storages) == 0 {
+		return storage, fmt.Errorf("multi: no storages configured")
+	}
+	if storage.Primary < 0 || storage.Primary >= len(storage.Storages) {
+		return storage, fmt.Errorf("multi: primary must be between 0 and %d", len(storage.Storages)-1)
+	}
+
+	for i, raw := range storage.Storages {
+		var typ struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(raw, &typ); err != nil {
+			return storage, fmt.Errorf("multi: storage %d: %v", i, err)
+		}
+		member, err := decode(typ.Type, raw)
+		if err != nil {
+			return storage, fmt.Errorf("multi: storage %d: %v", i, err)
+		}
+		storage.members = append(storage.members, member)
+	}
+	return storage, nil
+}
+
+// Type returns the storage driver package name
+func (Storage) Type() string {
+	return Type
+}
+
+// Store stores results in all members concurrently.
+func (m Storage) Store(results []types.Result) error {
+	return m.each("storing results", func(member Member) error {
+		return member.Store(results)
+	})
+}
+
+// StoreAs stores results as the check file with the given
+// name and timestamp in all members concurrently. Members
+// that can't store check files with a given name fail.
+func (m Storage) StoreAs(name string, timestamp int64, results []types.Result) error {
+	return m.each("storing results", func(member Member) error {
+		writer, ok := member.(interface {
+			StoreAs(name string, timestamp int64, results []types.Result) error
+		})
+		if !ok {
+			return fmt.Errorf("can't store check file %s", name)
+		}
+		return writer.StoreAs(name, timestamp, results)
+	})
+}
+
+// Maintain calls Maintain on all members that are
+// maintainers, concurrently.
+func (m Storage) Maintain() error {
+	return m.each("maintaining", func(member Member) error {
+		if maintainer, ok := member.(interface{ Maintain() error }); ok {
+			return maintainer.Maintain()
+		}
+		return nil
+	})
+}
+
+// each calls f with each member concurrently and handles
+// their errors according to m.Policy.
+func (m Storage) each(action string, f func(Member) error) error {
+	errs := make(types.Errors, len(m.members))
+	wg := sync.WaitGroup{}
+
+	for i, member := range m.members {
+		wg.Add(1)
+		go func(i int, member Member) {
+			if err := f(member); err != nil {
+				errs[i] = fmt.Errorf("%s: %v", member.Type(), err)
+			}
+			wg.Done()
+		}(i, member)
+	}
+	wg.Wait()
+
+	if errs.Empty() {
+		return nil
+	}
+	if m.Policy == PolicyBestEffort {
+		for _, err := range errs {
+			if err == nil {
+				log.Printf("multi: error %s: %s", action, errs)
+				return nil
+			}
+		}
+	}
+	return errs
+}
+
+// reader has the same methods as checkup.StorageReader.
+type reader interface {
+	Fetch(name string) ([]types.Result, error)
+	GetIndex() (map[string]int64, error)
+}
+
+// primary returns the member that results are read from.
+func (m Storage) primary() (Member, error) {
+	if m.Primary < 0 || m.Primary >= len(m.members) {
+		return nil, fmt.Errorf("multi: no primary storage")
+	}
+	return m.members[m.Primary], nil
+}
+
+// Fetch fetches results of the check with given name from
+// the primary member.
+func (m Storage) Fetch(name string) ([]types.Result, error) {
+	member, err := m.primary()
+	if err != nil {
+		return nil, err
+	}
+	r, ok := member.(reader)
+	if !ok {
+		return nil, fmt.Errorf("multi: primary storage %s can't be read", member.Type())
+	}
+	return r.Fetch(name)
+}
+
+// GetIndex returns the index of the primary member.
+func (m Storage) GetIndex() (map[string]int64, error) {
+	member, err := m.primary()
+	if err != nil {
+		return nil, err
+	}
+	r, ok := member.(reader)
+	if !ok {
+		return nil, fmt.Errorf("multi: primary storage %s can't be read", member.Type())
+	}
+	return r.GetIndex()
+}
+
+// QueryResults returns the results that match q from the
+// primary member.
+func (m Storage) QueryResults(q types.ResultQuery) ([]types.Result, error) {
+	member, err := m.primary()
+	if err != nil {
+		return nil, err
+	}
+	querier, ok := member.(interface {
+		QueryResults(q types.ResultQuery) ([]types.Result, error)
+	})
+	if !ok {
+		return nil, fmt.Errorf("multi: primary storage %s can't be queried", member.Type())
+	}
+	return querier.QueryResults(q)
+}
+
+// Summaries returns the summaries of period that start at
+// or after from and before to from the primary member.
+func (m Storage) Summaries(period string, from, to time.Time) ([]types.Summary, error) {
+	member, err := m.primary()
+	if err != nil {
+		return nil, err
+	}
+	reader, ok := member.(interface {
+		Summaries(period string, from, to time.Time) ([]types.Summary, error)
+	})
+	if !ok {
+		return nil, fmt.Errorf("multi: primary storage %s doesn't keep summaries", member.Type())
+	}
+	return reader.Summaries(period, from, to)
+}
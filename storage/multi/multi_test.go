@@ -0,0 +1,180 @@
+package multi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// fake is a member that records the results stored in it
+// and fails if its configuration says so.
+type fake struct {
+	Name string `json:"name"`
+	Fail bool   `json:"fail"`
+
+	mu         *sync.Mutex
+	stored     *[][]types.Result
+	maintained *int
+	unreadable bool
+}
+
+func (fake) Type() string { return "fake" }
+
+func (f fake) Store(results []types.Result) error {
+	if f.Fail {
+		return errors.New("store failed")
+	}
+	f.mu.Lock()
+	*f.stored = append(*f.stored, results)
+	f.mu.Unlock()
+	return nil
+}
+
+func (f fake) Maintain() error {
+	if f.Fail {
+		return errors.New("maintain failed")
+	}
+	f.mu.Lock()
+	*f.maintained++
+	f.mu.Unlock()
+	return nil
+}
+
+func (f fake) Fetch(name string) ([]types.Result, error) {
+	return []types.Result{{Title: f.Name + "/" + name}}, nil
+}
+
+func (f fake) GetIndex() (map[string]int64, error) {
+	return map[string]int64{f.Name: 1}, nil
+}
+
+// writeOnly is a member that can't be read or maintained.
+type writeOnly struct{}
+
+func (writeOnly) Type() string               { return "write_only" }
+func (writeOnly) Store([]types.Result) error { return nil }
+
+// newMulti returns a multi storage with the given policy and
+// primary, whose members are fakes with the given names. A
+// name starting with "!" is a fake that fails.
+func newMulti(t *testing.T, policy string, primary int, names ...string) (Storage, map[string]fake) {
+	fakes := make(map[string]fake)
+	decode := func(typeName string, config json.RawMessage) (Member, error) {
+		switch typeName {
+		case "fake":
+			f := fake{mu: new(sync.Mutex), stored: new([][]types.Result), maintained: new(int)}
+			err := json.Unmarshal(config, &f)
+			fakes[f.Name] = f
+			return f, err
+		case "write_only":
+			return writeOnly{}, nil
+		}
+		return nil, fmt.Errorf("unknown storage type '%s'", typeName)
+	}
+
+	var members []string
+	for _, name := range names {
+		if name == "write_only" {
+			members = append(members, `{"type":"write_only"}`)
+			continue
+		}
+		fail := strings.HasPrefix(name, "!")
+		members = append(members, fmt.Sprintf(`{"type":"fake","name":"%s","fail":%t}`, strings.TrimPrefix(name, "!"), fail))
+	}
+	config := fmt.Sprintf(`{"policy":"%s","primary":%d,"storages":[%s]}`, policy, primary, strings.Join(members, ","))
+	m, err := New(json.RawMessage(config), decode)
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	return m, fakes
+}
+
+func TestStore(t *testing.T) {
+	results := []types.Result{{Title: "Testing"}}
+
+	m, fakes := newMulti(t, "", 0, "a", "b")
+	if err := m.Store(results); err != nil {
+		t.Fatalf("Expected no error from Store(), got: %v", err)
+	}
+	if err := m.Maintain(); err != nil {
+		t.Fatalf("Expected no error from Maintain(), got: %v", err)
+	}
+	for name, f := range fakes {
+		if len(*f.stored) != 1 || (*f.stored)[0][0].Title != "Testing" {
+			t.Errorf("Expected results to be stored in %s, got %v", name, *f.stored)
+		}
+		if *f.maintained != 1 {
+			t.Errorf("Expected %s to be maintained once, got %d", name, *f.maintained)
+		}
+	}
+
+	for i, test := range []struct {
+		policy string
+		names  []string
+		err    bool
+	}{
+		{PolicyFailAny, []string{"a", "!b"}, true},
+		{PolicyBestEffort, []string{"a", "!b"}, false},
+		{PolicyBestEffort, []string{"!a", "!b"}, true},
+		{PolicyBestEffort, []string{"a", "write_only"}, false},
+	} {
+		m, fakes := newMulti(t, test.policy, 0, test.names...)
+		err := m.Store(results)
+		if test.err != (err != nil) {
+			t.Errorf("Test %d: expected error %t from Store(), got %v", i, test.err, err)
+		}
+		err = m.Maintain()
+		if test.err != (err != nil) {
+			t.Errorf("Test %d: expected error %t from Maintain(), got %v", i, test.err, err)
+		}
+		if f, ok := fakes["a"]; ok && !f.Fail && len(*f.stored) != 1 {
+			t.Errorf("Test %d: expected results to be stored in the member that didn't fail", i)
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	m, _ := newMulti(t, "", 1, "a", "b")
+	index, err := m.GetIndex()
+	if err != nil {
+		t.Fatalf("Expected no error from GetIndex(), got: %v", err)
+	}
+	if _, ok := index["b"]; !ok || len(index) != 1 {
+		t.Errorf("Expected index of primary storage, got %v", index)
+	}
+	results, err := m.Fetch("check.json")
+	if err != nil {
+		t.Fatalf("Expected no error from Fetch(), got: %v", err)
+	}
+	if len(results) != 1 || results[0].Title != "b/check.json" {
+		t.Errorf("Expected results from primary storage, got %v", results)
+	}
+	if _, err := m.QueryResults(types.ResultQuery{}); err == nil {
+		t.Errorf("Expected an error querying a primary storage that can't be queried")
+	}
+
+	m, _ = newMulti(t, "", 0, "write_only", "a")
+	if _, err := m.GetIndex(); err == nil {
+		t.Errorf("Expected an error reading a primary storage that can't be read")
+	}
+}
+
+func TestNew(t *testing.T) {
+	decode := func(typeName string, config json.RawMessage) (Member, error) {
+		return writeOnly{}, nil
+	}
+	for i, config := range []string{
+		`{"storages":[]}`,
+		`{"storages":[{"type":"write_only"}],"primary":1}`,
+		`{"storages":[{"type":"write_only"}],"policy":"sometimes"}`,
+	} {
+		if _, err := New(json.RawMessage(config), decode); err == nil {
+			t.Errorf("Test %d: expected an error for config %s", i, config)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package multi
+
+// Type should match the package name
+const Type = "multi"
+
+// Policies for handling the failure of some of the storages.
+const (
+	PolicyFailAny    = "fail_any"
+	PolicyBestEffort = "best_effort"
+)
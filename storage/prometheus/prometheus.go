@@ -0,0 +1,208 @@
+package prometheus
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/snappy"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Storage is a way to write checkup results as samples to
+// any endpoint that implements the Prometheus remote write
+// protocol. It only writes; the results can't be read back
+// by the status page.
+type Storage struct {
+	// URL is the remote write endpoint, for example
+	// "http://localhost:9090/api/v1/write" (required).
+	URL string `json:"url"`
+
+	// Username and Password, or BearerToken, authenticate
+	// the writes.
+	Username    string `json:"username,omitempty"`
+	Password    string `json:"password,omitempty"`
+	BearerToken string `json:"bearer_token,omitempty"`
+
+	// Job is the value of the job label of all series.
+	// Default is DefaultJob.
+	Job string `json:"job,omitempty"`
+
+	// Timeout is the maximum time to wait for a write.
+	// Default is 10 seconds.
+	Timeout time.Duration `json:"timeout,omitempty"`
+}
+
+// New creates a new Storage instance based on json config
+func New(config json.RawMessage) (Storage, error) {
+	var storage Storage
+	err := json.Unmarshal(config, &storage)
+	return storage, err
+}
+
+// Type returns the storage driver package name
+func (Storage) Type() string {
+	return Type
+}
+
+// Store writes results to the remote write endpoint. Each
+// result is written as the series checkup_healthy,
+// checkup_degraded, checkup_down, checkup_suppressed,
+// checkup_threshold_seconds, checkup_attempts,
+// checkup_failures, checkup_rtt_seconds (with a stat label
+// for each statistic) and checkup_attempt_rtt_seconds (with
+// an attempt label), labeled with the title, endpoint,
+// group, owner and labels of the result.
+func (p Storage) Store(results []types.Result) error {
+	if p.URL == "" {
+		return fmt.Errorf("prometheus: missing URL")
+	}
+	job := p.Job
+	if job == "" {
+		job = DefaultJob
+	}
+	body := snappy.Encode(nil, marshalWriteRequest(resultSeries(job, results)))
+
+	req, err := http.NewRequest("POST", p.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	if p.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+p.BearerToken)
+	} else if p.Username != "" {
+		req.SetBasicAuth(p.Username, p.Password)
+	}
+
+	timeout := p.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("prometheus: write failed with status %s: %s",
+			resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// resultSeries returns the series of results.
+func resultSeries(job string, results []types.Result) []series {
+	var ts []series
+	for _, result := range results {
+		labels := resultLabels(job, result)
+		timestamp := result.Timestamp / int64(time.Millisecond)
+		add := func(name string, value float64, extra ...label) {
+			l := append([]label{{"__name__", name}}, labels...)
+			l = append(l, extra...)
+			sort.Slice(l, func(i, j int) bool { return l[i].name < l[j].name })
+			ts = append(ts, series{labels: l, value: value, timestamp: timestamp})
+		}
+
+		var failures int
+		for _, attempt := range result.Times {
+			if attempt.Error != "" {
+				failures++
+			}
+		}
+		add("checkup_healthy", boolValue(result.Healthy))
+		add("checkup_degraded", boolValue(result.Degraded))
+		add("checkup_down", boolValue(result.Down))
+		add("checkup_suppressed", boolValue(result.Suppressed))
+		add("checkup_threshold_seconds", result.ThresholdRTT.Seconds())
+		add("checkup_attempts", float64(len(result.Times)))
+		add("checkup_failures", float64(failures))
+
+		if len(result.Times) == 0 {
+			continue
+		}
+		stats := result.ComputeStats()
+		for _, stat := range []struct {
+			name  string
+			value time.Duration
+		}{
+			{"mean", stats.Mean},
+			{"median", stats.Median},
+			{"min", stats.Min},
+			{"max", stats.Max},
+			{"p90", stats.P90},
+			{"p95", stats.P95},
+			{"p99", stats.P99},
+			{"stddev", stats.StdDev},
+		} {
+			add("checkup_rtt_seconds", stat.value.Seconds(), label{"stat", stat.name})
+		}
+		for n, attempt := range result.Times {
+			add("checkup_attempt_rtt_seconds", attempt.RTT.Seconds(), label{"attempt", strconv.Itoa(n)})
+		}
+	}
+	return ts
+}
+
+// resultLabels returns the labels common to the series of
+// result. Labels of the result don't override the others,
+// and invalid characters in their names are replaced.
+func resultLabels(job string, result types.Result) []label {
+	values := make(map[string]string)
+	for k, v := range result.Labels {
+		name := labelName(k)
+		if strings.HasPrefix(name, "__") {
+			// reserved for internal use, such as __name__
+			continue
+		}
+		values[name] = v
+	}
+	values["job"] = job
+	values["title"] = result.Title
+	values["endpoint"] = result.Endpoint
+	values["group"] = result.Group
+	values["owner"] = result.Owner
+	delete(values, "stat")
+	delete(values, "attempt")
+
+	var labels []label
+	for k, v := range values {
+		// empty labels are the same as missing labels
+		if k != "" && v != "" {
+			labels = append(labels, label{k, v})
+		}
+	}
+	return labels
+}
+
+// labelName replaces the characters of name that are not
+// allowed in label names with underscores.
+func labelName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
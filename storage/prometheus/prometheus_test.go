@@ -0,0 +1,179 @@
+package prometheus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+func TestStorage(t *testing.T) {
+	var (
+		received []series
+		header   http.Header
+	)
+	standIn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ := ioutil.ReadAll(r.Body)
+		b, err := snappy.Decode(nil, body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		received, err = unmarshalWriteRequest(b)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer standIn.Close()
+
+	results := []types.Result{{
+		Title:        "Example",
+		Endpoint:     "http://example.com",
+		Timestamp:    1500000000123456789,
+		ThresholdRTT: 50 * time.Millisecond,
+		Times: types.Attempts{
+			{RTT: 10 * time.Millisecond},
+			{RTT: 30 * time.Millisecond, Error: "timeout"},
+		},
+		Degraded: true,
+		Labels: map[string]string{"env": "prod", "team.name": "web", "job": "ignored",
+			"__name__": "reserved", "_.meta": "reserved"},
+	}}
+
+	specimen := Storage{URL: standIn.URL, BearerToken: "secret"}
+	if err := specimen.Store(results); err != nil {
+		t.Fatalf("Expected no error from Store(), got: %v", err)
+	}
+
+	if got := header.Get("Content-Encoding"); got != "snappy" {
+		t.Errorf("Expected snappy content encoding, got %q", got)
+	}
+	if got := header.Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("Expected bearer token, got %q", got)
+	}
+
+	samples := make(map[string]series)
+	for _, s := range received {
+		samples[s.String()] = s
+		if s.timestamp != 1500000000123 {
+			t.Errorf("Expected timestamp in milliseconds, got %d for %s", s.timestamp, s)
+		}
+	}
+	const common = `endpoint="http://example.com",env="prod",job="checkup",team_name="web",title="Example"`
+	const maxRTT = `checkup_rtt_seconds{endpoint="http://example.com",env="prod",job="checkup",stat="max",team_name="web",title="Example"}`
+	for name, value := range map[string]float64{
+		`checkup_healthy{` + common + `}`:                         0,
+		`checkup_degraded{` + common + `}`:                        1,
+		`checkup_threshold_seconds{` + common + `}`:               0.05,
+		`checkup_failures{` + common + `}`:                        1,
+		maxRTT:                                                    0.03,
+		`checkup_attempt_rtt_seconds{attempt="0",` + common + `}`: 0.01,
+		`checkup_attempt_rtt_seconds{attempt="1",` + common + `}`: 0.03,
+	} {
+		s, ok := samples[name]
+		if !ok {
+			t.Errorf("Expected series %s, got none", name)
+			continue
+		}
+		if s.value != value {
+			t.Errorf("Expected %s to be %v, got %v", name, value, s.value)
+		}
+	}
+	if len(received) != 7+8+2 {
+		t.Errorf("Expected %d series, got %d", 7+8+2, len(received))
+	}
+
+	if err := (Storage{URL: standIn.URL}).Store(nil); err != nil {
+		t.Fatalf("Expected no error writing no results, got: %v", err)
+	}
+	standIn.Config.Handler = http.NotFoundHandler()
+	if err := specimen.Store(results); err == nil {
+		t.Errorf("Expected an error when the write fails")
+	}
+}
+
+// String returns s in the Prometheus exposition format,
+// without its value, assuming its labels are sorted.
+func (s series) String() string {
+	var name string
+	var labels []string
+	for _, l := range s.labels {
+		if l.name == "__name__" {
+			name = l.value
+			continue
+		}
+		labels = append(labels, fmt.Sprintf("%s=%q", l.name, l.value))
+	}
+	return name + "{" + strings.Join(labels, ",") + "}"
+}
+
+// unmarshalWriteRequest decodes the series of a WriteRequest
+// that only holds samples encoded by marshalWriteRequest.
+func unmarshalWriteRequest(b []byte) ([]series, error) {
+	var ts []series
+	err := readFields(b, func(field int, v []byte) error {
+		var s series
+		err := readFields(v, func(field int, v []byte) error {
+			switch field {
+			case 1:
+				var l label
+				err := readFields(v, func(field int, v []byte) error {
+					if field == 1 {
+						l.name = string(v)
+					} else {
+						l.value = string(v)
+					}
+					return nil
+				})
+				s.labels = append(s.labels, l)
+				return err
+			case 2:
+				if len(v) < 9 || v[0] != 1<<3|wireFixed64 {
+					return fmt.Errorf("unexpected sample %x", v)
+				}
+				s.value = math.Float64frombits(binary.LittleEndian.Uint64(v[1:9]))
+				ts, n := binary.Uvarint(v[10:])
+				if v[9] != 2<<3|wireVarint || n <= 0 {
+					return fmt.Errorf("unexpected sample %x", v)
+				}
+				s.timestamp = int64(ts)
+			}
+			return nil
+		})
+		ts = append(ts, s)
+		return err
+	})
+	return ts, err
+}
+
+// readFields calls f with each length-delimited field of b.
+func readFields(b []byte, f func(field int, v []byte) error) error {
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 || tag&7 != wireBytes {
+			return fmt.Errorf("unexpected tag %x", b)
+		}
+		b = b[n:]
+		length, n := binary.Uvarint(b)
+		if n <= 0 || uint64(len(b)-n) < length {
+			return fmt.Errorf("truncated field %x", b)
+		}
+		if err := f(int(tag>>3), b[n:n+int(length)]); err != nil {
+			return err
+		}
+		b = b[n+int(length):]
+	}
+	return nil
+}
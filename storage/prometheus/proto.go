@@ -0,0 +1,78 @@
+package prometheus
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// This file encodes the protocol buffers messages of the
+// remote write protocol, which are few and small enough
+// that they don't warrant generated code:
+//
+//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+//	message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label { string name = 1; string value = 2; }
+//	message Sample { double value = 1; int64 timestamp = 2; }
+
+// label is a label of a series.
+type label struct {
+	name, value string
+}
+
+// series is a series with a single sample.
+type series struct {
+	labels    []label
+	value     float64
+	timestamp int64 // in milliseconds
+}
+
+// Wire types of protocol buffers fields.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+func appendTag(b []byte, field, wireType int) []byte {
+	return appendVarint(b, uint64(field<<3|wireType))
+}
+
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func appendBytes(b []byte, field int, v []byte) []byte {
+	b = appendTag(b, field, wireBytes)
+	b = appendVarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// marshalWriteRequest encodes a WriteRequest holding ts.
+func marshalWriteRequest(ts []series) []byte {
+	var b []byte
+	for _, s := range ts {
+		var msg []byte
+		for _, l := range s.labels {
+			var lmsg []byte
+			lmsg = appendBytes(lmsg, 1, []byte(l.name))
+			lmsg = appendBytes(lmsg, 2, []byte(l.value))
+			msg = appendBytes(msg, 1, lmsg)
+		}
+
+		var smsg []byte
+		smsg = appendTag(smsg, 1, wireFixed64)
+		var value [8]byte
+		binary.LittleEndian.PutUint64(value[:], math.Float64bits(s.value))
+		smsg = append(smsg, value[:]...)
+		smsg = appendTag(smsg, 2, wireVarint)
+		smsg = appendVarint(smsg, uint64(s.timestamp))
+		msg = appendBytes(msg, 2, smsg)
+
+		b = appendBytes(b, 1, msg)
+	}
+	return b
+}
@@ -0,0 +1,8 @@
+package prometheus
+
+// Type should match the package name
+const Type = "prometheus"
+
+// DefaultJob is the default value of the job label of the
+// series written by the storage.
+const DefaultJob = "checkup"
@@ -0,0 +1,134 @@
+// Package rollup summarizes the check files that storages
+// delete when they are maintained into hourly and daily
+// summaries of each endpoint.
+package rollup
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// FilenameFormatString is the format string of the names of
+// the files that storages keep summaries in, with the start
+// of their UTC day and their period. Each file holds the
+// summaries of all endpoints for one day.
+const FilenameFormatString = "%d-%s-summary.json"
+
+// Periods are the periods that check files are summarized
+// over, in the order they are stored.
+var Periods = []string{types.PeriodHour, types.PeriodDay}
+
+// Filename returns the name of the summary file of period
+// for the UTC day that starts at day.
+func Filename(period string, day time.Time) string {
+	return fmt.Sprintf(FilenameFormatString, day.UnixNano(), period)
+}
+
+// ParseFilename returns the period and start of the day of
+// the summary file with the given name, and whether name
+// is the name of a summary file.
+func ParseFilename(name string) (string, time.Time, bool) {
+	for _, period := range Periods {
+		var day int64
+		if _, err := fmt.Sscanf(name, "%d-"+period+"-summary.json", &day); err != nil {
+			continue
+		}
+		if t := time.Unix(0, day); Filename(period, t) == name {
+			return period, t, true
+		}
+	}
+	return "", time.Time{}, false
+}
+
+// Cutoff returns the time before which check files can be
+// deleted when check files older than expiry are rolled up:
+// the start of the UTC day that is expiry ago, so that only
+// complete days are summarized. Check files are thus kept up
+// to a day longer than expiry.
+func Cutoff(expiry time.Duration) time.Time {
+	return types.PeriodStart(types.PeriodDay, time.Now().Add(-expiry))
+}
+
+// Roll summarizes the check files of index that are older
+// than cutoff, one UTC day at a time. It fetches the check
+// files of each day with fetch, merges their summaries for
+// each period into those already kept, read with summaries,
+// and passes the result to store, which replaces the
+// summaries of that day. Check files added to a day that was
+// already rolled up, such as by a migration, are thus added
+// to its summaries. Storages must then delete exactly these
+// check files, those of index with a timestamp before cutoff,
+// so that they are not summarized twice.
+func Roll(index map[string]int64, cutoff time.Time,
+	fetch func(name string) ([]types.Result, error),
+	summaries func(period string, from, to time.Time) ([]types.Summary, error),
+	store func(period string, day time.Time, summaries []types.Summary) error) error {
+	days := make(map[int64][]string)
+	for name, nsec := range index {
+		if nsec >= cutoff.UnixNano() {
+			continue
+		}
+		day := types.PeriodStart(types.PeriodDay, time.Unix(0, nsec)).UnixNano()
+		days[day] = append(days[day], name)
+	}
+
+	var starts []int64
+	for day := range days {
+		starts = append(starts, day)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+
+	for _, start := range starts {
+		var results []types.Result
+		for _, name := range days[start] {
+			r, err := fetch(name)
+			if err != nil {
+				return fmt.Errorf("rollup: fetching %s: %v", name, err)
+			}
+			results = append(results, r...)
+		}
+		day := time.Unix(0, start).UTC()
+		for _, period := range Periods {
+			kept, err := summaries(period, day, day.AddDate(0, 0, 1))
+			if err != nil {
+				return fmt.Errorf("rollup: reading %s summaries of %s: %v", period, day.Format("2006-01-02"), err)
+			}
+			merged := types.MergeSummaries(kept, types.Summarize(results, period))
+			if err := store(period, day, merged); err != nil {
+				return fmt.Errorf("rollup: storing %s summaries of %s: %v", period, day.Format("2006-01-02"), err)
+			}
+		}
+	}
+	return nil
+}
+
+// Expired returns whether the summaries of period for the
+// UTC day that starts at day are past the expiry of r, which
+// like check files only happens to complete days.
+func Expired(r types.Rollup, period string, day time.Time) bool {
+	expiry := r.Expiry(period)
+	return expiry != 0 && day.Before(Cutoff(expiry))
+}
+
+// InRange returns whether the summary file for the UTC day
+// that starts at day may hold summaries that start between
+// from and to. Zero times are unbounded.
+func InRange(day, from, to time.Time) bool {
+	return (from.IsZero() || day.Add(24*time.Hour).After(from)) &&
+		(to.IsZero() || day.Before(to))
+}
+
+// Filter returns the summaries that start at or after from
+// and before to. Zero times are unbounded.
+func Filter(summaries []types.Summary, from, to time.Time) []types.Summary {
+	var filtered []types.Summary
+	for _, s := range summaries {
+		if (from.IsZero() || s.Start >= from.UnixNano()) && (to.IsZero() || s.Start < to.UnixNano()) {
+			filtered = append(filtered, s)
+		}
+	}
+	return filtered
+}
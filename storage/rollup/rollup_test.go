@@ -0,0 +1,86 @@
+package rollup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+func TestFilename(t *testing.T) {
+	day := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, period := range Periods {
+		name := Filename(period, day)
+		p, d, ok := ParseFilename(name)
+		if !ok || p != period || !d.Equal(day) {
+			t.Errorf("Expected %s to parse as %s of %s, got %v %s %s", name, period, day, ok, p, d)
+		}
+	}
+	for _, name := range []string{"1-check.json", "1-week-summary.json", "1-hour-summary.json.gz", "x-day-summary.json"} {
+		if _, _, ok := ParseFilename(name); ok {
+			t.Errorf("Expected %s not to be a summary file", name)
+		}
+	}
+}
+
+func TestRoll(t *testing.T) {
+	cutoff := Cutoff(48 * time.Hour)
+	day := cutoff.AddDate(0, 0, -1)
+	index := map[string]int64{
+		"1-check.json": day.Add(time.Hour).UnixNano(),
+		"2-check.json": day.Add(2 * time.Hour).UnixNano(),
+		"3-check.json": cutoff.Add(time.Hour).UnixNano(),
+	}
+	fetch := func(name string) ([]types.Result, error) {
+		return []types.Result{{Title: name, Timestamp: index[name], Healthy: true,
+			Times: types.Attempts{{RTT: time.Millisecond}}}}, nil
+	}
+	stored := make(map[string][]types.Summary)
+	summaries := func(period string, from, to time.Time) ([]types.Summary, error) {
+		return Filter(stored[period], from, to), nil
+	}
+	store := func(period string, d time.Time, summaries []types.Summary) error {
+		if !d.Equal(day) {
+			t.Errorf("Expected summaries of %s, got %s", day, d)
+		}
+		stored[period] = summaries
+		return nil
+	}
+	if err := Roll(index, cutoff, fetch, summaries, store); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got, want := len(stored[types.PeriodHour]), 2; got != want {
+		t.Errorf("Expected %d hourly summaries, got %d", want, got)
+	}
+	if got, want := len(stored[types.PeriodDay]), 2; got != want {
+		t.Errorf("Expected %d daily summaries, got %d", want, got)
+	}
+
+	// Check files added to a rolled up day are merged into
+	// its summaries
+	index = map[string]int64{"1-check.json": day.Add(time.Hour + time.Minute).UnixNano()}
+	if err := Roll(index, cutoff, fetch, summaries, store); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got, want := len(stored[types.PeriodHour]), 2; got != want {
+		t.Errorf("Expected %d hourly summaries, got %d", want, got)
+	}
+	if s := stored[types.PeriodHour][0]; s.Title != "1-check.json" || s.Results != 2 || s.RTTs != 2 || s.Uptime != 100 {
+		t.Errorf("Expected summaries of the first hour to be merged, got %+v", s)
+	}
+
+	r := types.Rollup{HourlyExpiry: 24 * time.Hour}
+	if !Expired(r, types.PeriodHour, day) {
+		t.Error("Expected hourly summaries to be expired")
+	}
+	if Expired(r, types.PeriodDay, day) {
+		t.Error("Expected daily summaries to be kept forever")
+	}
+
+	if got := Filter(stored[types.PeriodHour], day.Add(90*time.Minute), time.Time{}); len(got) != 1 || got[0].Title != "2-check.json" {
+		t.Errorf("Expected summaries from the second hour, got %+v", got)
+	}
+	if !InRange(day, day.Add(time.Hour), day.Add(2*time.Hour)) || InRange(day, cutoff, time.Time{}) {
+		t.Error("Expected day to be in range only of times during it")
+	}
+}
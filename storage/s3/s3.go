@@ -4,16 +4,21 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/client"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/s3"
 
+	"github.com/sourcegraph/checkup/storage/codec"
 	"github.com/sourcegraph/checkup/storage/fs"
+	"github.com/sourcegraph/checkup/storage/rollup"
 	"github.com/sourcegraph/checkup/types"
 )
 
@@ -22,16 +27,52 @@ const Type = "s3"
 
 // Storage is a way to store checkup results in an S3 bucket.
 type Storage struct {
+	// AccessKeyID and SecretAccessKey are static
+	// credentials to use. If empty, credentials are found
+	// by the default chain of the AWS SDK: environment
+	// variables, shared credentials file, then instance
+	// or container roles.
 	AccessKeyID     string `json:"access_key_id"`
 	SecretAccessKey string `json:"secret_access_key"`
 	Region          string `json:"region,omitempty"`
 	Bucket          string `json:"bucket"`
 
+	// Profile is the name of the profile in the shared
+	// AWS config and credentials files to use.
+	Profile string `json:"profile,omitempty"`
+
+	// RoleARN is the ARN of a role to assume with the
+	// credentials, and ExternalID is the external ID
+	// to assume it with, if required.
+	RoleARN    string `json:"role_arn,omitempty"`
+	ExternalID string `json:"external_id,omitempty"`
+
+	// Endpoint is the URL of an S3-compatible service,
+	// such as MinIO, Ceph or Cloudflare R2, to use
+	// instead of AWS.
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// ForcePathStyle controls whether to address the bucket
+	// in the path of URLs rather than in the host name,
+	// which many S3-compatible services require.
+	ForcePathStyle bool `json:"force_path_style,omitempty"`
+
+	// Prefix is prepended to the keys of check files,
+	// such as "checks/" to store them in a folder.
+	Prefix string `json:"prefix,omitempty"`
+
 	// Check files older than CheckExpiry will be
 	// deleted on calls to Maintain(). If this is
 	// the zero value, no old check files will be
 	// deleted.
 	CheckExpiry time.Duration `json:"check_expiry,omitempty"`
+
+	// Rollup, if set, makes Maintain() summarize check files
+	// per endpoint, hour and day before deleting them.
+	Rollup *types.Rollup `json:"rollup,omitempty"`
+
+	// Codec compresses and encrypts check files.
+	codec.Codec
 }
 
 // New creates a new Storage instance based on json config
@@ -48,46 +89,157 @@ func (Storage) Type() string {
 
 // Store stores results on S3 according to the configuration in s.
 func (s Storage) Store(results []types.Result) error {
+	return s.StoreAs(*fs.GenerateFilename(), 0, results)
+}
+
+// StoreAs stores results in the check file with the given
+// name, relative to s.Prefix. The timestamp is ignored: the
+// index is built from the names of the check files, so name
+// should be in the format of fs.FilenameFormatString.
+func (s Storage) StoreAs(name string, timestamp int64, results []types.Result) error {
 	jsonBytes, err := json.Marshal(results)
 	if err != nil {
 		return err
 	}
-	config := &aws.Config{
-		Region: &s.Region,
+	return s.put(name, jsonBytes)
+}
+
+// put encodes jsonBytes with the codec of s and writes them
+// to the object with the given name, relative to s.Prefix.
+func (s Storage) put(name string, jsonBytes []byte) error {
+	contents, err := s.Encode(jsonBytes)
+	if err != nil {
+		return err
 	}
-	if s.AccessKeyID != "" && s.SecretAccessKey != "" {
-		config.Credentials = credentials.NewStaticCredentials(s.AccessKeyID, s.SecretAccessKey, "")
+	svc, err := s.service()
+	if err != nil {
+		return err
 	}
-
-	svc := newS3(session.New(), config)
 	params := &s3.PutObjectInput{
-		Bucket: &s.Bucket,
-		Key:    fs.GenerateFilename(),
-		Body:   bytes.NewReader(jsonBytes),
+		Bucket:      &s.Bucket,
+		Key:         aws.String(s.Prefix + name),
+		Body:        bytes.NewReader(contents),
+		ContentType: aws.String(s.ContentType()),
+	}
+	if encoding := s.ContentEncoding(); encoding != "" {
+		// lets browsers, and the status page, decompress check files
+		params.ContentEncoding = aws.String(encoding)
 	}
 	_, err = svc.PutObject(params)
 	return err
 }
 
+// Fetch fetches results of the check file with the given
+// name, relative to s.Prefix.
+func (s Storage) Fetch(name string) ([]types.Result, error) {
+	svc, err := s.service()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := svc.GetObject(&s3.GetObjectInput{
+		Bucket: &s.Bucket,
+		Key:    aws.String(s.Prefix + name),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	contents, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	contents, err = s.Decode(contents)
+	if err != nil {
+		return nil, err
+	}
+	var results []types.Result
+	err = json.Unmarshal(contents, &results)
+	return results, err
+}
+
+// GetIndex returns the index of check files in the bucket,
+// as a map of their names relative to s.Prefix to their
+// timestamps. The timestamps are taken from the names, so
+// objects with other names are skipped.
+func (s Storage) GetIndex() (map[string]int64, error) {
+	svc, err := s.service()
+	if err != nil {
+		return nil, err
+	}
+
+	index := map[string]int64{}
+	var marker *string
+	for {
+		listResp, err := svc.ListObjects(&s3.ListObjectsInput{
+			Bucket: &s.Bucket,
+			Prefix: &s.Prefix,
+			Marker: marker,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, o := range listResp.Contents {
+			if o == nil || o.Key == nil {
+				continue
+			}
+			name := strings.TrimPrefix(*o.Key, s.Prefix)
+			var ts int64
+			if _, err := fmt.Sscanf(name, fs.FilenameFormatString, &ts); err != nil {
+				continue
+			}
+			if name != fmt.Sprintf(fs.FilenameFormatString, ts) {
+				continue
+			}
+			index[name] = ts
+		}
+
+		if listResp.IsTruncated == nil || !*listResp.IsTruncated || len(listResp.Contents) == 0 {
+			break
+		}
+		marker = listResp.Contents[len(listResp.Contents)-1].Key
+	}
+
+	return index, nil
+}
+
 // Maintain deletes check files that are older than s.CheckExpiry.
+// If s.Rollup is set, they are summarized before being deleted,
+// and expired summaries are deleted.
 func (s Storage) Maintain() error {
 	if s.CheckExpiry == 0 {
 		return nil
 	}
 
-	config := &aws.Config{
-		Region: &s.Region,
-	}
-	if s.AccessKeyID != "" && s.SecretAccessKey != "" {
-		config.Credentials = credentials.NewStaticCredentials(s.AccessKeyID, s.SecretAccessKey, "")
+	// With a rollup, check files are deleted by the timestamp
+	// in their name rather than their modification time, so
+	// that exactly the check files summarized are deleted.
+	var (
+		index  map[string]int64
+		cutoff time.Time
+	)
+	if s.Rollup != nil {
+		var err error
+		if index, err = s.GetIndex(); err != nil {
+			return err
+		}
+		cutoff = rollup.Cutoff(s.CheckExpiry)
+		if err := rollup.Roll(index, cutoff, s.Fetch, s.Summaries, s.storeSummaries); err != nil {
+			return err
+		}
 	}
 
-	svc := newS3(session.New(), config)
+	svc, err := s.service()
+	if err != nil {
+		return err
+	}
 
 	var marker *string
 	for {
 		listParams := &s3.ListObjectsInput{
 			Bucket: &s.Bucket,
+			Prefix: &s.Prefix,
 			Marker: marker,
 		}
 		listResp, err := svc.ListObjects(listParams)
@@ -97,7 +249,20 @@ func (s Storage) Maintain() error {
 
 		var objsToDelete []*s3.ObjectIdentifier
 		for _, o := range listResp.Contents {
-			if o == nil || o.LastModified == nil {
+			if o == nil || o.Key == nil || o.LastModified == nil {
+				continue
+			}
+			name := strings.TrimPrefix(*o.Key, s.Prefix)
+			if period, day, ok := rollup.ParseFilename(name); ok {
+				if s.Rollup != nil && rollup.Expired(*s.Rollup, period, day) {
+					objsToDelete = append(objsToDelete, &s3.ObjectIdentifier{Key: o.Key})
+				}
+				continue
+			}
+			if nsec, ok := index[name]; ok {
+				if nsec < cutoff.UnixNano() {
+					objsToDelete = append(objsToDelete, &s3.ObjectIdentifier{Key: o.Key})
+				}
 				continue
 			}
 			if time.Since(*o.LastModified) > s.CheckExpiry {
@@ -105,31 +270,97 @@ func (s Storage) Maintain() error {
 			}
 		}
 
-		if len(objsToDelete) == 0 {
+		if len(objsToDelete) > 0 {
+			delParams := &s3.DeleteObjectsInput{
+				Bucket: &s.Bucket,
+				Delete: &s3.Delete{
+					Objects: objsToDelete,
+					Quiet:   aws.Bool(true),
+				},
+			}
+
+			_, err = svc.DeleteObjects(delParams)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Summary files sort before check files, so pages with
+		// nothing to delete don't mean there is nothing left.
+		if listResp.IsTruncated == nil || !*listResp.IsTruncated || len(listResp.Contents) == 0 {
 			break
 		}
 
-		delParams := &s3.DeleteObjectsInput{
+		marker = listResp.Contents[len(listResp.Contents)-1].Key
+	}
+
+	return nil
+}
+
+// storeSummaries writes the summaries of period for the
+// UTC day that starts at day to their summary file.
+func (s Storage) storeSummaries(period string, day time.Time, summaries []types.Summary) error {
+	jsonBytes, err := json.Marshal(summaries)
+	if err != nil {
+		return err
+	}
+	return s.put(rollup.Filename(period, day), jsonBytes)
+}
+
+// Summaries returns the summaries of period that start at
+// or after from and before to, which are unbounded if zero.
+func (s Storage) Summaries(period string, from, to time.Time) ([]types.Summary, error) {
+	svc, err := s.service()
+	if err != nil {
+		return nil, err
+	}
+
+	var summaries []types.Summary
+	var marker *string
+	for {
+		listResp, err := svc.ListObjects(&s3.ListObjectsInput{
 			Bucket: &s.Bucket,
-			Delete: &s3.Delete{
-				Objects: objsToDelete,
-				Quiet:   aws.Bool(true),
-			},
+			Prefix: &s.Prefix,
+			Marker: marker,
+		})
+		if err != nil {
+			return nil, err
 		}
 
-		_, err = svc.DeleteObjects(delParams)
-		if err != nil {
-			return err
+		for _, o := range listResp.Contents {
+			if o == nil || o.Key == nil {
+				continue
+			}
+			p, day, ok := rollup.ParseFilename(strings.TrimPrefix(*o.Key, s.Prefix))
+			if !ok || p != period || !rollup.InRange(day, from, to) {
+				continue
+			}
+			resp, err := svc.GetObject(&s3.GetObjectInput{Bucket: &s.Bucket, Key: o.Key})
+			if err != nil {
+				return nil, err
+			}
+			contents, err := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			contents, err = s.Decode(contents)
+			if err != nil {
+				return nil, err
+			}
+			var daySummaries []types.Summary
+			if err := json.Unmarshal(contents, &daySummaries); err != nil {
+				return nil, err
+			}
+			summaries = append(summaries, rollup.Filter(daySummaries, from, to)...)
 		}
 
-		if !*listResp.IsTruncated {
+		if listResp.IsTruncated == nil || !*listResp.IsTruncated || len(listResp.Contents) == 0 {
 			break
 		}
-
 		marker = listResp.Contents[len(listResp.Contents)-1].Key
 	}
-
-	return nil
+	return summaries, nil
 }
 
 // Provision creates a new IAM user in the account specified
@@ -146,14 +377,20 @@ func (s Storage) Provision() (types.ProvisionInfo, error) {
 	const iamUser = "checkup-monitor-s3-public"
 	var info types.ProvisionInfo
 
+	sess, config, err := s.session()
+	if err != nil {
+		return info, err
+	}
+
 	// default region (required, but regions don't apply to S3, kinda weird)
-	if s.Region == "" {
-		s.Region = "us-east-1"
+	if config.Region == nil && aws.StringValue(sess.Config.Region) == "" {
+		config.Region = aws.String("us-east-1")
 	}
 
-	svcIam := iam.New(session.New(), &aws.Config{
-		Credentials: credentials.NewStaticCredentials(s.AccessKeyID, s.SecretAccessKey, ""),
-		Region:      &s.Region,
+	// IAM has its own endpoint, even if S3 is elsewhere
+	svcIam := iam.New(sess, &aws.Config{
+		Credentials: config.Credentials,
+		Region:      config.Region,
 	})
 
 	// Create a new user, just for reading the check files
@@ -186,10 +423,7 @@ func (s Storage) Provision() (types.ProvisionInfo, error) {
 	info.PublicAccessKey = *resp3.AccessKey.SecretAccessKey
 
 	// Prepare to talk to S3
-	svcS3 := s3.New(session.New(), &aws.Config{
-		Credentials: credentials.NewStaticCredentials(s.AccessKeyID, s.SecretAccessKey, ""),
-		Region:      &s.Region,
-	})
+	svcS3 := s3.New(sess, config)
 
 	// Create a bucket to hold all the checks
 	_, err = svcS3.CreateBucket(&s3.CreateBucketInput{
@@ -241,6 +475,60 @@ func (s Storage) Provision() (types.ProvisionInfo, error) {
 	return info, nil
 }
 
+// service returns a client for the S3 service according
+// to the configuration in s.
+func (s Storage) service() (s3svc, error) {
+	sess, config, err := s.session()
+	if err != nil {
+		return nil, err
+	}
+	return newS3(sess, config), nil
+}
+
+// session returns an AWS session and the config for S3
+// clients according to the configuration in s: its
+// credentials, profile, role, endpoint and addressing.
+func (s Storage) session() (*session.Session, *aws.Config, error) {
+	config := &aws.Config{}
+	if s.Region != "" {
+		config.Region = &s.Region
+	} else if s.Endpoint != "" {
+		// S3-compatible services need a region for signing
+		config.Region = aws.String("us-east-1")
+	}
+	if s.AccessKeyID != "" && s.SecretAccessKey != "" {
+		config.Credentials = credentials.NewStaticCredentials(s.AccessKeyID, s.SecretAccessKey, "")
+	}
+	if s.Endpoint != "" {
+		config.Endpoint = &s.Endpoint
+	}
+	if s.ForcePathStyle {
+		config.S3ForcePathStyle = aws.Bool(true)
+	}
+
+	opts := session.Options{
+		Config:  *config,
+		Profile: s.Profile,
+	}
+	if s.Profile != "" {
+		opts.SharedConfigState = session.SharedConfigEnable
+	}
+	sess, err := session.NewSessionWithOptions(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if s.RoleARN != "" {
+		config.Credentials = stscreds.NewCredentials(sess, s.RoleARN, func(p *stscreds.AssumeRoleProvider) {
+			if s.ExternalID != "" {
+				p.ExternalID = &s.ExternalID
+			}
+		})
+	}
+
+	return sess, config, nil
+}
+
 // newS3 calls s3.New(), but may be replaced for mocking in tests.
 var newS3 = func(p client.ConfigProvider, cfgs ...*aws.Config) s3svc {
 	return s3.New(p, cfgs...)
@@ -249,6 +537,7 @@ var newS3 = func(p client.ConfigProvider, cfgs ...*aws.Config) s3svc {
 // s3svc is used for mocking the s3.S3 type.
 type s3svc interface {
 	PutObject(*s3.PutObjectInput) (*s3.PutObjectOutput, error)
+	GetObject(*s3.GetObjectInput) (*s3.GetObjectOutput, error)
 	ListObjects(*s3.ListObjectsInput) (*s3.ListObjectsOutput, error)
 	DeleteObjects(*s3.DeleteObjectsInput) (*s3.DeleteObjectsOutput, error)
 }
@@ -2,9 +2,15 @@ package s3
 
 import (
 	"bytes"
+	"encoding/xml"
+	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -12,6 +18,8 @@ import (
 	"github.com/aws/aws-sdk-go/aws/client"
 	"github.com/aws/aws-sdk-go/service/s3"
 
+	"github.com/sourcegraph/checkup/storage/codec"
+	"github.com/sourcegraph/checkup/storage/rollup"
 	"github.com/sourcegraph/checkup/types"
 )
 
@@ -118,6 +126,10 @@ func (s *s3Mock) PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error
 	return nil, nil
 }
 
+func (s *s3Mock) GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	return &s3.GetObjectOutput{Body: ioutil.NopCloser(strings.NewReader("[]"))}, nil
+}
+
 func (s *s3Mock) ListObjects(input *s3.ListObjectsInput) (*s3.ListObjectsOutput, error) {
 	return &s3.ListObjectsOutput{
 		Contents: []*s3.Object{
@@ -134,3 +146,252 @@ func (s *s3Mock) DeleteObjects(input *s3.DeleteObjectsInput) (*s3.DeleteObjectsO
 	s.deleted = true
 	return nil, nil
 }
+
+// standIn is a minimal S3-compatible service that serves a
+// single bucket with path-style addressing.
+type standIn struct {
+	sync.Mutex
+	bucket    string
+	objects   map[string][]byte
+	encodings map[string]string
+	mimeTypes map[string]string
+	perPage   int
+}
+
+func (s *standIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	parts := strings.SplitN(path, "/", 2)
+	if parts[0] != s.bucket {
+		http.Error(w, "NoSuchBucket", http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case len(parts) == 2 && r.Method == http.MethodPut:
+		body, _ := ioutil.ReadAll(r.Body)
+		s.objects[parts[1]] = body
+		s.encodings[parts[1]] = r.Header.Get("Content-Encoding")
+		s.mimeTypes[parts[1]] = r.Header.Get("Content-Type")
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		body, ok := s.objects[parts[1]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<Error><Code>NoSuchKey</Code></Error>`)
+			return
+		}
+		if encoding := s.encodings[parts[1]]; encoding != "" {
+			w.Header().Set("Content-Encoding", encoding)
+		}
+		w.Write(body)
+	case r.Method == http.MethodGet:
+		s.list(w, r.URL.Query().Get("prefix"), r.URL.Query().Get("marker"))
+	case r.Method == http.MethodPost && r.URL.Query()["delete"] != nil:
+		var del struct {
+			Objects []struct {
+				Key string
+			} `xml:"Object"`
+		}
+		xml.NewDecoder(r.Body).Decode(&del)
+		for _, o := range del.Objects {
+			delete(s.objects, o.Key)
+		}
+		fmt.Fprint(w, `<DeleteResult></DeleteResult>`)
+	default:
+		http.Error(w, "NotImplemented", http.StatusNotImplemented)
+	}
+}
+
+func (s *standIn) list(w http.ResponseWriter, prefix, marker string) {
+	var keys []string
+	for key := range s.objects {
+		if strings.HasPrefix(key, prefix) && key > marker {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	truncated := len(keys) > s.perPage
+	if truncated {
+		keys = keys[:s.perPage]
+	}
+
+	fmt.Fprintf(w, `<ListBucketResult><Name>%s</Name><IsTruncated>%v</IsTruncated>`, s.bucket, truncated)
+	for _, key := range keys {
+		fmt.Fprintf(w, `<Contents><Key>%s</Key><LastModified>%s</LastModified><Size>%d</Size></Contents>`,
+			key, time.Now().UTC().Add(-time.Hour).Format(time.RFC3339), len(s.objects[key]))
+	}
+	fmt.Fprint(w, `</ListBucketResult>`)
+}
+
+func TestS3StandIn(t *testing.T) {
+	newS3 = func(p client.ConfigProvider, cfgs ...*aws.Config) s3svc {
+		return s3.New(p, cfgs...)
+	}
+	backend := &standIn{
+		bucket: "checkup",
+		objects: map[string][]byte{
+			"other-check.json":       []byte("[]"),
+			"checks/readme.txt":      []byte("hello"),
+			"checks/1-check.json":    []byte(`[{"title":"Old"}]`),
+			"checks/2-check.json.gz": []byte("binary"),
+		},
+		encodings: map[string]string{},
+		mimeTypes: map[string]string{},
+		perPage:   2,
+	}
+	srv := httptest.NewServer(backend)
+	defer srv.Close()
+
+	specimen := Storage{
+		AccessKeyID:     "fakeKeyID",
+		SecretAccessKey: "fakeKey",
+		Bucket:          "checkup",
+		Endpoint:        srv.URL,
+		ForcePathStyle:  true,
+		Prefix:          "checks/",
+	}
+
+	results := []types.Result{{Title: "Testing"}}
+	if err := specimen.Store(results); err != nil {
+		t.Fatalf("Expected no error from Store(), got: %v", err)
+	}
+
+	index, err := specimen.GetIndex()
+	if err != nil {
+		t.Fatalf("Expected no error from GetIndex(), got: %v", err)
+	}
+	if got, want := len(index), 2; got != want {
+		t.Fatalf("Expected %d check files in index, got %d: %v", want, got, index)
+	}
+	if got, want := index["1-check.json"], int64(1); got != want {
+		t.Errorf("Expected timestamp %d, got %d", want, got)
+	}
+
+	for name, ts := range index {
+		if ts == 1 {
+			continue
+		}
+		if time.Since(time.Unix(0, ts)) > time.Minute {
+			t.Errorf("Expected recent timestamp for %s, got %s", name, time.Unix(0, ts))
+		}
+		fetched, err := specimen.Fetch(name)
+		if err != nil {
+			t.Fatalf("Expected no error from Fetch(), got: %v", err)
+		}
+		if len(fetched) != 1 || fetched[0].Title != "Testing" {
+			t.Errorf("Expected stored results, got %+v", fetched)
+		}
+	}
+
+	if _, err := specimen.Fetch("3-check.json"); err == nil {
+		t.Error("Expected an error fetching a missing check file")
+	}
+
+	specimen.CheckExpiry = time.Minute
+	if err := specimen.Maintain(); err != nil {
+		t.Fatalf("Expected no error from Maintain(), got: %v", err)
+	}
+	if _, ok := backend.objects["other-check.json"]; !ok {
+		t.Error("Expected objects outside of the prefix to be kept")
+	}
+	if _, ok := backend.objects["checks/1-check.json"]; ok {
+		t.Error("Expected old check file to be deleted")
+	}
+
+	// Compressed check files are served with a Content-Encoding
+	specimen.Compression = codec.CompressionGzip
+	if err := specimen.StoreAs("5-check.json", 5, results); err != nil {
+		t.Fatalf("Expected no error from StoreAs(), got: %v", err)
+	}
+	if got := backend.encodings["checks/5-check.json"]; got != "gzip" {
+		t.Errorf("Expected gzip content encoding, got %q", got)
+	}
+	if got := backend.mimeTypes["checks/5-check.json"]; got != "application/json" {
+		t.Errorf("Expected JSON content type, got %q", got)
+	}
+	if body := backend.objects["checks/5-check.json"]; !bytes.HasPrefix(body, []byte{0x1f, 0x8b}) {
+		t.Errorf("Expected check file to be compressed, got %q", body)
+	}
+	fetched, err := specimen.Fetch("5-check.json")
+	if err != nil {
+		t.Fatalf("Expected no error from Fetch(), got: %v", err)
+	}
+	if len(fetched) != 1 || fetched[0].Title != "Testing" {
+		t.Errorf("Expected stored results, got %+v", fetched)
+	}
+
+	// Other compressed check files can't be decoded by browsers
+	specimen.Compression = codec.CompressionZstd
+	if err := specimen.StoreAs("6-check.json", 6, results); err != nil {
+		t.Fatalf("Expected no error from StoreAs(), got: %v", err)
+	}
+	if got := backend.encodings["checks/6-check.json"]; got != "" {
+		t.Errorf("Expected no content encoding, got %q", got)
+	}
+	if got := backend.mimeTypes["checks/6-check.json"]; got != "application/octet-stream" {
+		t.Errorf("Expected binary content type, got %q", got)
+	}
+}
+
+func TestS3Rollup(t *testing.T) {
+	newS3 = func(p client.ConfigProvider, cfgs ...*aws.Config) s3svc {
+		return s3.New(p, cfgs...)
+	}
+	day := types.PeriodStart(types.PeriodDay, time.Now()).AddDate(0, 0, -3)
+	old, recent := day.Add(time.Hour).UnixNano(), time.Now().UnixNano()
+	backend := &standIn{
+		bucket: "checkup",
+		objects: map[string][]byte{
+			fmt.Sprintf("checks/%d-check.json", old):    []byte(fmt.Sprintf(`[{"title":"Old","timestamp":%d,"healthy":true}]`, old)),
+			fmt.Sprintf("checks/%d-check.json", recent): []byte(fmt.Sprintf(`[{"title":"New","timestamp":%d}]`, recent)),
+			"checks/1-day-summary.json":                 []byte(`[]`),
+		},
+		encodings: map[string]string{},
+		mimeTypes: map[string]string{},
+		perPage:   2,
+	}
+	// Retained summary files sort before the check files and
+	// fill whole pages with nothing to delete
+	for i := 10; i < 14; i++ {
+		name := "checks/" + rollup.Filename(types.PeriodDay, day.AddDate(0, 0, -i))
+		backend.objects[name] = []byte(`[]`)
+	}
+	srv := httptest.NewServer(backend)
+	defer srv.Close()
+
+	specimen := Storage{
+		AccessKeyID:     "fakeKeyID",
+		SecretAccessKey: "fakeKey",
+		Bucket:          "checkup",
+		Endpoint:        srv.URL,
+		ForcePathStyle:  true,
+		Prefix:          "checks/",
+		CheckExpiry:     24 * time.Hour,
+		Rollup:          &types.Rollup{DailyExpiry: 365 * 24 * time.Hour},
+	}
+	if err := specimen.Maintain(); err != nil {
+		t.Fatalf("Expected no error from Maintain(), got: %v", err)
+	}
+
+	// The stand-in reports check files as modified an hour
+	// ago, as if they were copied in with StoreAs
+	if _, ok := backend.objects[fmt.Sprintf("checks/%d-check.json", old)]; ok {
+		t.Error("Expected rolled up check file to be deleted")
+	}
+	if _, ok := backend.objects[fmt.Sprintf("checks/%d-check.json", recent)]; !ok {
+		t.Error("Expected check file of the current day to be kept until it is rolled up")
+	}
+	if _, ok := backend.objects["checks/1-day-summary.json"]; ok {
+		t.Error("Expected expired summary file to be deleted")
+	}
+
+	summaries, err := specimen.Summaries(types.PeriodDay, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Expected no error from Summaries(), got: %v", err)
+	}
+	if len(summaries) != 1 || summaries[0].Title != "Old" || summaries[0].Start != day.UnixNano() || summaries[0].Uptime != 100 {
+		t.Errorf("Expected daily summary of the old check file, got %+v", summaries)
+	}
+}
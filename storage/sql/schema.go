@@ -0,0 +1,204 @@
+// +build sql
+
+package sql
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// migrations upgrade the database schema one version at a
+// time: migrations[i] upgrades the schema from version i
+// to version i+1. Version 1 is the original table of JSON
+// blobs, which predates the schema_version table.
+//
+// Titles and endpoints have no length limit, so they are
+// stored as TEXT, which MySQL can't index as is; they are
+// indexed and keyed by their textHash instead.
+var migrations = []migration{
+	{
+		statements: []string{
+			`CREATE TABLE checks (
+    name VARCHAR(255) NOT NULL PRIMARY KEY,
+    timestamp INT8 NOT NULL,
+    results TEXT
+)`,
+			`CREATE UNIQUE INDEX idx_checks_timestamp ON checks(timestamp)`,
+		},
+	},
+	{
+		statements: []string{
+			`CREATE TABLE schema_version (
+    version INTEGER NOT NULL
+)`,
+			`INSERT INTO schema_version (version) VALUES (1)`,
+			`CREATE TABLE results (
+    check_name VARCHAR(255) NOT NULL,
+    position INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    title_hash VARCHAR(64) NOT NULL,
+    endpoint TEXT NOT NULL,
+    endpoint_hash VARCHAR(64) NOT NULL,
+    timestamp INT8 NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    data TEXT NOT NULL,
+    PRIMARY KEY (check_name, position)
+)`,
+			`CREATE INDEX idx_results_title ON results(title_hash)`,
+			`CREATE INDEX idx_results_endpoint ON results(endpoint_hash)`,
+			`CREATE INDEX idx_results_status ON results(status)`,
+			`CREATE INDEX idx_results_timestamp ON results(timestamp)`,
+			`CREATE TABLE attempts (
+    check_name VARCHAR(255) NOT NULL,
+    result_position INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    rtt INT8 NOT NULL,
+    error TEXT NOT NULL,
+    PRIMARY KEY (check_name, result_position, position)
+)`,
+		},
+		convert: normalizeChecks,
+	},
+	{
+		statements: []string{
+			`CREATE TABLE summaries (
+    period VARCHAR(8) NOT NULL,
+    start INT8 NOT NULL,
+    title TEXT NOT NULL,
+    title_hash VARCHAR(64) NOT NULL,
+    endpoint TEXT NOT NULL,
+    endpoint_hash VARCHAR(64) NOT NULL,
+    data TEXT NOT NULL,
+    PRIMARY KEY (period, start, title_hash, endpoint_hash)
+)`,
+			`CREATE INDEX idx_summaries_start ON summaries(start)`,
+		},
+	},
+}
+
+// migration is a single upgrade of the database schema.
+type migration struct {
+	// statements are executed in order to change the schema.
+	statements []string
+
+	// convert, if set, is called after statements to convert
+	// the existing data to the new schema.
+	convert func(tx *sqlx.Tx) error
+}
+
+// schemaVersion returns the version of the schema of db:
+// 0 for an empty database, 1 for the original table of
+// JSON blobs, or the version in the schema_version table.
+func schemaVersion(db *sqlx.DB) int {
+	var version int
+	if err := db.Get(&version, `SELECT version FROM schema_version`); err == nil {
+		return version
+	}
+	var count int
+	if err := db.Get(&count, `SELECT COUNT(*) FROM checks`); err == nil {
+		return 1
+	}
+	return 0
+}
+
+// migrate upgrades the schema of db to the latest version.
+// Each migration runs in its own transaction.
+func migrate(db *sqlx.DB) error {
+	for version := schemaVersion(db); version < len(migrations); version++ {
+		tx, err := db.Beginx()
+		if err != nil {
+			return err
+		}
+		if err := migrations[version].apply(tx, version+1); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply runs m in tx and records the new version.
+func (m migration) apply(tx *sqlx.Tx, version int) error {
+	for _, st := range m.statements {
+		if _, err := tx.Exec(st); err != nil {
+			return err
+		}
+	}
+	if m.convert != nil {
+		if err := m.convert(tx); err != nil {
+			return err
+		}
+	}
+	if version < 2 {
+		// version 1 is recorded by the absence of schema_version
+		return nil
+	}
+	_, err := tx.Exec(tx.Rebind(`UPDATE schema_version SET version = ?`), version)
+	return err
+}
+
+// normalizeChecks moves the results stored as JSON blobs in
+// the checks table into the results and attempts tables.
+func normalizeChecks(tx *sqlx.Tx) error {
+	var names []string
+	err := tx.Select(&names, `SELECT name FROM checks WHERE results IS NOT NULL`)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		var contents []byte
+		err := tx.Get(&contents, tx.Rebind(`SELECT results FROM checks WHERE name = ?`), name)
+		if err != nil {
+			return err
+		}
+		var results []types.Result
+		if err := json.Unmarshal(contents, &results); err != nil {
+			return err
+		}
+		if err := insertResults(tx, name, results); err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec(`UPDATE checks SET results = NULL`)
+	return err
+}
+
+// insertResults inserts results of the check with given name
+// into the results and attempts tables.
+func insertResults(tx *sqlx.Tx, name string, results []types.Result) error {
+	insertResult := tx.Rebind(`INSERT INTO results (check_name, position, title, title_hash, endpoint, endpoint_hash, timestamp, status, data) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	insertAttempt := tx.Rebind(`INSERT INTO attempts (check_name, result_position, position, rtt, error) VALUES (?, ?, ?, ?, ?)`)
+	for i, result := range results {
+		data, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(insertResult, name, i, result.Title, textHash(result.Title),
+			result.Endpoint, textHash(result.Endpoint), result.Timestamp, string(result.Status()), string(data))
+		if err != nil {
+			return err
+		}
+		for j, attempt := range result.Times {
+			_, err = tx.Exec(insertAttempt, name, i, j, int64(attempt.RTT), attempt.Error)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// textHash returns the hash of s that TEXT columns are
+// indexed by.
+func textHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
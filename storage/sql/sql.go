@@ -5,26 +5,28 @@ package sql
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net"
 	"strconv"
+	"sync"
 	"time"
 
+	"github.com/go-sql-driver/mysql" // Enable mysql backend
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"           // Enable postgresql beckend
 	_ "github.com/mattn/go-sqlite3" // Enable sqlite3 backend
 
 	"github.com/sourcegraph/checkup/storage/fs"
+	"github.com/sourcegraph/checkup/storage/rollup"
 	"github.com/sourcegraph/checkup/types"
 )
 
-// schema is the table schema expected by the sqlite3 checkup storage.
-const schema = `
-CREATE TABLE checks (
-    name TEXT NOT NULL PRIMARY KEY,
-    timestamp INT8 NOT NULL,
-    results TEXT
-);
-CREATE UNIQUE INDEX idx_checks_timestamp ON checks(timestamp);
-`
+// pools holds the open connection pools, keyed by driver
+// and data source name.
+var (
+	pools   = make(map[string]*sqlx.DB)
+	poolsMu sync.Mutex
+)
 
 // Storage is a way to store checkup results in a SQL database.
 type Storage struct {
@@ -41,11 +43,41 @@ type Storage struct {
 		SSLMode  string `json:"sslmode,omitempty"`
 	} `json:"postgresql"`
 
+	// MySQL contains the MySQL or MariaDB connection settings.
+	MySQL *struct {
+		Host     string            `json:"host,omitempty"`
+		Port     int               `json:"port,omitempty"`
+		User     string            `json:"user"`
+		Password string            `json:"password,omitempty"`
+		DBName   string            `json:"dbname"`
+		Params   map[string]string `json:"params,omitempty"`
+	} `json:"mysql"`
+
+	// DSN is a data source name passed as is to Driver,
+	// which must be one of "sqlite3", "postgres" or "mysql".
+	// It can be used instead of the other backend settings.
+	DSN    string `json:"dsn,omitempty"`
+	Driver string `json:"driver,omitempty"`
+
+	// MaxOpenConns, MaxIdleConns and ConnMaxLifetime limit
+	// the pool of connections to the database, which is
+	// shared by all Storages with the same settings and kept
+	// open until Close is called. Zero values use the
+	// defaults of database/sql, except for sqlite3, which
+	// defaults to a single open connection.
+	MaxOpenConns    int           `json:"max_open_conns,omitempty"`
+	MaxIdleConns    int           `json:"max_idle_conns,omitempty"`
+	ConnMaxLifetime time.Duration `json:"conn_max_lifetime,omitempty"`
+
 	// Check files older than CheckExpiry will be
 	// deleted on calls to Maintain(). If this is
 	// the zero value, no old check files will be
 	// deleted.
 	CheckExpiry time.Duration `json:"check_expiry,omitempty"`
+
+	// Rollup, if set, makes Maintain() summarize check files
+	// per endpoint, hour and day before deleting them.
+	Rollup *types.Rollup `json:"rollup,omitempty"`
 }
 
 // New creates a new Storage instance based on json config
@@ -60,25 +92,50 @@ func (Storage) Type() string {
 	return Type
 }
 
-func (sql Storage) dbConnect() (*sqlx.DB, error) {
+// dataSource returns the name of the driver and the data
+// source name of the configured database.
+func (sql Storage) dataSource() (string, string, error) {
 	// Only one SQL backend can be present
-	if sql.SqliteDBFile != "" && sql.PostgreSQL != nil {
-		return nil, errors.New("several SQL backends are configured")
+	var backends int
+	for _, configured := range []bool{
+		sql.SqliteDBFile != "",
+		sql.PostgreSQL != nil,
+		sql.MySQL != nil,
+		sql.DSN != "",
+	} {
+		if configured {
+			backends++
+		}
+	}
+	if backends > 1 {
+		return "", "", errors.New("several SQL backends are configured")
+	}
+
+	// Raw data source name
+	if sql.DSN != "" {
+		switch sql.Driver {
+		case "sqlite3", "postgres", "mysql":
+			return sql.Driver, sql.DSN, nil
+		case "":
+			return "", "", errors.New("missing driver for data source name")
+		default:
+			return "", "", fmt.Errorf("unknown SQL driver '%s'", sql.Driver)
+		}
 	}
 
 	// SQLite3 configuration
 	if sql.SqliteDBFile != "" {
-		return sqlx.Connect("sqlite3", sql.SqliteDBFile)
+		return "sqlite3", sql.SqliteDBFile, nil
 	}
 
 	// PostgreSQL configuration
 	if sql.PostgreSQL != nil {
 		var pgOptions string
 		if sql.PostgreSQL.DBName == "" {
-			return nil, errors.New("missing PostgreSQL database name")
+			return "", "", errors.New("missing PostgreSQL database name")
 		}
 		if sql.PostgreSQL.User == "" {
-			return nil, errors.New("missing PostgreSQL username")
+			return "", "", errors.New("missing PostgreSQL username")
 		}
 		if sql.PostgreSQL.Host != "" {
 			pgOptions += " host=" + sql.PostgreSQL.Host
@@ -94,12 +151,95 @@ func (sql Storage) dbConnect() (*sqlx.DB, error) {
 		if sql.PostgreSQL.SSLMode != "" {
 			pgOptions += " sslmode=" + sql.PostgreSQL.SSLMode
 		}
-		return sqlx.Connect("postgres", pgOptions)
+		return "postgres", pgOptions, nil
+	}
+
+	// MySQL configuration
+	if sql.MySQL != nil {
+		if sql.MySQL.DBName == "" {
+			return "", "", errors.New("missing MySQL database name")
+		}
+		if sql.MySQL.User == "" {
+			return "", "", errors.New("missing MySQL username")
+		}
+		cfg := mysql.NewConfig()
+		cfg.User = sql.MySQL.User
+		cfg.Passwd = sql.MySQL.Password
+		cfg.DBName = sql.MySQL.DBName
+		cfg.Params = sql.MySQL.Params
+		if sql.MySQL.Host != "" {
+			host := sql.MySQL.Host
+			port := sql.MySQL.Port
+			if port == 0 {
+				port = 3306
+			}
+			cfg.Net = "tcp"
+			cfg.Addr = net.JoinHostPort(host, strconv.Itoa(port))
+		}
+		return "mysql", cfg.FormatDSN(), nil
+	}
+
+	return "", "", errors.New("no configured database backend")
+}
+
+// dbConnect returns the connection pool of the configured
+// database. The pool is opened, and the schema migrated to
+// the latest version, on first use.
+func (sql Storage) dbConnect() (*sqlx.DB, error) {
+	driver, dsn, err := sql.dataSource()
+	if err != nil {
+		return nil, err
+	}
+
+	poolsMu.Lock()
+	defer poolsMu.Unlock()
+
+	key := driver + " " + dsn
+	if db, ok := pools[key]; ok {
+		return db, nil
+	}
+
+	db, err := sqlx.Connect(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	maxOpenConns := sql.MaxOpenConns
+	if maxOpenConns == 0 && driver == "sqlite3" {
+		// sqlite3 allows a single writer at a time
+		maxOpenConns = 1
+	}
+	db.SetMaxOpenConns(maxOpenConns)
+	if sql.MaxIdleConns != 0 {
+		db.SetMaxIdleConns(sql.MaxIdleConns)
+	}
+	db.SetConnMaxLifetime(sql.ConnMaxLifetime)
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
 	}
+	pools[key] = db
+	return db, nil
+}
 
-	// TODO: MySQL backend?
+// Close closes the connection pool of the database. The
+// pool is opened again if the Storage is used after Close.
+func (sql Storage) Close() error {
+	driver, dsn, err := sql.dataSource()
+	if err != nil {
+		return err
+	}
+
+	poolsMu.Lock()
+	defer poolsMu.Unlock()
 
-	return nil, errors.New("no configured database backend")
+	key := driver + " " + dsn
+	db, ok := pools[key]
+	if !ok {
+		return nil
+	}
+	delete(pools, key)
+	return db.Close()
 }
 
 // GetIndex returns the list of check results for the database.
@@ -108,7 +248,6 @@ func (sql Storage) GetIndex() (map[string]int64, error) {
 	if err != nil {
 		return nil, err
 	}
-	defer db.Close()
 
 	idx := make(map[string]int64)
 	var check struct {
@@ -116,20 +255,20 @@ func (sql Storage) GetIndex() (map[string]int64, error) {
 		Timestamp int64  `db:"timestamp"`
 	}
 
-	rows, err := db.Queryx(`SELECT name,timestamp FROM "checks"`)
+	rows, err := db.Queryx(`SELECT name, timestamp FROM checks`)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 	for rows.Next() {
 		err := rows.StructScan(&check)
 		if err != nil {
-			rows.Close()
 			return nil, err
 		}
 		idx[check.Name] = check.Timestamp
 	}
 
-	return idx, nil
+	return idx, rows.Err()
 }
 
 // Fetch fetches results of the check with given name.
@@ -138,42 +277,106 @@ func (sql Storage) Fetch(name string) ([]types.Result, error) {
 	if err != nil {
 		return nil, err
 	}
-	defer db.Close()
 
-	var checkResult []byte
-	var results []types.Result
+	var timestamp int64
+	err = db.Get(&timestamp, db.Rebind(`SELECT timestamp FROM checks WHERE name = ?`), name)
+	if err != nil {
+		return nil, err
+	}
 
-	err = db.Get(&checkResult, `SELECT results FROM "checks" WHERE name=$1 LIMIT 1`, name)
+	var data []string
+	err = db.Select(&data, db.Rebind(`SELECT data FROM results WHERE check_name = ? ORDER BY position`), name)
 	if err != nil {
 		return nil, err
 	}
-	if err = json.Unmarshal(checkResult, &results); err != nil {
+	return unmarshalResults(data)
+}
+
+// QueryResults returns the results that match q, ordered by
+// their timestamp.
+func (sql Storage) QueryResults(q types.ResultQuery) ([]types.Result, error) {
+	db, err := sql.dbConnect()
+	if err != nil {
 		return nil, err
 	}
+
+	query := `SELECT data FROM results WHERE 1 = 1`
+	var args []interface{}
+	if q.Title != "" {
+		query += ` AND title_hash = ? AND title = ?`
+		args = append(args, textHash(q.Title), q.Title)
+	}
+	if q.Endpoint != "" {
+		query += ` AND endpoint_hash = ? AND endpoint = ?`
+		args = append(args, textHash(q.Endpoint), q.Endpoint)
+	}
+	if q.Status != "" {
+		query += ` AND status = ?`
+		args = append(args, string(q.Status))
+	}
+	if !q.From.IsZero() {
+		query += ` AND timestamp >= ?`
+		args = append(args, q.From.UnixNano())
+	}
+	if !q.To.IsZero() {
+		query += ` AND timestamp < ?`
+		args = append(args, q.To.UnixNano())
+	}
+	query += ` ORDER BY timestamp, check_name, position`
+	if q.Limit > 0 {
+		query += fmt.Sprintf(` LIMIT %d`, q.Limit)
+	}
+
+	var data []string
+	if err := db.Select(&data, db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	return unmarshalResults(data)
+}
+
+// unmarshalResults decodes results stored as JSON.
+func unmarshalResults(data []string) ([]types.Result, error) {
+	results := make([]types.Result, len(data))
+	for i, d := range data {
+		if err := json.Unmarshal([]byte(d), &results[i]); err != nil {
+			return nil, err
+		}
+	}
 	return results, nil
 }
 
 // Store stores results in the database.
 func (sql Storage) Store(results []types.Result) error {
+	return sql.StoreAs(*fs.GenerateFilename(), time.Now().UnixNano(), results)
+}
+
+// StoreAs stores results as the check with the given name
+// and timestamp.
+func (sql Storage) StoreAs(name string, timestamp int64, results []types.Result) error {
 	db, err := sql.dbConnect()
 	if err != nil {
 		return err
 	}
-	defer db.Close()
 
-	name := *fs.GenerateFilename()
-	contents, err := json.Marshal(results)
+	tx, err := db.Beginx()
 	if err != nil {
 		return err
 	}
-
-	// Insert data
-	const insertResults = `INSERT INTO "checks" (name, timestamp, results) VALUES (?, ?, ?)`
-	_, err = db.Exec(insertResults, name, time.Now().UnixNano(), contents)
-	return err
+	const insertCheck = `INSERT INTO checks (name, timestamp) VALUES (?, ?)`
+	_, err = tx.Exec(tx.Rebind(insertCheck), name, timestamp)
+	if err == nil {
+		err = insertResults(tx, name, results)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
 }
 
 // Maintain deletes check files that are older than sql.CheckExpiry.
+// If sql.Rollup is set, they are summarized before being deleted,
+// and expired summaries are deleted.
 func (sql Storage) Maintain() error {
 	if sql.CheckExpiry == 0 {
 		return nil
@@ -183,22 +386,113 @@ func (sql Storage) Maintain() error {
 	if err != nil {
 		return err
 	}
-	defer db.Close()
 
-	const st = `DELETE FROM "checks" WHERE timestamp < ?`
 	ts := time.Now().Add(-1 * sql.CheckExpiry).UnixNano()
-	_, err = db.Exec(st, ts)
-	return err
+	if sql.Rollup != nil {
+		index, err := sql.GetIndex()
+		if err != nil {
+			return err
+		}
+		cutoff := rollup.Cutoff(sql.CheckExpiry)
+		if err := rollup.Roll(index, cutoff, sql.Fetch, sql.Summaries, sql.storeSummaries); err != nil {
+			return err
+		}
+		ts = cutoff.UnixNano()
+	}
+
+	expired := `SELECT name FROM checks WHERE timestamp < ?`
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	for _, st := range []string{
+		`DELETE FROM attempts WHERE check_name IN (` + expired + `)`,
+		`DELETE FROM results WHERE check_name IN (` + expired + `)`,
+		`DELETE FROM checks WHERE timestamp < ?`,
+	} {
+		if _, err := tx.Exec(tx.Rebind(st), ts); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if sql.Rollup != nil {
+		for _, period := range rollup.Periods {
+			expiry := sql.Rollup.Expiry(period)
+			if expiry == 0 {
+				continue
+			}
+			const deleteSummaries = `DELETE FROM summaries WHERE period = ? AND start < ?`
+			start := rollup.Cutoff(expiry).UnixNano()
+			if _, err := tx.Exec(tx.Rebind(deleteSummaries), period, start); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+	return tx.Commit()
 }
 
-// initialize creates the "checks" table in the database.
-func (sql Storage) initialize() error {
+// storeSummaries replaces the summaries of period for the
+// UTC day that starts at day.
+func (sql Storage) storeSummaries(period string, day time.Time, summaries []types.Summary) error {
 	db, err := sql.dbConnect()
 	if err != nil {
 		return err
 	}
-	defer db.Close()
 
-	_, err = db.Exec(schema)
-	return err
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	const deleteDay = `DELETE FROM summaries WHERE period = ? AND start >= ? AND start < ?`
+	_, err = tx.Exec(tx.Rebind(deleteDay), period, day.UnixNano(), day.AddDate(0, 0, 1).UnixNano())
+	for i := 0; err == nil && i < len(summaries); i++ {
+		s := summaries[i]
+		var data []byte
+		data, err = json.Marshal(s)
+		if err != nil {
+			break
+		}
+		const insertSummary = `INSERT INTO summaries (period, start, title, title_hash, endpoint, endpoint_hash, data) VALUES (?, ?, ?, ?, ?, ?, ?)`
+		_, err = tx.Exec(tx.Rebind(insertSummary), s.Period, s.Start, s.Title, textHash(s.Title),
+			s.Endpoint, textHash(s.Endpoint), string(data))
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Summaries returns the summaries of period that start at
+// or after from and before to, which are unbounded if zero.
+func (sql Storage) Summaries(period string, from, to time.Time) ([]types.Summary, error) {
+	db, err := sql.dbConnect()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT data FROM summaries WHERE period = ?`
+	args := []interface{}{period}
+	if !from.IsZero() {
+		query += ` AND start >= ?`
+		args = append(args, from.UnixNano())
+	}
+	if !to.IsZero() {
+		query += ` AND start < ?`
+		args = append(args, to.UnixNano())
+	}
+	query += ` ORDER BY start, title, endpoint`
+
+	var data []string
+	if err := db.Select(&data, db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	summaries := make([]types.Summary, len(data))
+	for i, d := range data {
+		if err := json.Unmarshal([]byte(d), &summaries[i]); err != nil {
+			return nil, err
+		}
+	}
+	return summaries, nil
 }
@@ -3,12 +3,17 @@
 package sql
 
 import (
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/jmoiron/sqlx"
+
 	"github.com/sourcegraph/checkup/types"
 )
 
@@ -27,10 +32,7 @@ func TestSQL(t *testing.T) {
 	specimen := Storage{
 		SqliteDBFile: dbFile,
 	}
-
-	if err := specimen.initialize(); err != nil {
-		t.Fatalf("Could not initialize test database, got: %v", err)
-	}
+	defer specimen.Close()
 
 	if err := specimen.Store(results); err != nil {
 		t.Fatalf("Expected no error from Store(), got: %v", err)
@@ -98,3 +100,287 @@ func TestSQL(t *testing.T) {
 		t.Fatalf("Expected not to be able to fetch the result from the DB")
 	}
 }
+
+func TestSQLQuery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkup")
+	if err != nil {
+		t.Fatalf("Cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	specimen := Storage{
+		SqliteDBFile: filepath.Join(dir, "checkuptest.db"),
+	}
+	defer specimen.Close()
+
+	now := time.Now()
+	rounds := [][]types.Result{
+		{
+			{Title: "A", Endpoint: "http://a", Timestamp: now.Add(-2 * time.Hour).UnixNano(), Healthy: true},
+			{Title: "B", Endpoint: "http://b", Timestamp: now.Add(-2 * time.Hour).UnixNano(), Down: true,
+				Times: types.Attempts{{RTT: time.Second, Error: "timeout"}}},
+		},
+		{
+			{Title: "A", Endpoint: "http://a", Timestamp: now.Add(-time.Hour).UnixNano(), Degraded: true},
+			{Title: "B", Endpoint: "http://b", Timestamp: now.Add(-time.Hour).UnixNano(), Healthy: true,
+				Times: types.Attempts{{RTT: time.Millisecond}}},
+		},
+	}
+	for _, results := range rounds {
+		if err := specimen.Store(results); err != nil {
+			t.Fatalf("Expected no error from Store(), got: %v", err)
+		}
+	}
+
+	for i, test := range []struct {
+		query  types.ResultQuery
+		expect []string
+	}{
+		{types.ResultQuery{}, []string{"A", "B", "A", "B"}},
+		{types.ResultQuery{Endpoint: "http://b"}, []string{"B", "B"}},
+		{types.ResultQuery{Title: "A", Status: types.StatusDegraded}, []string{"A"}},
+		{types.ResultQuery{Status: types.StatusDown}, []string{"B"}},
+		{types.ResultQuery{From: now.Add(-90 * time.Minute)}, []string{"A", "B"}},
+		{types.ResultQuery{To: now.Add(-90 * time.Minute)}, []string{"A", "B"}},
+		{types.ResultQuery{Limit: 3}, []string{"A", "B", "A"}},
+	} {
+		results, err := specimen.QueryResults(test.query)
+		if err != nil {
+			t.Fatalf("Test %d: expected no error, got %v", i, err)
+		}
+		if len(results) != len(test.expect) {
+			t.Fatalf("Test %d: expected %d results, got %d", i, len(test.expect), len(results))
+		}
+		for j, result := range results {
+			if result.Title != test.expect[j] {
+				t.Errorf("Test %d: expected result %d to be %s, got %s", i, j, test.expect[j], result.Title)
+			}
+		}
+	}
+
+	// Endpoints have no length limit
+	long := types.Result{Title: "Long", Endpoint: "http://example.com/?q=" + strings.Repeat("x", 300), Healthy: true}
+	if err := specimen.Store([]types.Result{long}); err != nil {
+		t.Fatalf("Expected no error storing a long endpoint, got: %v", err)
+	}
+	results, err := specimen.QueryResults(types.ResultQuery{Endpoint: long.Endpoint})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(results) != 1 || results[0].Title != "Long" {
+		t.Errorf("Expected result with long endpoint, got %+v", results)
+	}
+
+	down, err := specimen.QueryResults(types.ResultQuery{Status: types.StatusDown})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(down[0].Times) != 1 || down[0].Times[0].Error != "timeout" {
+		t.Errorf("Expected attempts of result to be stored, got %+v", down[0].Times)
+	}
+}
+
+func TestSQLMigrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkup")
+	if err != nil {
+		t.Fatalf("Cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	dbFile := filepath.Join(dir, "checkuptest.db")
+
+	// Create a database with the original schema
+	db, err := sqlx.Connect("sqlite3", dbFile)
+	if err != nil {
+		t.Fatalf("Could not create test database, got: %v", err)
+	}
+	for _, st := range migrations[0].statements {
+		if _, err := db.Exec(st); err != nil {
+			t.Fatalf("Could not create legacy schema, got: %v", err)
+		}
+	}
+	results := []types.Result{{Title: "Legacy", Endpoint: "http://legacy", Down: true}}
+	contents, err := json.Marshal(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`INSERT INTO checks (name, timestamp, results) VALUES (?, ?, ?)`,
+		"legacy-check.json", time.Now().UnixNano(), contents)
+	if err != nil {
+		t.Fatalf("Could not insert legacy check, got: %v", err)
+	}
+	db.Close()
+
+	specimen := Storage{
+		SqliteDBFile: dbFile,
+	}
+	defer specimen.Close()
+
+	testResults, err := specimen.Fetch("legacy-check.json")
+	if err != nil {
+		t.Fatalf("Could not fetch migrated check, got: %v", err)
+	}
+	if len(testResults) != 1 || testResults[0].Title != "Legacy" {
+		t.Fatalf("Expected migrated results to be %+v, got %+v", results, testResults)
+	}
+
+	down, err := specimen.QueryResults(types.ResultQuery{Endpoint: "http://legacy", Status: types.StatusDown})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(down) != 1 {
+		t.Fatalf("Expected migrated result to be queryable, got %d results", len(down))
+	}
+
+	// Migrating again must be a no-op
+	db, err = specimen.dbConnect()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if version := schemaVersion(db); version != len(migrations) {
+		t.Errorf("Expected schema version %d, got %d", len(migrations), version)
+	}
+}
+
+func TestSQLRollup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkup")
+	if err != nil {
+		t.Fatalf("Cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	specimen := Storage{
+		SqliteDBFile: filepath.Join(dir, "checkuptest.db"),
+		CheckExpiry:  24 * time.Hour,
+		Rollup:       &types.Rollup{HourlyExpiry: 30 * 24 * time.Hour},
+	}
+	defer specimen.Close()
+
+	today := types.PeriodStart(types.PeriodDay, time.Now())
+	for i, ts := range []time.Time{
+		today.AddDate(0, 0, -3).Add(time.Hour),
+		today.AddDate(0, 0, -3).Add(2 * time.Hour),
+		today.AddDate(0, 0, -60),
+		today,
+	} {
+		results := []types.Result{
+			{Title: "Testing", Endpoint: "http://test", Timestamp: ts.UnixNano(), Healthy: i%2 == 0, Down: i%2 == 1,
+				Times: types.Attempts{{RTT: time.Duration(i+1) * time.Millisecond}}},
+		}
+		if err := specimen.StoreAs(fmt.Sprintf("%d-check.json", i), ts.UnixNano(), results); err != nil {
+			t.Fatalf("Expected no error from StoreAs(), got: %v", err)
+		}
+	}
+
+	// Maintaining twice must not duplicate summaries
+	for i := 0; i < 2; i++ {
+		if err := specimen.Maintain(); err != nil {
+			t.Fatalf("Expected no error from Maintain(), got: %v", err)
+		}
+	}
+
+	index, err := specimen.GetIndex()
+	if err != nil {
+		t.Fatalf("Expected no error from GetIndex(), got: %v", err)
+	}
+	if len(index) != 1 {
+		t.Errorf("Expected only the recent check to be kept, got %v", index)
+	}
+
+	hourly, err := specimen.Summaries(types.PeriodHour, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Expected no error from Summaries(), got: %v", err)
+	}
+	if len(hourly) != 2 || hourly[0].Uptime != 100 || hourly[1].Uptime != 0 {
+		t.Errorf("Expected 2 hourly summaries of the recent day, got %+v", hourly)
+	}
+
+	daily, err := specimen.Summaries(types.PeriodDay, today.AddDate(0, 0, -7), time.Time{})
+	if err != nil {
+		t.Fatalf("Expected no error from Summaries(), got: %v", err)
+	}
+	if len(daily) != 1 {
+		t.Fatalf("Expected 1 daily summary in range, got %+v", daily)
+	}
+	if d := daily[0]; d.Results != 2 || d.Uptime != 50 || d.Min != time.Millisecond || d.Max != 2*time.Millisecond {
+		t.Errorf("Expected summary of both checks of the day, got %+v", d)
+	}
+}
+
+func TestSQLPool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkup")
+	if err != nil {
+		t.Fatalf("Cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	specimen := Storage{
+		Driver: "sqlite3",
+		DSN:    filepath.Join(dir, "checkuptest.db"),
+	}
+	defer specimen.Close()
+
+	db1, err := specimen.dbConnect()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	db2, err := specimen.dbConnect()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if db1 != db2 {
+		t.Errorf("Expected the connection pool to be reused")
+	}
+	if err := specimen.Close(); err != nil {
+		t.Fatalf("Expected no error from Close(), got: %v", err)
+	}
+	db3, err := specimen.dbConnect()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if db3 == db1 {
+		t.Errorf("Expected a new connection pool after Close()")
+	}
+}
+
+func TestSQLDataSource(t *testing.T) {
+	for i, test := range []struct {
+		config string
+		driver string
+		dsn    string
+		err    bool
+	}{
+		{`{"sqlite_db_file": "checkup.db"}`, "sqlite3", "checkup.db", false},
+		{`{"driver": "mysql", "dsn": "user@/checkup"}`, "mysql", "user@/checkup", false},
+		{`{"dsn": "user@/checkup"}`, "", "", true},
+		{`{"driver": "oracle", "dsn": "user@/checkup"}`, "", "", true},
+		{`{"sqlite_db_file": "checkup.db", "driver": "mysql", "dsn": "user@/checkup"}`, "", "", true},
+		{`{"postgresql": {"user": "checkup", "dbname": "checkup", "host": "db"}}`,
+			"postgres", " host=db user=checkup dbname=checkup", false},
+		{`{"mysql": {"user": "checkup", "password": "secret", "dbname": "checkup", "host": "db"}}`,
+			"mysql", "checkup:secret@tcp(db:3306)/checkup", false},
+		{`{"mysql": {"user": "checkup", "dbname": "checkup", "params": {"charset": "utf8mb4"}}}`,
+			"mysql", "checkup@/checkup?charset=utf8mb4", false},
+		{`{"mysql": {"user": "checkup"}}`, "", "", true},
+		{`{}`, "", "", true},
+	} {
+		specimen, err := New([]byte(test.config))
+		if err != nil {
+			t.Fatalf("Test %d: expected no error from New(), got %v", i, err)
+		}
+		driver, dsn, err := specimen.dataSource()
+		if test.err {
+			if err == nil {
+				t.Errorf("Test %d: expected an error, got none", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: expected no error, got %v", i, err)
+			continue
+		}
+		if driver != test.driver || dsn != test.dsn {
+			t.Errorf("Test %d: expected %s %q, got %s %q", i, test.driver, test.dsn, driver, dsn)
+		}
+	}
+}
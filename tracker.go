@@ -0,0 +1,79 @@
+package checkup
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Tracker keeps the state of endpoints across rounds of
+// checks, so that an endpoint with a failure threshold is
+// only reported as down after failing enough consecutive
+// rounds, and as healthy again after passing enough.
+type Tracker struct {
+	mu     sync.Mutex
+	states map[string]*trackerState
+}
+
+// trackerState is the state of one endpoint.
+type trackerState struct {
+	down     bool
+	failures int
+	passes   int
+}
+
+// NewTracker returns a new, empty Tracker.
+func NewTracker() *Tracker {
+	return &Tracker{states: make(map[string]*trackerState)}
+}
+
+// Track records result as the outcome of the latest round
+// of checks of its endpoint and adjusts its status
+// according to o.
+func (t *Tracker) Track(result *types.Result, o types.CheckerOptions) {
+	if o.FailureThreshold <= 1 && o.RecoveryThreshold <= 1 {
+		return
+	}
+	if o.FailureThreshold < 1 {
+		o.FailureThreshold = 1
+	}
+	if o.RecoveryThreshold < 1 {
+		o.RecoveryThreshold = 1
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.states[result.Title]
+	if !ok {
+		s = new(trackerState)
+		t.states[result.Title] = s
+	}
+
+	if result.Down {
+		s.failures++
+		s.passes = 0
+		if s.failures >= o.FailureThreshold {
+			s.down = true
+		}
+		if !s.down {
+			result.Down = false
+			result.Degraded = true
+			result.Notice = fmt.Sprintf("failed %d of %d consecutive rounds before down", s.failures, o.FailureThreshold)
+		}
+	} else {
+		s.passes++
+		s.failures = 0
+		if s.passes >= o.RecoveryThreshold {
+			s.down = false
+		}
+		if s.down {
+			result.Healthy = false
+			result.Degraded = false
+			result.Down = true
+			result.Notice = fmt.Sprintf("passed %d of %d consecutive rounds before healthy", s.passes, o.RecoveryThreshold)
+		}
+	}
+	result.ConsecutiveFailures = s.failures
+}
@@ -8,6 +8,10 @@ import (
 type Attempt struct {
 	RTT   time.Duration `json:"rtt"`
 	Error string        `json:"error,omitempty"`
+
+	// Retries is how many times the attempt was retried
+	// after failing.
+	Retries int `json:"retries,omitempty"`
 }
 
 // Attempts is a list of Attempt that can be sorted by RTT.
@@ -16,3 +20,18 @@ type Attempts []Attempt
 func (a Attempts) Len() int           { return len(a) }
 func (a Attempts) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a Attempts) Less(i, j int) bool { return a[i].RTT < a[j].RTT }
+
+// Retry calls attempt until it succeeds or has been retried
+// retries times. It waits backoff before the first retry
+// and doubles the wait before each subsequent one. The last
+// attempt is returned.
+func Retry(retries int, backoff time.Duration, attempt func() Attempt) Attempt {
+	a := attempt()
+	for i := 1; i <= retries && a.Error != ""; i++ {
+		time.Sleep(backoff)
+		backoff *= 2
+		a = attempt()
+		a.Retries = i
+	}
+	return a
+}
@@ -0,0 +1,61 @@
+package types
+
+// CheckerOptions holds the configuration that is common to
+// all checkers and is handled by the checkup rather than by
+// the checkers themselves. Checkers embed it.
+type CheckerOptions struct {
+	// DependsOn lists the names of the endpoints that this
+	// endpoint depends on. If this endpoint is down while
+	// any of them is down too, its result is suppressed:
+	// it is marked unknown instead of down and notifiers
+	// skip it.
+	DependsOn []string `json:"depends_on,omitempty"`
+
+	// FailureThreshold is how many consecutive rounds of
+	// checks the endpoint must fail before it is reported
+	// as down. Until then, it is reported as degraded.
+	// Default is 1.
+	FailureThreshold int `json:"failure_threshold,omitempty"`
+
+	// RecoveryThreshold is how many consecutive rounds of
+	// checks the endpoint must pass after being reported
+	// as down before it is reported as healthy again.
+	// Until then, it is still reported as down. Default
+	// is 1.
+	RecoveryThreshold int `json:"recovery_threshold,omitempty"`
+
+	// Tags, Group, Owner and Labels are metadata about the
+	// endpoint that are copied into its results. Tags can be
+	// used to select which checks to run, Group to group
+	// endpoints on the status page and Owner to route
+	// notifications.
+	Tags   []string          `json:"tags,omitempty"`
+	Group  string            `json:"group,omitempty"`
+	Owner  string            `json:"owner,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// Options returns the common options of a checker.
+func (o CheckerOptions) Options() CheckerOptions {
+	return o
+}
+
+// Annotate copies the metadata in o into result.
+func (o CheckerOptions) Annotate(result *Result) {
+	result.Tags = o.Tags
+	result.Group = o.Group
+	result.Owner = o.Owner
+	result.Labels = o.Labels
+}
+
+// HasTag returns whether o has any of tags.
+func (o CheckerOptions) HasTag(tags ...string) bool {
+	for _, tag := range tags {
+		for _, t := range o.Tags {
+			if t == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
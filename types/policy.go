@@ -0,0 +1,130 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Policy is how a checker concludes the status of an
+// endpoint from the attempts of a check. The zero value
+// is the default policy: any failed attempt means the
+// endpoint is down, and a median round trip time over the
+// threshold means it is degraded.
+type Policy struct {
+	// DownIfFailuresOver is how many attempts may fail
+	// before the endpoint is down. A value below 1 is a
+	// ratio of the attempts, for example 0.5 for more than
+	// half of them; otherwise it is a count.
+	DownIfFailuresOver float64 `json:"down_if_failures_over,omitempty"`
+
+	// DegradedIfP95Over is the 95th percentile round trip
+	// time over which the endpoint is degraded, in addition
+	// to the checker's threshold.
+	DegradedIfP95Over time.Duration `json:"degraded_if_p95_over,omitempty"`
+
+	// ThresholdStat is the statistic of the round trip
+	// times that is compared with the checker's threshold:
+	// "median" (the default), "mean", "p90", "p95" or "p99".
+	ThresholdStat string `json:"threshold_stat,omitempty"`
+}
+
+// Validate returns an error if p is misconfigured.
+func (p Policy) Validate() error {
+	if p.DownIfFailuresOver < 0 {
+		return fmt.Errorf("invalid down_if_failures_over %v", p.DownIfFailuresOver)
+	}
+	if _, ok := p.stat(Stats{}); !ok {
+		return fmt.Errorf("invalid threshold_stat '%s'", p.ThresholdStat)
+	}
+	return nil
+}
+
+// Failed returns whether enough of times failed for the
+// endpoint to be down. It always is if all of them failed.
+func (p Policy) Failed(times Attempts) bool {
+	var failures int
+	for _, a := range times {
+		if a.Error != "" {
+			failures++
+		}
+	}
+	if failures > 0 && failures == len(times) {
+		return true
+	}
+	over := p.DownIfFailuresOver
+	if over > 0 && over < 1 {
+		over = math.Floor(over * float64(len(times)))
+	}
+	return float64(failures) > over
+}
+
+// Slow returns a notice if the round trip times of result
+// exceed threshold or DegradedIfP95Over, in which case the
+// endpoint is degraded. Otherwise it returns "".
+func (p Policy) Slow(result Result, threshold time.Duration) string {
+	if len(result.Times) == 0 || (threshold <= 0 && p.DegradedIfP95Over <= 0) {
+		return ""
+	}
+	stats := result.ComputeStats()
+	if threshold > 0 {
+		if rtt, _ := p.stat(stats); rtt > threshold {
+			return fmt.Sprintf("%s round trip time exceeded threshold (%s)", p.statName(), threshold)
+		}
+	}
+	if p.DegradedIfP95Over > 0 && stats.P95 > p.DegradedIfP95Over {
+		return fmt.Sprintf("p95 round trip time exceeded threshold (%s)", p.DegradedIfP95Over)
+	}
+	return ""
+}
+
+// Conclude makes the conclusion about the status of result
+// from its attempts, with threshold as the maximum round
+// trip time to allow for a healthy endpoint.
+func (p Policy) Conclude(result Result, threshold time.Duration) Result {
+	result.ThresholdRTT = threshold
+
+	// Check errors (down)
+	if p.Failed(result.Times) {
+		result.Down = true
+		return result
+	}
+
+	// Check round trip time (degraded)
+	if notice := p.Slow(result, threshold); notice != "" {
+		result.Notice = notice
+		result.Degraded = true
+		return result
+	}
+
+	result.Healthy = true
+	return result
+}
+
+// statName returns the name of the statistic that is
+// compared with the threshold.
+func (p Policy) statName() string {
+	if p.ThresholdStat == "" {
+		return "median"
+	}
+	return p.ThresholdStat
+}
+
+// stat returns the statistic of stats that is compared
+// with the threshold, and whether p.ThresholdStat is
+// valid.
+func (p Policy) stat(stats Stats) (time.Duration, bool) {
+	switch p.statName() {
+	case "median":
+		return stats.Median, true
+	case "mean":
+		return stats.Mean, true
+	case "p90":
+		return stats.P90, true
+	case "p95":
+		return stats.P95, true
+	case "p99":
+		return stats.P99, true
+	}
+	return 0, false
+}
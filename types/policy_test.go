@@ -0,0 +1,92 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func attempts(rtts ...time.Duration) Attempts {
+	var a Attempts
+	for _, rtt := range rtts {
+		a = append(a, Attempt{RTT: rtt})
+	}
+	return a
+}
+
+func TestPolicyFailed(t *testing.T) {
+	times := attempts(1, 1, 1, 1, 1, 1, 1, 1, 1, 1)
+	for i, test := range []struct {
+		over     float64
+		failures int
+		want     bool
+	}{
+		{0, 0, false},
+		{0, 1, true},
+		{2, 2, false},
+		{2, 3, true},
+		{0.5, 5, false},
+		{0.5, 6, true},
+		{20, 10, true},
+	} {
+		for j := range times {
+			times[j].Error = ""
+			if j < test.failures {
+				times[j].Error = "failed"
+			}
+		}
+		p := Policy{DownIfFailuresOver: test.over}
+		if got := p.Failed(times); got != test.want {
+			t.Errorf("Test %d: expected failed to be %v, got %v", i, test.want, got)
+		}
+	}
+}
+
+func TestPolicyConclude(t *testing.T) {
+	times := attempts(1, 1, 1, 1, 1, 1, 1, 1, 1, 100)
+	for i, test := range []struct {
+		policy    Policy
+		threshold time.Duration
+		want      StatusText
+		notice    string
+	}{
+		{Policy{}, 0, StatusHealthy, ""},
+		{Policy{}, 10, StatusHealthy, ""},
+		{Policy{ThresholdStat: "mean"}, 5, StatusDegraded, "mean round trip time exceeded threshold (5ns)"},
+		{Policy{ThresholdStat: "p90"}, 10, StatusHealthy, ""},
+		{Policy{ThresholdStat: "p99"}, 10, StatusDegraded, "p99 round trip time exceeded threshold (10ns)"},
+		{Policy{DegradedIfP95Over: 50}, 0, StatusDegraded, "p95 round trip time exceeded threshold (50ns)"},
+	} {
+		result := test.policy.Conclude(Result{Times: times}, test.threshold)
+		if got := result.Status(); got != test.want {
+			t.Errorf("Test %d: expected status %s, got %s", i, test.want, got)
+		}
+		if got := result.Notice; got != test.notice {
+			t.Errorf("Test %d: expected notice '%s', got '%s'", i, test.notice, got)
+		}
+		if got := result.ThresholdRTT; got != test.threshold {
+			t.Errorf("Test %d: expected threshold %s, got %s", i, test.threshold, got)
+		}
+	}
+
+	times[0].Error = "failed"
+	if result := (Policy{}).Conclude(Result{Times: times}, 0); !result.Down {
+		t.Errorf("Expected down result with default policy, got %s", result.Status())
+	}
+	if result := (Policy{DownIfFailuresOver: 1}).Conclude(Result{Times: times}, 0); !result.Healthy {
+		t.Errorf("Expected healthy result with one failure tolerated, got %s", result.Status())
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	if err := (Policy{ThresholdStat: "p95"}).Validate(); err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	for i, p := range []Policy{
+		{ThresholdStat: "p50"},
+		{DownIfFailuresOver: -1},
+	} {
+		if err := p.Validate(); err == nil {
+			t.Errorf("Test %d: expected an error", i)
+		}
+	}
+}
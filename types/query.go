@@ -0,0 +1,27 @@
+package types
+
+import (
+	"time"
+)
+
+// ResultQuery selects results from a storage that can query
+// them. Zero-valued fields match any result.
+type ResultQuery struct {
+	// Title is the title of the endpoint of the results.
+	Title string `json:"title,omitempty"`
+
+	// Endpoint is the endpoint of the results.
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Status is the status of the results.
+	Status StatusText `json:"status,omitempty"`
+
+	// From and To restrict the results to those with a
+	// timestamp at or after From and before To.
+	From time.Time `json:"from,omitempty"`
+	To   time.Time `json:"to,omitempty"`
+
+	// Limit is the maximum number of results to return.
+	// If zero, all matching results are returned.
+	Limit int `json:"limit,omitempty"`
+}
@@ -2,6 +2,7 @@ package types
 
 import (
 	"fmt"
+	"math"
 	"sort"
 	"time"
 
@@ -35,6 +36,17 @@ type Result struct {
 	Degraded bool `json:"degraded,omitempty"`
 	Down     bool `json:"down,omitempty"`
 
+	// Suppressed is true if the endpoint was down while an
+	// endpoint it depends on was down too. Its status is then
+	// unknown rather than down, and notifiers skip it.
+	Suppressed bool `json:"suppressed,omitempty"`
+
+	// ConsecutiveFailures is how many rounds of checks in a
+	// row, including this one, the endpoint has failed. It
+	// is only tracked for checkers with a failure or
+	// recovery threshold.
+	ConsecutiveFailures int `json:"consecutive_failures,omitempty"`
+
 	// Notice contains a description of some condition of this
 	// check that might have affected the result in some way.
 	// For example, that the median RTT is above the threshold.
@@ -43,6 +55,13 @@ type Result struct {
 	// Message is an optional message to show on the status page.
 	// For example, what you're doing to fix a problem.
 	Message string `json:"message,omitempty"`
+
+	// Tags, Group, Owner and Labels are the metadata of the
+	// checker that produced the result.
+	Tags   []string          `json:"tags,omitempty"`
+	Group  string            `json:"group,omitempty"`
+	Owner  string            `json:"owner,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 func NewResult() Result {
@@ -77,6 +96,17 @@ func (r Result) ComputeStats() Stats {
 
 	s.Mean = time.Duration(int64(s.Total) / int64(len(r.Times)))
 
+	s.P90 = percentile(sorted, 90)
+	s.P95 = percentile(sorted, 95)
+	s.P99 = percentile(sorted, 99)
+
+	var variance float64
+	for _, a := range r.Times {
+		d := float64(a.RTT - s.Mean)
+		variance += d * d
+	}
+	s.StdDev = time.Duration(math.Sqrt(variance / float64(len(r.Times))))
+
 	return s
 }
 
@@ -94,6 +124,8 @@ func (r Result) String() string {
 	s += fmt.Sprintf("        Min: %s\n", stats.Min)
 	s += fmt.Sprintf("     Median: %s\n", stats.Median)
 	s += fmt.Sprintf("       Mean: %s\n", stats.Mean)
+	s += fmt.Sprintf("        P95: %s\n", stats.P95)
+	s += fmt.Sprintf("     StdDev: %s\n", stats.StdDev)
 	s += fmt.Sprintf("        All: %v\n", r.Times)
 	statusLine := fmt.Sprintf(" Assessment: %v\n", r.Status())
 	switch r.Status() {
@@ -108,6 +140,20 @@ func (r Result) String() string {
 	return s
 }
 
+// OwnedBy returns whether r is owned by any of owners. Any
+// result is if owners is empty.
+func (r Result) OwnedBy(owners []string) bool {
+	if len(owners) == 0 {
+		return true
+	}
+	for _, owner := range owners {
+		if r.Owner == owner {
+			return true
+		}
+	}
+	return false
+}
+
 // Status returns a text representation of the overall status
 // indicated in r.
 func (r Result) Status() StatusText {
@@ -12,4 +12,23 @@ type Stats struct {
 	Median time.Duration `json:"median,omitempty"`
 	Min    time.Duration `json:"min,omitempty"`
 	Max    time.Duration `json:"max,omitempty"`
+
+	// P90, P95 and P99 are the 90th, 95th and 99th
+	// percentiles, using the nearest-rank method.
+	P90 time.Duration `json:"p90,omitempty"`
+	P95 time.Duration `json:"p95,omitempty"`
+	P99 time.Duration `json:"p99,omitempty"`
+
+	// StdDev is the population standard deviation.
+	StdDev time.Duration `json:"stddev,omitempty"`
+}
+
+// percentile returns the pth percentile of sorted, using
+// the nearest-rank method.
+func percentile(sorted Attempts, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1].RTT
 }
@@ -0,0 +1,202 @@
+package types
+
+import (
+	"sort"
+	"time"
+)
+
+// Periods of time that results are summarized over.
+const (
+	PeriodHour = "hour"
+	PeriodDay  = "day"
+)
+
+// Summary summarizes the results of an endpoint over a
+// period of time, so that uptime history can be kept after
+// the results themselves are deleted.
+type Summary struct {
+	Title    string `json:"title,omitempty"`
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Period is the period of time summarized, PeriodHour
+	// or PeriodDay, starting at Start; UTC UnixNano format.
+	Period string `json:"period"`
+	Start  int64  `json:"start"`
+
+	// Results is the number of results summarized, and
+	// Healthy, Degraded, Down and Unknown the number of
+	// them with each status.
+	Results  int `json:"results"`
+	Healthy  int `json:"healthy,omitempty"`
+	Degraded int `json:"degraded,omitempty"`
+	Down     int `json:"down,omitempty"`
+	Unknown  int `json:"unknown,omitempty"`
+
+	// Uptime is the percentage of the results with a known
+	// status that were not down.
+	Uptime float64 `json:"uptime"`
+
+	// RTTs is the number of round trip times of successful
+	// attempts of the results, and Min, Median, P95 and Max
+	// are statistics of them.
+	RTTs   int           `json:"rtts,omitempty"`
+	Min    time.Duration `json:"min,omitempty"`
+	Median time.Duration `json:"median,omitempty"`
+	P95    time.Duration `json:"p95,omitempty"`
+	Max    time.Duration `json:"max,omitempty"`
+}
+
+// Rollup configures the summaries that a storage keeps of
+// the results it deletes when it is maintained.
+type Rollup struct {
+	// HourlyExpiry and DailyExpiry are how long hourly and
+	// daily summaries are kept. If zero, they are kept
+	// forever.
+	HourlyExpiry time.Duration `json:"hourly_expiry,omitempty"`
+	DailyExpiry  time.Duration `json:"daily_expiry,omitempty"`
+}
+
+// Expiry returns how long summaries of period are kept.
+func (r Rollup) Expiry(period string) time.Duration {
+	if period == PeriodHour {
+		return r.HourlyExpiry
+	}
+	return r.DailyExpiry
+}
+
+// PeriodDuration returns the duration of period.
+func PeriodDuration(period string) time.Duration {
+	if period == PeriodHour {
+		return time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// PeriodStart returns the start of the period that t is
+// in. Periods are aligned on UTC hours and days.
+func PeriodStart(period string, t time.Time) time.Time {
+	return t.UTC().Truncate(PeriodDuration(period))
+}
+
+// Summarize summarizes results per endpoint and period,
+// ordered by start, title and endpoint.
+func Summarize(results []Result, period string) []Summary {
+	type key struct {
+		title, endpoint string
+		start           int64
+	}
+	summaries := make(map[key]*Summary)
+	rtts := make(map[key]Attempts)
+	var keys []key
+
+	for _, r := range results {
+		k := key{r.Title, r.Endpoint, PeriodStart(period, time.Unix(0, r.Timestamp)).UnixNano()}
+		s, ok := summaries[k]
+		if !ok {
+			s = &Summary{Title: r.Title, Endpoint: r.Endpoint, Period: period, Start: k.start}
+			summaries[k] = s
+			keys = append(keys, k)
+		}
+		s.Results++
+		switch r.Status() {
+		case StatusHealthy:
+			s.Healthy++
+		case StatusDegraded:
+			s.Degraded++
+		case StatusDown:
+			s.Down++
+		default:
+			s.Unknown++
+		}
+		for _, a := range r.Times {
+			if a.Error == "" {
+				rtts[k] = append(rtts[k], a)
+			}
+		}
+	}
+
+	list := make([]Summary, len(keys))
+	for i, k := range keys {
+		s := summaries[k]
+		s.computeUptime()
+		if s.RTTs = len(rtts[k]); s.RTTs > 0 {
+			stats := Result{Times: rtts[k]}.ComputeStats()
+			s.Min, s.Median, s.P95, s.Max = stats.Min, stats.Median, stats.P95, stats.Max
+		}
+		list[i] = *s
+	}
+	sortSummaries(list)
+	return list
+}
+
+// MergeSummaries combines the summaries of a and b that are
+// of the same endpoint and period, such as those of check
+// files summarized at different times, and returns them
+// ordered by start, title and endpoint. The Median and P95
+// of combined summaries are the averages of theirs weighted
+// by their RTTs, so they are approximate.
+func MergeSummaries(a, b []Summary) []Summary {
+	type key struct {
+		title, endpoint, period string
+		start                   int64
+	}
+	index := make(map[key]int)
+	var list []Summary
+	for _, s := range append(append([]Summary(nil), a...), b...) {
+		k := key{s.Title, s.Endpoint, s.Period, s.Start}
+		i, ok := index[k]
+		if !ok {
+			index[k] = len(list)
+			list = append(list, s)
+			continue
+		}
+		m := &list[i]
+		m.Results += s.Results
+		m.Healthy += s.Healthy
+		m.Degraded += s.Degraded
+		m.Down += s.Down
+		m.Unknown += s.Unknown
+		m.computeUptime()
+		if s.RTTs == 0 {
+			continue
+		}
+		if m.RTTs == 0 {
+			m.RTTs, m.Min, m.Median, m.P95, m.Max = s.RTTs, s.Min, s.Median, s.P95, s.Max
+			continue
+		}
+		rtts := m.RTTs + s.RTTs
+		m.Median = (m.Median*time.Duration(m.RTTs) + s.Median*time.Duration(s.RTTs)) / time.Duration(rtts)
+		m.P95 = (m.P95*time.Duration(m.RTTs) + s.P95*time.Duration(s.RTTs)) / time.Duration(rtts)
+		if s.Min < m.Min {
+			m.Min = s.Min
+		}
+		if s.Max > m.Max {
+			m.Max = s.Max
+		}
+		m.RTTs = rtts
+	}
+	sortSummaries(list)
+	return list
+}
+
+// computeUptime sets s.Uptime from its counts of results.
+func (s *Summary) computeUptime() {
+	s.Uptime = 0
+	if known := s.Results - s.Unknown; known > 0 {
+		s.Uptime = 100 * float64(s.Healthy+s.Degraded) / float64(known)
+	}
+}
+
+// sortSummaries orders summaries by start, title and endpoint.
+func sortSummaries(summaries []Summary) {
+	sort.Slice(summaries, func(i, j int) bool {
+		a, b := summaries[i], summaries[j]
+		if a.Start != b.Start {
+			return a.Start < b.Start
+		}
+		if a.Title != b.Title {
+			return a.Title < b.Title
+		}
+		return a.Endpoint < b.Endpoint
+	})
+}
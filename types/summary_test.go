@@ -0,0 +1,84 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	day := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) int64 { return day.Add(d).UnixNano() }
+	results := []Result{
+		{Title: "B", Endpoint: "http://b", Timestamp: at(10 * time.Minute), Down: true,
+			Times: Attempts{{RTT: time.Second, Error: "timeout"}}},
+		{Title: "A", Endpoint: "http://a", Timestamp: at(10 * time.Minute), Healthy: true,
+			Times: attempts(4*time.Millisecond, 2*time.Millisecond)},
+		{Title: "A", Endpoint: "http://a", Timestamp: at(50 * time.Minute), Degraded: true,
+			Times: attempts(6 * time.Millisecond)},
+		{Title: "A", Endpoint: "http://a", Timestamp: at(70 * time.Minute), Down: true},
+		{Title: "A", Endpoint: "http://a", Timestamp: at(80 * time.Minute)},
+	}
+
+	hourly := Summarize(results, PeriodHour)
+	if got, want := len(hourly), 3; got != want {
+		t.Fatalf("Expected %d hourly summaries, got %d: %+v", want, got, hourly)
+	}
+	a := hourly[0]
+	if a.Title != "A" || a.Start != day.UnixNano() || a.Period != PeriodHour {
+		t.Fatalf("Expected first summary to be of A at %s, got %+v", day, a)
+	}
+	if a.Results != 2 || a.Healthy != 1 || a.Degraded != 1 || a.Uptime != 100 {
+		t.Errorf("Expected 2 results up, got %+v", a)
+	}
+	if a.Min != 2*time.Millisecond || a.Median != 4*time.Millisecond || a.Max != 6*time.Millisecond {
+		t.Errorf("Expected RTT statistics of all attempts, got %+v", a)
+	}
+	if b := hourly[1]; b.Title != "B" || b.Down != 1 || b.Uptime != 0 || b.Max != 0 {
+		t.Errorf("Expected B to be down without RTTs, got %+v", b)
+	}
+	if a := hourly[2]; a.Start != day.Add(time.Hour).UnixNano() || a.Down != 1 || a.Unknown != 1 || a.Uptime != 0 {
+		t.Errorf("Expected second hour of A to be down, got %+v", a)
+	}
+
+	daily := Summarize(results, PeriodDay)
+	if got, want := len(daily), 2; got != want {
+		t.Fatalf("Expected %d daily summaries, got %d: %+v", want, got, daily)
+	}
+	if a := daily[0]; a.Results != 4 || a.Unknown != 1 || a.Uptime < 66 || a.Uptime > 67 {
+		t.Errorf("Expected A to be up two thirds of the day, got %+v", a)
+	}
+}
+
+func TestMergeSummaries(t *testing.T) {
+	a := []Summary{
+		{Title: "A", Period: PeriodHour, Start: 1, Results: 2, Healthy: 2, Uptime: 100,
+			RTTs: 3, Min: 2, Median: 10, P95: 20, Max: 30},
+		{Title: "B", Period: PeriodHour, Start: 1, Results: 1, Down: 1},
+	}
+	b := []Summary{
+		{Title: "A", Period: PeriodHour, Start: 1, Results: 2, Down: 1, Unknown: 1,
+			RTTs: 1, Min: 1, Median: 30, P95: 40, Max: 40},
+		{Title: "A", Period: PeriodHour, Start: 0, Results: 1, Healthy: 1, Uptime: 100},
+	}
+
+	merged := MergeSummaries(a, b)
+	if got, want := len(merged), 3; got != want {
+		t.Fatalf("Expected %d summaries, got %d: %+v", want, got, merged)
+	}
+	if merged[0].Start != 0 || merged[1].Title != "A" || merged[2].Title != "B" {
+		t.Errorf("Expected summaries ordered by start and title, got %+v", merged)
+	}
+	m := merged[1]
+	if m.Results != 4 || m.Healthy != 2 || m.Down != 1 || m.Unknown != 1 {
+		t.Errorf("Expected counts to add up, got %+v", m)
+	}
+	if m.Uptime < 66 || m.Uptime > 67 {
+		t.Errorf("Expected uptime of the known results, got %v", m.Uptime)
+	}
+	if m.RTTs != 4 || m.Min != 1 || m.Max != 40 || m.Median != 15 || m.P95 != 25 {
+		t.Errorf("Expected RTT statistics to be combined, got %+v", m)
+	}
+	if a[0].Results != 2 {
+		t.Errorf("Expected summaries merged to be left unchanged, got %+v", a[0])
+	}
+}